@@ -2,18 +2,32 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
+	"github.com/cleeryy/clarr/internal/audit"
+	"github.com/cleeryy/clarr/internal/auth"
 	"github.com/cleeryy/clarr/internal/cleaner"
 	"github.com/cleeryy/clarr/internal/config"
+	"github.com/cleeryy/clarr/internal/deluge"
+	"github.com/cleeryy/clarr/internal/health"
+	"github.com/cleeryy/clarr/internal/jobs"
+	"github.com/cleeryy/clarr/internal/lidarr"
+	"github.com/cleeryy/clarr/internal/metrics"
 	"github.com/cleeryy/clarr/internal/qbittorrent"
+	"github.com/cleeryy/clarr/internal/queue"
 	"github.com/cleeryy/clarr/internal/radarr"
+	"github.com/cleeryy/clarr/internal/readarr"
+	"github.com/cleeryy/clarr/internal/sabnzbd"
 	"github.com/cleeryy/clarr/internal/sonarr"
+	"github.com/cleeryy/clarr/internal/transmission"
+	"github.com/cleeryy/clarr/internal/trash"
 	"github.com/cleeryy/clarr/internal/webhook"
 	"github.com/gin-gonic/gin"
 	"github.com/robfig/cron/v3"
@@ -22,6 +36,15 @@ import (
 
 var version = "dev"
 
+// rescanTimeout borne l'attente d'un rescan complet via /api/rescan.
+const rescanTimeout = 30 * time.Minute
+
+// jobHistory est le nombre de runs terminés gardés pour /api/jobs.
+const jobHistory = 100
+
+// drainTimeout borne l'attente des runs en cours à l'arrêt.
+const drainTimeout = 30 * time.Second
+
 func main() {
 	// ─── Logger ───────────────────────────────────────────────────────
 	logger, _ := zap.NewProduction()
@@ -40,114 +63,348 @@ func main() {
 		logger.Fatal("failed to load config", zap.Error(err))
 	}
 
+	// ─── Metrics ──────────────────────────────────────────────────────
+	// Les clients des *arr et du client de téléchargement sont mesurés
+	// dès leur création.
+	promMetrics := metrics.New()
+
 	// ─── Clients ──────────────────────────────────────────────────────
-	radarrClient := radarr.New(cfg.Radarr.URL, cfg.Radarr.APIKey)
-	sonarrClient := sonarr.New(cfg.Sonarr.URL, cfg.Sonarr.APIKey)
+	radarrInstances := newInstances(promMetrics, "radarr", cfg.Radarr.All(), radarr.New)
+	sonarrInstances := newInstances(promMetrics, "sonarr", cfg.Sonarr.All(), sonarr.New)
+	lidarrInstances := newInstances(promMetrics, "lidarr", cfg.Lidarr.All(), lidarr.New)
+	readarrInstances := newInstances(promMetrics, "readarr", cfg.Readarr.All(), readarr.New)
 
-	qbitClient, err := qbittorrent.New(
-		cfg.Qbittorrent.URL,
-		cfg.Qbittorrent.Username,
-		cfg.Qbittorrent.Password,
-	)
+	// Lidarr et Readarr sont optionnels.
+	var webhookOpts []webhook.Option
+	if len(lidarrInstances) > 0 {
+		webhookOpts = append(webhookOpts, webhook.WithLidarr(lidarrInstances...))
+	}
+	if len(readarrInstances) > 0 {
+		webhookOpts = append(webhookOpts, webhook.WithReadarr(readarrInstances...))
+	}
+
+	// Plex et Emby n'exposent leur webhook que si un token est configuré.
+	if cfg.Plex.WebhookToken != "" {
+		webhookOpts = append(webhookOpts, webhook.WithPlex(cfg.Plex.WebhookToken))
+	}
+	if cfg.Emby.WebhookToken != "" {
+		webhookOpts = append(webhookOpts, webhook.WithEmby(cfg.Emby.WebhookToken))
+	}
+
+	// Chaque instance de chaque application est rescannée par /api/rescan.
+	var rescanTargets []rescanTarget
+	rescanTargets = append(rescanTargets, newRescanTargets("radarr", radarrInstances, (*radarr.Client).RescanAll, (*radarr.Client).WaitForCommand)...)
+	rescanTargets = append(rescanTargets, newRescanTargets("sonarr", sonarrInstances, (*sonarr.Client).RescanAll, (*sonarr.Client).WaitForCommand)...)
+	rescanTargets = append(rescanTargets, newRescanTargets("lidarr", lidarrInstances, (*lidarr.Client).RescanAll, (*lidarr.Client).WaitForCommand)...)
+	rescanTargets = append(rescanTargets, newRescanTargets("readarr", readarrInstances, (*readarr.Client).RescanAll, (*readarr.Client).WaitForCommand)...)
+
+	downloadClient, err := newDownloadClient(cfg, promMetrics, logger)
 	if err != nil {
-		logger.Fatal("failed to connect to qbittorrent", zap.Error(err))
+		logger.Fatal("failed to create download client", zap.Error(err))
 	}
 
+	// ─── Job Queue ────────────────────────────────────────────────────
+	jobQueue, err := queue.Open(cfg.Data.Dir, queue.Options{
+		Workers:       cfg.Queue.Workers,
+		MaxAttempts:   cfg.Queue.MaxAttempts,
+		RetryDelay:    cfg.Queue.RetryDelay,
+		MaxRetryDelay: cfg.Queue.MaxRetryDelay,
+		DeadRetention: cfg.Queue.DeadRetention,
+	}, logger)
+	if err != nil {
+		logger.Fatal("failed to open job queue", zap.Error(err))
+	}
+
+	// ─── Audit ────────────────────────────────────────────────────────
+	auditLog, err := audit.Open(cfg.Data.Dir, logger)
+	if err != nil {
+		logger.Fatal("failed to open audit log", zap.Error(err))
+	}
+	webhookOpts = append(webhookOpts, webhook.WithAudit(auditLog), webhook.WithMetrics(promMetrics))
+
 	// ─── Cleaner ──────────────────────────────────────────────────────
-	cleanerSvc := cleaner.New(cfg.Cleaner.DownloadDir, cfg.Cleaner.DryRun, qbitClient, logger)
+	sightings, err := cleaner.OpenSightings(cfg.Data.Dir)
+	if err != nil {
+		logger.Fatal("failed to open cleaner state", zap.Error(err))
+	}
+
+	cleanerOpts := []cleaner.Option{
+		cleaner.WithSeedingPolicy(seedingPolicy(cfg.Cleaner.Seeding)),
+		cleaner.WithMediaRoots(cfg.Cleaner.MediaRoots...),
+		cleaner.WithSafetyPolicy(cleaner.SafetyPolicy{
+			MinAge:         cfg.Cleaner.Safety.MinAge,
+			SkipIncomplete: cfg.Cleaner.Safety.SkipIncomplete,
+			TempSuffixes:   cfg.Cleaner.Safety.TempSuffixes,
+			GraceScans:     cfg.Cleaner.Safety.GraceScans,
+		}),
+		cleaner.WithSightings(sightings),
+		cleaner.WithStatsCache(cfg.API.StatsCacheTTL),
+		cleaner.WithAudit(auditLog),
+		cleaner.WithFilter(cleaner.Filter{
+			Include:    cfg.Cleaner.Include,
+			Exclude:    cfg.Cleaner.Exclude,
+			Extensions: cfg.Cleaner.Extensions,
+			MinSize:    cfg.Cleaner.MinSize,
+		}),
+	}
+	var trashBin *trash.Trash
+	if cfg.Cleaner.Trash.Enabled {
+		trashBin, err = trash.Open(cfg.Cleaner.Trash.Dir, cfg.Cleaner.Trash.Retention, logger, trash.WithAudit(auditLog))
+		if err != nil {
+			logger.Fatal("failed to open trash", zap.Error(err))
+		}
+		cleanerOpts = append(cleanerOpts, cleaner.WithTrash(trashBin))
+	}
+	if cfg.Cleaner.Detection == "copy" {
+		// Sans hardlinks, l'historique d'import des *arr dit où sont les copies.
+		var sources []cleaner.ImportSource
+		for _, inst := range radarrInstances {
+			sources = append(sources, inst.Client)
+		}
+		for _, inst := range sonarrInstances {
+			sources = append(sources, inst.Client)
+		}
+		cleanerOpts = append(cleanerOpts, cleaner.WithDetector(cleaner.NewCopyDetector(cfg.Cleaner.MediaRoots, sources, logger)))
+	}
+	cleanerSvc := cleaner.New(cfg.Cleaner.DownloadDir, cfg.Cleaner.DryRun, downloadClient, logger, cleanerOpts...)
+
+	// ─── Jobs ─────────────────────────────────────────────────────────
+	tracker := jobs.New(jobHistory, logger)
+
+	cleanupMode := metrics.ModeDelete
+	switch {
+	case cfg.Cleaner.DryRun:
+		cleanupMode = metrics.ModeDryRun
+	case trashBin != nil:
+		cleanupMode = metrics.ModeTrash
+	}
+
+	// cleanupJob adapte un cleanup au tracker de jobs : complet, ou limité
+	// à roots. trigger est le déclencheur journalisé par l'audit et compté
+	// dans les métriques.
+	cleanupJob := func(trigger string, roots []string) func() (any, error) {
+		return func() (any, error) {
+			ctx := audit.WithTrigger(context.Background(), trigger)
+			start := time.Now()
+			var result *cleaner.CleanupResult
+			var err error
+			if len(roots) == 0 {
+				result, err = cleanerSvc.Cleanup(ctx)
+			} else {
+				result, err = cleanerSvc.CleanupPaths(ctx, roots)
+			}
+			if err != nil {
+				promMetrics.ObserveCleanup(trigger, cleanupMode, time.Since(start), 0, 0, err)
+				return nil, err
+			}
+
+			promMetrics.ObserveCleanup(trigger, cleanupMode, time.Since(start), len(result.OrphanFiles), result.FreedBytes, nil)
+			// Un cleanup ciblé ne voit qu'une partie du downloadDir.
+			if len(roots) == 0 {
+				backlog := result.Backlog()
+				promMetrics.SetBacklog(len(backlog), cleaner.TotalSize(backlog))
+			}
+			logger.Info("cleanup done",
+				zap.Int("orphans", len(result.OrphanFiles)),
+				zap.String("freed", result.FreedBytesHuman()),
+				zap.Int("errors", len(result.Errors)),
+			)
+			return result, nil
+		}
+	}
+
+	runRescan := func() (any, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), rescanTimeout)
+		defer cancel()
+
+		// Les rescans tournent en parallèle côté *arr,
+		// on attend ensuite la fin de chacun.
+		commands := make([]int, len(rescanTargets))
+		errs := make([]error, len(rescanTargets))
+		for i, t := range rescanTargets {
+			commands[i], errs[i] = t.start()
+		}
+
+		results := make([]rescanResult, len(rescanTargets))
+		for i, t := range rescanTargets {
+			if errs[i] == nil {
+				errs[i] = t.wait(ctx, commands[i])
+			}
+			results[i] = newRescanResult(t.app, t.instance, commands[i], errs[i])
+		}
+		return results, errors.Join(errs...)
+	}
 
 	// ─── Scheduler ────────────────────────────────────────────────────
-	c := cron.New()
+	// Le tick attend la fin du cleanup : SkipIfStillRunning saute alors les
+	// ticks qui tombent pendant un run encore en cours.
+	c := cron.New(cron.WithChain(cron.SkipIfStillRunning(cronLogger{logger.Sugar()})))
 	_, err = c.AddFunc(cfg.Cleaner.Schedule, func() {
-		logger.Info("scheduled cleanup starting")
-		result, err := cleanerSvc.Cleanup()
-		if err != nil {
-			logger.Error("scheduled cleanup failed", zap.Error(err))
+		job, started := tracker.TryStart("cleanup", "cron", cleanupJob(audit.TriggerCron, nil))
+		if !started {
+			promMetrics.SkipCleanup(audit.TriggerCron, cleanupMode)
+			logger.Warn("scheduled cleanup skipped, another cleanup is running",
+				zap.String("running_job_id", job.ID),
+				zap.String("running_trigger", job.Trigger),
+			)
 			return
 		}
-		logger.Info("scheduled cleanup done",
-			zap.Int("orphans", len(result.OrphanFiles)),
-			zap.String("freed", result.FreedBytesHuman()),
-			zap.Int("errors", len(result.Errors)),
-		)
+		logger.Info("scheduled cleanup starting", zap.String("job_id", job.ID))
+		tracker.Wait(job.ID)
 	})
 	if err != nil {
 		logger.Fatal("invalid cron schedule", zap.Error(err))
 	}
 	c.Start()
-	defer c.Stop()
+
+	// ─── Health ───────────────────────────────────────────────────────
+	// Toutes les dépendances configurées sont critiques, sauf celles
+	// listées dans health.optional.
+	optional := make(map[string]bool, len(cfg.Health.Optional))
+	for _, name := range cfg.Health.Optional {
+		optional[name] = true
+	}
+	// Les hardlinks imposent un seul système de fichiers avec les bibliothèques.
+	var sameFS []string
+	if cfg.Cleaner.Detection != "copy" {
+		sameFS = cfg.Cleaner.MediaRoots
+	}
+	healthChecks := []health.Check{{
+		Name:     "download_dir",
+		Critical: !optional["download_dir"],
+		Probe:    health.DownloadDir(cfg.Cleaner.DownloadDir, sameFS, cfg.Health.RequireMount),
+	}}
+	if v, ok := downloadClient.(versioner); ok {
+		healthChecks = append(healthChecks, health.Check{Name: cfg.DownloadClient, Critical: !optional[cfg.DownloadClient], Probe: v.Version})
+	}
+	healthChecks = append(healthChecks, instanceChecks("radarr", radarrInstances, optional)...)
+	healthChecks = append(healthChecks, instanceChecks("sonarr", sonarrInstances, optional)...)
+	healthChecks = append(healthChecks, instanceChecks("lidarr", lidarrInstances, optional)...)
+	healthChecks = append(healthChecks, instanceChecks("readarr", readarrInstances, optional)...)
+	checker := health.New(cfg.Health.CacheTTL, cfg.Health.Timeout, healthChecks...)
 
 	// ─── Router ───────────────────────────────────────────────────────
 	gin.SetMode(gin.ReleaseMode)
 	r := gin.New()
 	r.Use(gin.Recovery())
-	r.Use(ginZapLogger(logger))
+	r.Use(ginZapLogger(logger, promMetrics))
+
+	// Clés d'API sur /api/* : les webhooks gardent leur propre
+	// authentification, /health et /metrics restent ouverts.
+	keys := apiKeys(cfg.API)
+	switch {
+	case cfg.API.AuthDisabled:
+		logger.Warn("management API authentication disabled, anyone who can reach clarr can trigger deletions")
+	case len(keys) == 0:
+		logger.Warn("no API key configured, management API is read-only; set CLARR_API_KEY to enable cleanup, rescan and trash actions")
+		r.Use(auth.Middleware("/api/", nil, logger))
+	default:
+		r.Use(auth.Middleware("/api/", keys, logger))
+	}
 
 	// Health check.
 	r.GET("/health", func(ctx *gin.Context) {
-		ctx.JSON(http.StatusOK, gin.H{
+		body := gin.H{
 			"status":  "ok",
 			"service": "clarr",
 			"version": version,
-		})
+		}
+		if s, ok := downloadClient.(statusReporter); ok {
+			if err := s.LastError(); err != nil {
+				body["status"] = "degraded"
+				body[cfg.DownloadClient] = err.Error()
+			}
+		}
+		ctx.JSON(http.StatusOK, body)
 	})
 
-	// Webhook Jellyfin.
-	webhookHandler := webhook.New(cfg.Jellyfin.WebhookSecret, radarrClient, sonarrClient, logger)
+	// Liveness et readiness, pour les healthchecks Docker/Kubernetes.
+	checker.Register(r)
+
+	// Webhooks Jellyfin, Plex et Emby ; Radarr/Sonarr déclenchent un
+	// cleanup ciblé des releases dont ils suppriment un fichier.
+	if cfg.ArrWebhook.Password != "" {
+		webhookOpts = append(webhookOpts,
+			webhook.WithArrWebhooks(cfg.ArrWebhook.Username, cfg.ArrWebhook.Password, cleanerSvc),
+			webhook.WithTracker(tracker),
+		)
+	}
+	webhookHandler := webhook.New(cfg.Jellyfin.WebhookSecret, cfg.Jellyfin.UnmonitorSweep, radarrInstances, sonarrInstances, jobQueue, logger, webhookOpts...)
 	webhookHandler.Register(r)
 
-	// Cleanup manuel via API.
+	// Les workers démarrent une fois tous les handlers enregistrés,
+	// et rejouent les événements restés en attente.
+	queueCtx, stopQueue := context.WithCancel(context.Background())
+	if err := jobQueue.Start(queueCtx); err != nil {
+		logger.Fatal("failed to start job queue", zap.Error(err))
+	}
+
+	// Métriques Prometheus.
+	promMetrics.Register(r)
+
+	// Suivi des runs de cleanup/rescan.
+	tracker.Register(r)
+
+	// Journal d'audit : recherche et export.
+	auditLog.Register(r)
+
+	// Corbeille : liste, restauration et purge.
+	if trashBin != nil {
+		trashBin.Register(r)
+	}
+
+	// Cleanup manuel via API. Avec "paths", seuls ces fichiers et dossiers
+	// du downloadDir sont parcourus.
 	r.POST("/api/cleanup", func(ctx *gin.Context) {
-		go func() {
-			result, err := cleanerSvc.Cleanup()
-			if err != nil {
-				logger.Error("manual cleanup failed", zap.Error(err))
-				return
-			}
-			logger.Info("manual cleanup done",
-				zap.Int("orphans", len(result.OrphanFiles)),
-				zap.String("freed", result.FreedBytesHuman()),
-			)
-		}()
-		ctx.JSON(http.StatusAccepted, gin.H{"status": "cleanup started"})
+		var req struct {
+			Paths []string `json:"paths"`
+		}
+		if err := ctx.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid body: " + err.Error()})
+			return
+		}
+		roots, err := cleanerSvc.Scope(req.Paths)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		job, started := tracker.TryStart("cleanup", "api", cleanupJob(audit.TriggerAPI, roots))
+		if !started {
+			promMetrics.SkipCleanup(audit.TriggerAPI, cleanupMode)
+			ctx.JSON(http.StatusConflict, gin.H{"error": "cleanup already running", "job_id": job.ID})
+			return
+		}
+		body := gin.H{"status": "cleanup started", "job_id": job.ID}
+		if len(roots) > 0 {
+			body["paths"] = roots
+		}
+		ctx.JSON(http.StatusAccepted, body)
 	})
 
-	// Rescan manuel Radarr + Sonarr.
+	// Rescan manuel de toutes les instances Radarr, Sonarr (+ Lidarr, Readarr).
 	r.POST("/api/rescan", func(ctx *gin.Context) {
-		go func() {
-			if err := radarrClient.RescanAll(); err != nil {
-				logger.Error("radarr rescan failed", zap.Error(err))
-			}
-			if err := sonarrClient.RescanAll(); err != nil {
-				logger.Error("sonarr rescan failed", zap.Error(err))
-			}
-			logger.Info("manual rescan done")
-		}()
-		ctx.JSON(http.StatusAccepted, gin.H{"status": "rescan started"})
+		job := tracker.Start("rescan", "api", runRescan)
+		ctx.JSON(http.StatusAccepted, gin.H{"status": "rescan started", "job_id": job.ID})
 	})
 
 	// Stats disque.
 	r.GET("/api/stats", func(ctx *gin.Context) {
-		orphans, err := cleanerSvc.FindOrphans()
+		stats, err := cleanerSvc.Stats()
 		if err != nil {
 			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
 
-		var totalSize int64
-		for _, o := range orphans {
-			totalSize += o.Size
-		}
+		promMetrics.SetBacklog(stats.OrphanCount, stats.OrphanSize)
 
-		result := &cleaner.CleanupResult{
-			OrphanFiles: orphans,
-			FreedBytes:  totalSize,
-		}
+		result := &cleaner.CleanupResult{FreedBytes: stats.OrphanSize}
 
 		ctx.JSON(http.StatusOK, gin.H{
-			"orphan_count":    len(orphans),
+			"orphan_count":    stats.OrphanCount,
 			"orphan_size":     result.FreedBytesHuman(),
-			"orphan_size_raw": totalSize,
+			"orphan_size_raw": stats.OrphanSize,
+			"checked_at":      stats.CheckedAt,
 			"dry_run":         cfg.Cleaner.DryRun,
 			"download_dir":    cfg.Cleaner.DownloadDir,
 		})
@@ -179,21 +436,226 @@ func main() {
 		logger.Fatal("forced shutdown", zap.Error(err))
 	}
 
+	// Plus aucun déclencheur : le cron attend la fin de son tick en cours,
+	// puis la file de jobs s'arrête. Les jobs interrompus restent en base
+	// et seront rejoués au démarrage.
+	drainCtx, cancelDrain := context.WithTimeout(context.Background(), drainTimeout)
+	defer cancelDrain()
+	select {
+	case <-c.Stop().Done():
+	case <-drainCtx.Done():
+	}
+	stopQueue()
+	if err := jobQueue.Close(); err != nil {
+		logger.Error("failed to close job queue", zap.Error(err))
+	}
+
+	// Les cleanups et rescans encore en cours écrivent dans cleaner.db et
+	// le journal d'audit : les bases ne sont fermées qu'une fois vidés.
+	if err := tracker.Drain(drainCtx); err != nil {
+		// Chaque transaction bolt est déjà sur disque : quitter sans
+		// fermer les bases ne perd que le run interrompu.
+		logger.Error("jobs still running at shutdown, leaving databases open", zap.Error(err))
+		return
+	}
+	if err := sightings.Close(); err != nil {
+		logger.Error("failed to close cleaner state", zap.Error(err))
+	}
+	if err := auditLog.Close(); err != nil {
+		logger.Error("failed to close audit log", zap.Error(err))
+	}
+
 	logger.Info("clarr stopped cleanly")
 }
 
-// ─── Middleware ───────────────────────────────────────────────────────
+// ─── Cleaner ───────────────────────────────────────────────────────
+
+// transportSetter est implémenté par les clients HTTP des upstreams.
+type transportSetter interface {
+	SetTransport(http.RoundTripper)
+}
+
+// versioner est implémenté par les clients capables de vérifier leur
+// connexion, pour /health/ready.
+type versioner interface {
+	Version() (string, error)
+}
+
+// statusReporter est implémenté par les clients qui mémorisent l'issue
+// de leur dernier échange, pour /health.
+type statusReporter interface {
+	LastError() error
+}
+
+// newDownloadClient crée le client choisi par download_client.
+func newDownloadClient(cfg *config.Config, m *metrics.Metrics, logger *zap.Logger) (cleaner.DownloadClient, error) {
+	transport := m.Transport(cfg.DownloadClient, cfg.DownloadClient, nil)
+
+	switch cfg.DownloadClient {
+	case "transmission":
+		client := transmission.New(cfg.Transmission.URL, cfg.Transmission.Username, cfg.Transmission.Password)
+		client.SetTransport(transport)
+		return client, nil
+	case "deluge":
+		client, err := deluge.New(cfg.Deluge.URL, cfg.Deluge.Password)
+		if err != nil {
+			return nil, err
+		}
+		client.SetTransport(transport)
+		return client, nil
+	case "sabnzbd":
+		client := sabnzbd.New(cfg.Sabnzbd.URL, cfg.Sabnzbd.APIKey)
+		client.SetTransport(transport)
+		return client, nil
+	}
 
-func ginZapLogger(logger *zap.Logger) gin.HandlerFunc {
+	qbitClient, err := qbittorrent.New(
+		cfg.Qbittorrent.URL,
+		cfg.Qbittorrent.Username,
+		cfg.Qbittorrent.Password,
+	)
+	if err != nil {
+		return nil, err
+	}
+	qbitClient.SetTransport(transport)
+	// qBittorrent peut démarrer après clarr : la connexion sera retentée
+	// au prochain appel, /health signale l'état dégradé en attendant.
+	if err := qbitClient.Login(); err != nil {
+		logger.Warn("qbittorrent unreachable at startup, will retry lazily", zap.Error(err))
+	}
+	return qbitClient, nil
+}
+
+// newInstances crée un client par instance *arr configurée. Ses requêtes
+// sont mesurées sous upstream=app et instance=<nom>.
+func newInstances[C transportSetter](m *metrics.Metrics, app string, cfgs []config.ArrInstance, newClient func(url, apiKey string) C) []webhook.Instance[C] {
+	instances := make([]webhook.Instance[C], 0, len(cfgs))
+	for _, c := range cfgs {
+		client := newClient(c.URL, c.APIKey)
+		client.SetTransport(m.Transport(app, c.Name, nil))
+		instances = append(instances, webhook.Instance[C]{Name: c.Name, Client: client})
+	}
+	return instances
+}
+
+// instanceChecks vérifie chaque instance de app sous le nom app/<instance>.
+func instanceChecks[C versioner](app string, instances []webhook.Instance[C], optional map[string]bool) []health.Check {
+	checks := make([]health.Check, 0, len(instances))
+	for _, inst := range instances {
+		name := app + "/" + inst.Name
+		checks = append(checks, health.Check{Name: name, Critical: !optional[name], Probe: inst.Client.Version})
+	}
+	return checks
+}
+
+// apiKeys convertit les clés d'API de la configuration.
+func apiKeys(cfg config.APIConfig) []auth.Key {
+	var keys []auth.Key
+	for _, k := range cfg.All() {
+		keys = append(keys, auth.Key{Name: k.Name, Secret: k.Key, Scope: auth.Scope(k.Scope)})
+	}
+	return keys
+}
+
+func seedingPolicy(cfg config.SeedingConfig) cleaner.SeedingPolicy {
+	policy := cleaner.SeedingPolicy{
+		MinRatio:       cfg.MinRatio,
+		MinSeedingTime: cfg.MinSeedingTime,
+	}
+	for _, r := range cfg.Rules {
+		policy.Rules = append(policy.Rules, cleaner.SeedingRule{
+			Category:       r.Category,
+			Tracker:        r.Tracker,
+			MinRatio:       r.MinRatio,
+			MinSeedingTime: r.MinSeedingTime,
+			AlwaysKeep:     r.AlwaysKeep,
+		})
+	}
+	return policy
+}
+
+// ─── Rescan ────────────────────────────────────────────────────────
+
+// rescanTarget est une instance *arr à rescanner.
+type rescanTarget struct {
+	app      string
+	instance string
+	start    func() (int, error)
+	wait     func(ctx context.Context, commandID int) error
+}
+
+// newRescanTargets adapte les instances d'une application à rescanTarget,
+// dont le statut de commande diffère d'un client à l'autre.
+func newRescanTargets[C, S any](app string, instances []webhook.Instance[C], start func(C) (int, error), wait func(C, context.Context, int) (S, error)) []rescanTarget {
+	targets := make([]rescanTarget, 0, len(instances))
+	for _, inst := range instances {
+		client := inst.Client
+		targets = append(targets, rescanTarget{
+			app:      app,
+			instance: inst.Name,
+			start:    func() (int, error) { return start(client) },
+			wait: func(ctx context.Context, commandID int) error {
+				_, err := wait(client, ctx, commandID)
+				return err
+			},
+		})
+	}
+	return targets
+}
+
+// rescanResult est le résultat d'un rescan pour une instance *arr.
+type rescanResult struct {
+	App       string `json:"app"`
+	Instance  string `json:"instance"`
+	CommandID int    `json:"command_id,omitempty"`
+	Status    string `json:"status"` // "completed" | "failed"
+	Error     string `json:"error,omitempty"`
+}
+
+func newRescanResult(app, instance string, commandID int, err error) rescanResult {
+	r := rescanResult{App: app, Instance: instance, CommandID: commandID, Status: "completed"}
+	if err != nil {
+		r.Status = "failed"
+		r.Error = err.Error()
+	}
+	return r
+}
+
+// ─── Cron ──────────────────────────────────────────────────────────
+
+// cronLogger adapte zap à l'interface cron.Logger.
+type cronLogger struct {
+	s *zap.SugaredLogger
+}
+
+func (l cronLogger) Info(msg string, keysAndValues ...any) {
+	l.s.Infow("cron: "+msg, keysAndValues...)
+}
+
+func (l cronLogger) Error(err error, msg string, keysAndValues ...any) {
+	l.s.Errorw("cron: "+msg, append(keysAndValues, "error", err)...)
+}
+
+// ─── Middleware ────────────────────────────────────────────────────
+
+// ginZapLogger logge chaque requête, avec la clé d'API utilisée, et
+// alimente l'histogramme des requêtes HTTP.
+func ginZapLogger(logger *zap.Logger, m *metrics.Metrics) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		start := time.Now()
 		c.Next()
-		logger.Info("request",
+		latency := time.Since(start)
+		m.ObserveRequest(c.Request.Method, c.FullPath(), c.Writer.Status(), latency)
+		fields := []zap.Field{
 			zap.String("method", c.Request.Method),
 			zap.String("path", c.Request.URL.Path),
 			zap.Int("status", c.Writer.Status()),
-			zap.Duration("latency", time.Since(start)),
+			zap.Duration("latency", latency),
 			zap.String("ip", c.ClientIP()),
-		)
+		}
+		if key := auth.KeyName(c); key != "" {
+			fields = append(fields, zap.String("api_key", key))
+		}
+		logger.Info("request", fields...)
 	}
 }
@@ -0,0 +1,256 @@
+// Package arr regroupe le client HTTP commun aux API v1 de Lidarr et
+// Readarr : requêtes authentifiées, erreurs typées, commandes et dossiers
+// racine. Les packages lidarr et readarr n'y ajoutent que leurs modèles.
+package arr
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Client parle à l'API v1 d'une application *arr.
+type Client struct {
+	app          string // préfixe des erreurs : "lidarr", "readarr"
+	baseURL      string
+	apiKey       string
+	httpClient   *http.Client
+	pollInterval time.Duration
+}
+
+// NewClient crée un client pour l'application app.
+func NewClient(app, baseURL, apiKey string) *Client {
+	return &Client{
+		app:     app,
+		baseURL: baseURL,
+		apiKey:  apiKey,
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+		pollInterval: 2 * time.Second,
+	}
+}
+
+// SetTransport remplace le transport HTTP du client, par exemple pour
+// mesurer les requêtes.
+func (c *Client) SetTransport(rt http.RoundTripper) {
+	c.httpClient.Transport = rt
+}
+
+// ─── Models ─────────────────────────────────────────────────────────
+
+// RootFolder est un dossier racine de la bibliothèque.
+type RootFolder struct {
+	ID   int    `json:"id"`
+	Path string `json:"path"`
+}
+
+// CommandStatus est l'état d'une commande tel que retourné par /api/v1/command.
+type CommandStatus struct {
+	ID      int    `json:"id"`
+	Name    string `json:"name"`
+	Status  string `json:"status"` // queued | started | completed | failed | aborted | cancelled | orphaned
+	Message string `json:"message"`
+}
+
+// SystemStatus est la réponse de /api/v1/system/status.
+type SystemStatus struct {
+	AppName string `json:"appName"`
+	Version string `json:"version"`
+}
+
+// ─── Errors ─────────────────────────────────────────────────────────
+
+// APIError est retourné quand l'application répond avec un statut >= 400.
+type APIError struct {
+	App        string
+	StatusCode int
+	Method     string
+	Endpoint   string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s: unexpected status %d on %s %s", e.App, e.StatusCode, e.Method, e.Endpoint)
+}
+
+// Temporary indique si la requête peut être réessayée plus tard
+// (erreur serveur ou rate limit), par opposition à une requête invalide.
+func (e *APIError) Temporary() bool {
+	return e.StatusCode >= 500 || e.StatusCode == http.StatusTooManyRequests
+}
+
+// ─── HTTP Helper ────────────────────────────────────────────────────
+
+// do envoie la requête et retourne la réponse, dont l'appelant doit
+// fermer le body. Un statut >= 400 est retourné en *APIError.
+func (c *Client) do(method, endpoint string, body any) (*http.Response, error) {
+	return c.doContext(context.Background(), method, endpoint, body)
+}
+
+// Get décode dans v la réponse JSON de endpoint ; what nomme la ressource
+// dans l'erreur de décodage.
+func (c *Client) Get(endpoint, what string, v any) error {
+	resp, err := c.do(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("%s: decode %s: %w", c.app, what, err)
+	}
+	return nil
+}
+
+// Put envoie body et ignore la réponse.
+func (c *Client) Put(endpoint string, body any) error {
+	return c.send(http.MethodPut, endpoint, body)
+}
+
+// Delete supprime la ressource endpoint.
+func (c *Client) Delete(endpoint string) error {
+	return c.send(http.MethodDelete, endpoint, nil)
+}
+
+func (c *Client) send(method, endpoint string, body any) error {
+	resp, err := c.do(method, endpoint, body)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// doContext envoie la requête ; annuler ctx l'interrompt.
+func (c *Client) doContext(ctx context.Context, method, endpoint string, body any) (*http.Response, error) {
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			return nil, fmt.Errorf("%s: encode body: %w", c.app, err)
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+endpoint, &buf)
+	if err != nil {
+		return nil, fmt.Errorf("%s: build request: %w", c.app, err)
+	}
+
+	req.Header.Set("X-Api-Key", c.apiKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s: request failed: %w", c.app, err)
+	}
+
+	if resp.StatusCode >= 400 {
+		resp.Body.Close()
+		return nil, &APIError{App: c.app, StatusCode: resp.StatusCode, Method: method, Endpoint: endpoint}
+	}
+
+	return resp, nil
+}
+
+// ─── Library Methods ────────────────────────────────────────────────
+
+// GetRootFolders retourne les dossiers racine de la bibliothèque.
+func (c *Client) GetRootFolders() ([]RootFolder, error) {
+	var folders []RootFolder
+	if err := c.Get("/api/v1/rootfolder", "root folders", &folders); err != nil {
+		return nil, err
+	}
+	return folders, nil
+}
+
+// ─── System Methods ─────────────────────────────────────────────────
+
+// Version retourne la version de l'application, lue dans le statut
+// système, ce qui vérifie au passage l'URL et la clé d'API.
+func (c *Client) Version() (string, error) {
+	var status SystemStatus
+	if err := c.Get("/api/v1/system/status", "system status", &status); err != nil {
+		return "", err
+	}
+	return status.Version, nil
+}
+
+// ─── Command Methods ────────────────────────────────────────────────
+
+// StartCommand envoie une commande et retourne son ID sans attendre
+// qu'elle soit exécutée. cmd est la commande propre à l'application.
+func (c *Client) StartCommand(cmd any) (int, error) {
+	resp, err := c.do(http.MethodPost, "/api/v1/command", cmd)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	var status CommandStatus
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		return 0, fmt.Errorf("%s: decode command: %w", c.app, err)
+	}
+	return status.ID, nil
+}
+
+// GetCommand retourne l'état courant d'une commande.
+func (c *Client) GetCommand(commandID int) (*CommandStatus, error) {
+	return c.getCommand(context.Background(), commandID)
+}
+
+func (c *Client) getCommand(ctx context.Context, commandID int) (*CommandStatus, error) {
+	resp, err := c.doContext(ctx, http.MethodGet, fmt.Sprintf("/api/v1/command/%d", commandID), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var status CommandStatus
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		return nil, fmt.Errorf("%s: decode command: %w", c.app, err)
+	}
+	return &status, nil
+}
+
+// WaitForCommand interroge la commande jusqu'à ce qu'elle soit terminée.
+// Une interrogation en échec (réseau, 5xx) est retentée au tick suivant ;
+// seule une requête refusée (4xx) arrête l'attente. Retourne une erreur si
+// la commande échoue, est annulée, ou si ctx expire avant, y compris
+// pendant une requête.
+func (c *Client) WaitForCommand(ctx context.Context, commandID int) (*CommandStatus, error) {
+	ticker := time.NewTicker(c.pollInterval)
+	defer ticker.Stop()
+
+	var last *CommandStatus
+	var pollErr error
+	for {
+		status, err := c.getCommand(ctx, commandID)
+		var apiErr *APIError
+		switch {
+		case err == nil:
+			last, pollErr = status, nil
+			switch status.Status {
+			case "completed":
+				return status, nil
+			case "failed", "aborted", "cancelled", "orphaned":
+				return status, fmt.Errorf("%s: command %d (%s) %s: %s", c.app, commandID, status.Name, status.Status, status.Message)
+			}
+		case errors.As(err, &apiErr) && !apiErr.Temporary():
+			return last, err
+		default:
+			pollErr = err
+		}
+
+		select {
+		case <-ctx.Done():
+			if pollErr != nil {
+				return last, fmt.Errorf("%s: wait for command %d: %w (last poll: %v)", c.app, commandID, ctx.Err(), pollErr)
+			}
+			return last, fmt.Errorf("%s: wait for command %d (%s): %w", c.app, commandID, last.Name, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package arr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// commandServer renvoie successivement les états donnés pour la commande 7.
+// "503" et "404" répondent ce statut, "hang" ne répond qu'à l'annulation
+// de la requête.
+func commandServer(t *testing.T, states ...string) *httptest.Server {
+	t.Helper()
+	polls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/api/v1/command":
+			fmt.Fprint(w, `{"id": 7, "name": "RescanFolders", "status": "queued"}`)
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v1/command/7":
+			state := states[min(polls, len(states)-1)]
+			polls++
+			switch state {
+			case "503":
+				w.WriteHeader(http.StatusServiceUnavailable)
+			case "404":
+				w.WriteHeader(http.StatusNotFound)
+			case "hang":
+				<-r.Context().Done()
+			default:
+				fmt.Fprintf(w, `{"id": 7, "name": "RescanFolders", "status": %q, "message": "boom"}`, state)
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestWaitForCommand(t *testing.T) {
+	tests := []struct {
+		name    string
+		states  []string
+		timeout time.Duration
+		wantErr bool
+	}{
+		{"completed", []string{"queued", "started", "completed"}, time.Second, false},
+		{"failed", []string{"started", "failed"}, time.Second, true},
+		{"timeout", []string{"started"}, 50 * time.Millisecond, true},
+		{"transient poll errors are retried", []string{"started", "503", "503", "completed"}, time.Second, false},
+		{"unknown command stops the wait", []string{"started", "404", "completed"}, time.Second, true},
+		{"stuck request is cancelled with ctx", []string{"hang"}, 50 * time.Millisecond, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewClient("lidarr", commandServer(t, tt.states...).URL, "key")
+			c.pollInterval = 5 * time.Millisecond
+
+			id, err := c.StartCommand(struct {
+				Name string `json:"name"`
+			}{"RescanFolders"})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if id != 7 {
+				t.Fatalf("expected command id 7, got %d", id)
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), tt.timeout)
+			defer cancel()
+
+			start := time.Now()
+			_, err = c.WaitForCommand(ctx, id)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("WaitForCommand error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if elapsed := time.Since(start); elapsed > tt.timeout+time.Second {
+				t.Errorf("WaitForCommand returned after %s, ctx was not honoured", elapsed)
+			}
+		})
+	}
+}
+
+func TestAPIError_NamesApp(t *testing.T) {
+	c := NewClient("readarr", commandServer(t, "completed").URL, "key")
+
+	_, err := c.GetRootFolders()
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound || apiErr.Temporary() {
+		t.Fatalf("got %v, want permanent 404 APIError", err)
+	}
+	if want := "readarr: unexpected status 404 on GET /api/v1/rootfolder"; err.Error() != want {
+		t.Errorf("error = %q, want %q", err, want)
+	}
+}
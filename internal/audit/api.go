@@ -0,0 +1,153 @@
+package audit
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultLimit = 100
+	maxLimit     = 1000
+)
+
+// csvHeader est l'en-tête de l'export CSV, dans l'ordre des colonnes.
+var csvHeader = []string{"id", "time", "trigger", "action", "target", "detail", "dry_run", "outcome", "error"}
+
+// Register expose le journal sur l'API. GET /api/audit accepte les
+// filtres action, outcome, trigger, target, dry_run, since et until
+// (RFC 3339), pagine avec limit et before, et exporte en CSV ou NDJSON
+// avec format=csv|ndjson (sans limite par défaut).
+func (l *Log) Register(r *gin.Engine) {
+	r.GET("/api/audit", func(c *gin.Context) {
+		format := c.DefaultQuery("format", "json")
+		f, err := parseFilter(c, format == "json")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		switch format {
+		case "json":
+			entries := []Entry{}
+			next, err := l.Query(f, func(e Entry) error {
+				entries = append(entries, e)
+				return nil
+			})
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			body := gin.H{"entries": entries}
+			if next > 0 {
+				body["next"] = strconv.FormatUint(next, 10)
+			}
+			c.JSON(http.StatusOK, body)
+		case "ndjson":
+			c.Header("Content-Type", "application/x-ndjson")
+			c.Header("Content-Disposition", `attachment; filename="clarr-audit.ndjson"`)
+			enc := json.NewEncoder(c.Writer)
+			l.export(c, f, func(e Entry) error { return enc.Encode(e) })
+		case "csv":
+			c.Header("Content-Type", "text/csv")
+			c.Header("Content-Disposition", `attachment; filename="clarr-audit.csv"`)
+			w := csv.NewWriter(c.Writer)
+			if err := w.Write(csvHeader); err != nil {
+				return
+			}
+			l.export(c, f, func(e Entry) error { return w.Write(e.record()) })
+			w.Flush()
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("unknown format %q, want json, csv or ndjson", format)})
+		}
+	})
+}
+
+// export écrit les entrées au fil de l'eau. Une erreur survenue après le
+// début de la réponse ne peut plus changer son statut : elle est loggée.
+func (l *Log) export(c *gin.Context, f Filter, write func(Entry) error) {
+	c.Status(http.StatusOK)
+	if _, err := l.Query(f, write); err != nil {
+		l.logger.Error("audit export interrupted", zap.Error(err))
+	}
+}
+
+func (e Entry) record() []string {
+	return []string{
+		strconv.FormatUint(e.ID, 10),
+		e.Time.Format(time.RFC3339),
+		e.Trigger,
+		string(e.Action),
+		e.Target,
+		e.Detail,
+		strconv.FormatBool(e.DryRun),
+		string(e.Outcome),
+		e.Error,
+	}
+}
+
+// parseFilter lit les filtres de la requête. paged applique la limite par
+// défaut de l'API JSON ; les exports ne sont limités que sur demande.
+func parseFilter(c *gin.Context, paged bool) (Filter, error) {
+	f := Filter{
+		Action:  Action(c.Query("action")),
+		Outcome: Outcome(c.Query("outcome")),
+		Trigger: c.Query("trigger"),
+		Target:  c.Query("target"),
+	}
+
+	for _, t := range []struct {
+		name string
+		dst  *time.Time
+	}{
+		{"since", &f.Since},
+		{"until", &f.Until},
+	} {
+		v := c.Query(t.name)
+		if v == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return Filter{}, fmt.Errorf("invalid %s: want RFC 3339, got %q", t.name, v)
+		}
+		*t.dst = parsed
+	}
+
+	if v := c.Query("dry_run"); v != "" {
+		dryRun, err := strconv.ParseBool(v)
+		if err != nil {
+			return Filter{}, fmt.Errorf("invalid dry_run %q", v)
+		}
+		f.DryRun = &dryRun
+	}
+
+	if v := c.Query("before"); v != "" {
+		before, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			return Filter{}, fmt.Errorf("invalid before %q", v)
+		}
+		f.Before = before
+	}
+
+	if paged {
+		f.Limit = defaultLimit
+	}
+	if v := c.Query("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 {
+			return Filter{}, fmt.Errorf("invalid limit %q", v)
+		}
+		f.Limit = limit
+	}
+	if paged {
+		f.Limit = min(f.Limit, maxLimit)
+	}
+	return f, nil
+}
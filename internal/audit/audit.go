@@ -0,0 +1,240 @@
+// Package audit garde une trace persistante et en ajout seul de chaque
+// action destructive de clarr : fichiers supprimés ou mis en corbeille,
+// téléchargements retirés du client, éléments unmonitorés.
+package audit
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+	"go.uber.org/zap"
+)
+
+var entriesBucket = []byte("entries")
+
+// Log est le journal d'audit, stocké dans audit.db.
+type Log struct {
+	db     *bolt.DB
+	logger *zap.Logger
+}
+
+// ─── Models ───────────────────────────────────────────────────────────
+
+// Action est le type d'action journalisée.
+type Action string
+
+const (
+	ActionDeleteFile     Action = "delete_file"
+	ActionTrashFile      Action = "trash_file"
+	ActionRestoreFile    Action = "restore_file"
+	ActionPurgeFile      Action = "purge_file"
+	ActionRemoveDownload Action = "remove_download"
+	ActionUnmonitor      Action = "unmonitor"
+)
+
+// Outcome est l'issue d'une action.
+type Outcome string
+
+const (
+	OutcomeSuccess   Outcome = "success"
+	OutcomeFailed    Outcome = "failed"
+	OutcomeSimulated Outcome = "simulated" // dry-run : rien n'a été touché
+)
+
+// Entry est une action journalisée.
+type Entry struct {
+	ID      uint64    `json:"id"`
+	Time    time.Time `json:"time"`
+	Trigger string    `json:"trigger"` // cron | api | webhook:<source>:<job id>
+	Action  Action    `json:"action"`
+	Target  string    `json:"target"`           // chemin, hash du torrent, radarr/<instance>/movie/<id>...
+	Detail  string    `json:"detail,omitempty"` // titre, nom du torrent, raison
+	DryRun  bool      `json:"dry_run"`
+	Outcome Outcome   `json:"outcome"`
+	Error   string    `json:"error,omitempty"`
+}
+
+// ─── Trigger ──────────────────────────────────────────────────────────
+
+// Déclencheurs des actions hors webhooks.
+const (
+	TriggerCron = "cron"
+	TriggerAPI  = "api"
+)
+
+// WebhookTrigger identifie un job de webhook : webhook:<source>:<job id>.
+func WebhookTrigger(source string, jobID uint64) string {
+	return fmt.Sprintf("webhook:%s:%d", source, jobID)
+}
+
+type triggerKey struct{}
+
+// WithTrigger attache à ctx ce qui a déclenché les actions qui suivent.
+func WithTrigger(ctx context.Context, trigger string) context.Context {
+	return context.WithValue(ctx, triggerKey{}, trigger)
+}
+
+// TriggerFrom retourne le déclencheur attaché à ctx, ou "".
+func TriggerFrom(ctx context.Context) string {
+	trigger, _ := ctx.Value(triggerKey{}).(string)
+	return trigger
+}
+
+// ─── Lifecycle ────────────────────────────────────────────────────────
+
+// Open ouvre (ou crée) le journal dans dataDir.
+func Open(dataDir string, logger *zap.Logger) (*Log, error) {
+	if err := os.MkdirAll(dataDir, 0o755); err != nil {
+		return nil, fmt.Errorf("audit: create data dir: %w", err)
+	}
+
+	db, err := bolt.Open(filepath.Join(dataDir, "audit.db"), 0o600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("audit: open db: %w", err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(entriesBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("audit: create bucket: %w", err)
+	}
+
+	return &Log{db: db, logger: logger}, nil
+}
+
+// Close ferme la base.
+func (l *Log) Close() error {
+	return l.db.Close()
+}
+
+// ─── Methods ──────────────────────────────────────────────────────────
+
+// Record journalise e, dont l'issue est déduite de err et de e.DryRun.
+// Le déclencheur vient de ctx. Un échec d'écriture est loggé mais ne
+// remonte pas : l'action a déjà eu lieu.
+func (l *Log) Record(ctx context.Context, e Entry, err error) {
+	e.Time = time.Now().UTC()
+	if e.Trigger == "" {
+		e.Trigger = TriggerFrom(ctx)
+	}
+	switch {
+	case err != nil:
+		e.Outcome = OutcomeFailed
+		e.Error = err.Error()
+	case e.DryRun:
+		e.Outcome = OutcomeSimulated
+	default:
+		e.Outcome = OutcomeSuccess
+	}
+
+	werr := l.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(entriesBucket)
+		id, err := b.NextSequence()
+		if err != nil {
+			return err
+		}
+		e.ID = id
+
+		data, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		return b.Put(itob(id), data)
+	})
+	if werr != nil {
+		l.logger.Error("failed to record audit entry",
+			zap.String("action", string(e.Action)),
+			zap.String("target", e.Target),
+			zap.Error(werr),
+		)
+	}
+}
+
+// Filter sélectionne des entrées. Les champs vides ne filtrent pas.
+type Filter struct {
+	Since   time.Time
+	Until   time.Time
+	Action  Action
+	Outcome Outcome
+	Trigger string // préfixe : "webhook" matche tous les webhooks
+	Target  string // sous-chaîne, sans tenir compte de la casse
+	DryRun  *bool
+	Before  uint64 // pagination : entrées d'ID strictement inférieur
+	Limit   int    // 0 = sans limite
+}
+
+func (f Filter) matches(e Entry) bool {
+	switch {
+	case !f.Since.IsZero() && e.Time.Before(f.Since),
+		!f.Until.IsZero() && !e.Time.Before(f.Until),
+		f.Action != "" && e.Action != f.Action,
+		f.Outcome != "" && e.Outcome != f.Outcome,
+		f.Trigger != "" && !strings.HasPrefix(e.Trigger, f.Trigger),
+		f.Target != "" && !strings.Contains(strings.ToLower(e.Target), strings.ToLower(f.Target)),
+		f.DryRun != nil && e.DryRun != *f.DryRun:
+		return false
+	}
+	return true
+}
+
+// Query appelle fn pour chaque entrée qui matche f, de la plus récente à
+// la plus ancienne, jusqu'à f.Limit entrées. Elle retourne l'ID à passer
+// en Before pour la page suivante, ou 0 s'il n'y en a pas.
+func (l *Log) Query(f Filter, fn func(Entry) error) (uint64, error) {
+	var next uint64
+	err := l.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(entriesBucket).Cursor()
+
+		k, v := c.Last()
+		if f.Before > 0 {
+			k, v = c.Seek(itob(f.Before))
+			// Seek se place sur Before ou après : on repart en arrière.
+			if k == nil {
+				k, v = c.Last()
+			}
+			if k != nil && binary.BigEndian.Uint64(k) >= f.Before {
+				k, v = c.Prev()
+			}
+		}
+
+		n := 0
+		var last uint64
+		for ; k != nil; k, v = c.Prev() {
+			var e Entry
+			if err := json.Unmarshal(v, &e); err != nil {
+				return fmt.Errorf("audit: decode entry %d: %w", binary.BigEndian.Uint64(k), err)
+			}
+			if !f.matches(e) {
+				continue
+			}
+			// Une entrée de plus existe : la page suivante part de la dernière rendue.
+			if f.Limit > 0 && n == f.Limit {
+				next = last
+				return nil
+			}
+			if err := fn(e); err != nil {
+				return err
+			}
+			last = e.ID
+			n++
+		}
+		return nil
+	})
+	return next, err
+}
+
+func itob(v uint64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, v)
+	return b
+}
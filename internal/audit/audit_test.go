@@ -0,0 +1,220 @@
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+func openLog(t *testing.T) *Log {
+	t.Helper()
+	l, err := Open(t.TempDir(), zap.NewNop())
+	if err != nil {
+		t.Fatalf("open audit log: %v", err)
+	}
+	t.Cleanup(func() { l.Close() })
+	return l
+}
+
+// seed journalise un run de cron puis un job de webhook.
+func seed(l *Log) {
+	cron := WithTrigger(context.Background(), TriggerCron)
+	l.Record(cron, Entry{Action: ActionDeleteFile, Target: "/downloads/Dune.2021.mkv", DryRun: true}, nil)
+	l.Record(cron, Entry{Action: ActionDeleteFile, Target: "/downloads/Andor.S01E01.mkv"}, nil)
+	l.Record(cron, Entry{Action: ActionRemoveDownload, Target: "abc123", Detail: "Andor.S01"}, errors.New("qbittorrent unavailable"))
+
+	hook := WithTrigger(context.Background(), WebhookTrigger("jellyfin", 42))
+	l.Record(hook, Entry{Action: ActionUnmonitor, Target: "radarr/default/movie/7", Detail: "Dune"}, nil)
+}
+
+// all retourne les entrées qui matchent f.
+func all(t *testing.T, l *Log, f Filter) ([]Entry, uint64) {
+	t.Helper()
+	var entries []Entry
+	next, err := l.Query(f, func(e Entry) error {
+		entries = append(entries, e)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return entries, next
+}
+
+func TestLog_Record(t *testing.T) {
+	l := openLog(t)
+	seed(l)
+
+	entries, _ := all(t, l, Filter{})
+	if len(entries) != 4 {
+		t.Fatalf("expected 4 entries, got %d", len(entries))
+	}
+
+	want := []struct {
+		trigger string
+		outcome Outcome
+	}{
+		{"webhook:jellyfin:42", OutcomeSuccess},
+		{"cron", OutcomeFailed},
+		{"cron", OutcomeSuccess},
+		{"cron", OutcomeSimulated},
+	}
+	for i, w := range want {
+		e := entries[i]
+		if e.ID != uint64(4-i) || e.Trigger != w.trigger || e.Outcome != w.outcome || e.Time.IsZero() {
+			t.Errorf("entry %d = %+v, want trigger %s outcome %s", i, e, w.trigger, w.outcome)
+		}
+	}
+	if entries[1].Error != "qbittorrent unavailable" {
+		t.Errorf("error not recorded: %+v", entries[1])
+	}
+}
+
+func TestLog_Query(t *testing.T) {
+	l := openLog(t)
+	seed(l)
+
+	dryRun := false
+	tests := []struct {
+		name   string
+		filter Filter
+		want   []uint64
+	}{
+		{"action", Filter{Action: ActionDeleteFile}, []uint64{2, 1}},
+		{"outcome", Filter{Outcome: OutcomeFailed}, []uint64{3}},
+		{"trigger prefix", Filter{Trigger: "webhook"}, []uint64{4}},
+		{"target substring", Filter{Target: "andor"}, []uint64{2}},
+		{"dry run", Filter{Action: ActionDeleteFile, DryRun: &dryRun}, []uint64{2}},
+		{"before", Filter{Before: 3}, []uint64{2, 1}},
+		{"before past the end", Filter{Before: 99}, []uint64{4, 3, 2, 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, _ := all(t, l, tt.filter)
+			var got []uint64
+			for _, e := range entries {
+				got = append(got, e.ID)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got ids %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("got ids %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestLog_QueryPagination(t *testing.T) {
+	l := openLog(t)
+	seed(l)
+
+	var pages [][]Entry
+	f := Filter{Limit: 3}
+	for {
+		entries, next := all(t, l, f)
+		pages = append(pages, entries)
+		if next == 0 {
+			break
+		}
+		f.Before = next
+	}
+
+	if len(pages) != 2 || len(pages[0]) != 3 || len(pages[1]) != 1 || pages[1][0].ID != 1 {
+		t.Fatalf("unexpected pages: %+v", pages)
+	}
+
+	// Une page pleine sans entrée derrière n'annonce pas de page suivante.
+	if _, next := all(t, l, Filter{Limit: 4}); next != 0 {
+		t.Errorf("next = %d on the last page, want 0", next)
+	}
+}
+
+func TestRegister(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	l := openLog(t)
+	seed(l)
+
+	r := gin.New()
+	l.Register(r)
+
+	get := func(target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		return w
+	}
+
+	w := get("/api/audit?limit=2")
+	var page struct {
+		Entries []Entry `json:"entries"`
+		Next    string  `json:"next"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != http.StatusOK || len(page.Entries) != 2 || page.Next != "3" {
+		t.Fatalf("GET /api/audit?limit=2 = %d %s", w.Code, w.Body)
+	}
+
+	w = get("/api/audit?limit=2&before=" + page.Next)
+	page.Next = ""
+	if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Entries) != 2 || page.Entries[0].ID != 2 || page.Next != "" {
+		t.Fatalf("second page = %s", w.Body)
+	}
+
+	w = get("/api/audit?format=csv&trigger=cron")
+	records, err := csv.NewReader(w.Body).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != http.StatusOK || len(records) != 4 || strings.Join(records[0], ",") != strings.Join(csvHeader, ",") {
+		t.Fatalf("csv export = %d %v", w.Code, records)
+	}
+	if records[1][3] != string(ActionRemoveDownload) || records[1][7] != string(OutcomeFailed) {
+		t.Errorf("unexpected csv row %v", records[1])
+	}
+
+	w = get("/api/audit?format=ndjson&action=unmonitor")
+	if ct := w.Header().Get("Content-Type"); ct != "application/x-ndjson" {
+		t.Errorf("content type = %q", ct)
+	}
+	scanner := bufio.NewScanner(w.Body)
+	var lines []Entry
+	for scanner.Scan() {
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			t.Fatalf("invalid ndjson line %q: %v", scanner.Text(), err)
+		}
+		lines = append(lines, e)
+	}
+	if len(lines) != 1 || lines[0].Target != "radarr/default/movie/7" {
+		t.Fatalf("ndjson export = %+v", lines)
+	}
+
+	for _, q := range []string{"since=yesterday", "dry_run=maybe", "limit=0", "before=x", "format=xml"} {
+		if w := get("/api/audit?" + q); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: got %d, want 400", q, w.Code)
+		}
+	}
+
+	// La limite de l'API JSON est plafonnée.
+	if w := get("/api/audit?limit=" + strconv.Itoa(maxLimit+1)); w.Code != http.StatusOK {
+		t.Errorf("limit above max: got %d", w.Code)
+	}
+}
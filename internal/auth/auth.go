@@ -0,0 +1,114 @@
+// Package auth protège l'API de gestion de clarr par clé d'API, comme les
+// *arr : en-tête X-Api-Key ou paramètre apikey.
+package auth
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// Scope est l'étendue des droits d'une clé.
+type Scope string
+
+const (
+	ScopeRead  Scope = "read"  // GET uniquement
+	ScopeAdmin Scope = "admin" // toute l'API, dont cleanup, rescan et purge
+)
+
+// contextKey est la clé Gin sous laquelle est rangé le nom de la clé
+// authentifiée.
+const contextKey = "auth.key"
+
+// Key est une clé d'API nommée.
+type Key struct {
+	Name   string
+	Secret string
+	Scope  Scope
+}
+
+// allows indique si la clé peut appeler une route avec cette méthode.
+func (k Key) allows(method string) bool {
+	return k.Scope == ScopeAdmin || readOnly(method)
+}
+
+// readOnly indique si la méthode ne modifie rien.
+func readOnly(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead
+}
+
+// Middleware exige une clé valide sur les routes sous prefix (/api/). Les
+// autres routes, webhooks compris, gardent leur propre authentification.
+// La route est celle matchée par Gin, pas le chemin brut, pour qu'un
+// chemin détourné ne contourne pas le contrôle.
+//
+// Sans aucune clé, l'API est en lecture seule : les GET restent ouverts,
+// les routes qui suppriment ou relancent quelque chose sont refusées.
+func Middleware(prefix string, keys []Key, logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !strings.HasPrefix(c.FullPath(), prefix) {
+			c.Next()
+			return
+		}
+
+		if len(keys) == 0 {
+			if !readOnly(c.Request.Method) {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "no api key configured, set CLARR_API_KEY to enable this endpoint"})
+				return
+			}
+			c.Next()
+			return
+		}
+
+		secret := c.GetHeader("X-Api-Key")
+		if secret == "" {
+			secret = c.Query("apikey")
+		}
+		if secret == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing api key"})
+			return
+		}
+
+		key, ok := lookup(keys, secret)
+		if !ok {
+			logger.Warn("invalid api key", zap.String("path", c.Request.URL.Path), zap.String("ip", c.ClientIP()))
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid api key"})
+			return
+		}
+		if !key.allows(c.Request.Method) {
+			logger.Warn("api key not allowed",
+				zap.String("key", key.Name),
+				zap.String("method", c.Request.Method),
+				zap.String("path", c.Request.URL.Path),
+			)
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": fmt.Sprintf("api key %q is read-only", key.Name)})
+			return
+		}
+
+		c.Set(contextKey, key.Name)
+		c.Next()
+	}
+}
+
+// KeyName retourne le nom de la clé qui a authentifié la requête, vide
+// sur une route non protégée.
+func KeyName(c *gin.Context) string {
+	return c.GetString(contextKey)
+}
+
+// lookup cherche la clé en temps constant : toutes les clés sont comparées,
+// pour ne rien révéler par la durée de la réponse.
+func lookup(keys []Key, secret string) (Key, bool) {
+	var found Key
+	ok := false
+	for _, k := range keys {
+		if subtle.ConstantTimeCompare([]byte(secret), []byte(k.Secret)) == 1 {
+			found, ok = k, true
+		}
+	}
+	return found, ok
+}
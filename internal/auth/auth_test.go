@@ -0,0 +1,116 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// router reproduit les routes de main : l'API de gestion, un webhook et
+// les healthchecks.
+func router() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(Middleware("/api/", []Key{
+		{Name: "default", Secret: "admin-secret", Scope: ScopeAdmin},
+		{Name: "grafana", Secret: "read-secret", Scope: ScopeRead},
+	}, zap.NewNop()))
+
+	ok := func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{"key": KeyName(c)}) }
+	r.GET("/api/stats", ok)
+	r.GET("/api/jobs/:id", ok)
+	r.POST("/api/cleanup", ok)
+	r.POST("/api/trash/purge", ok)
+	r.POST("/webhook/jellyfin", ok)
+	r.GET("/health/ready", ok)
+	return r
+}
+
+func TestMiddleware(t *testing.T) {
+	r := router()
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		header string
+		want   int
+	}{
+		{"stats without key", http.MethodGet, "/api/stats", "", http.StatusUnauthorized},
+		{"cleanup without key", http.MethodPost, "/api/cleanup", "", http.StatusUnauthorized},
+		{"cleanup with invalid key", http.MethodPost, "/api/cleanup", "wrong", http.StatusUnauthorized},
+		{"stats with invalid query key", http.MethodGet, "/api/stats?apikey=wrong", "", http.StatusUnauthorized},
+		{"key prefix", http.MethodGet, "/api/stats", "admin", http.StatusUnauthorized},
+		{"stats with read key", http.MethodGet, "/api/stats", "read-secret", http.StatusOK},
+		{"job with read key", http.MethodGet, "/api/jobs/42", "read-secret", http.StatusOK},
+		{"cleanup with read key", http.MethodPost, "/api/cleanup", "read-secret", http.StatusForbidden},
+		{"purge with read key", http.MethodPost, "/api/trash/purge", "read-secret", http.StatusForbidden},
+		{"cleanup with admin key", http.MethodPost, "/api/cleanup", "admin-secret", http.StatusOK},
+		{"cleanup with query key", http.MethodPost, "/api/cleanup?apikey=admin-secret", "", http.StatusOK},
+		{"webhook keeps its own auth", http.MethodPost, "/webhook/jellyfin", "", http.StatusOK},
+		{"health stays open", http.MethodGet, "/health/ready", "", http.StatusOK},
+		{"unknown route", http.MethodGet, "/api/missing", "", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, nil)
+			if tt.header != "" {
+				req.Header.Set("X-Api-Key", tt.header)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Errorf("%s %s = %d, want %d: %s", tt.method, tt.target, w.Code, tt.want, w.Body)
+			}
+		})
+	}
+}
+
+func TestMiddleware_KeyName(t *testing.T) {
+	r := router()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/stats", nil)
+	req.Header.Set("X-Api-Key", "read-secret")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Body.String() != `{"key":"grafana"}` {
+		t.Errorf("key name = %s, want grafana", w.Body)
+	}
+}
+
+func TestMiddleware_NoKeys(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(Middleware("/api/", nil, zap.NewNop()))
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	r.GET("/api/stats", ok)
+	r.POST("/api/cleanup", ok)
+	r.POST("/api/rescan", ok)
+	r.POST("/api/trash/purge", ok)
+	r.POST("/webhook/jellyfin", ok)
+
+	tests := []struct {
+		method string
+		target string
+		want   int
+	}{
+		{http.MethodGet, "/api/stats", http.StatusOK},
+		{http.MethodPost, "/api/cleanup", http.StatusForbidden},
+		{http.MethodPost, "/api/rescan", http.StatusForbidden},
+		{http.MethodPost, "/api/trash/purge", http.StatusForbidden},
+		{http.MethodPost, "/api/cleanup?apikey=anything", http.StatusForbidden},
+		{http.MethodPost, "/webhook/jellyfin", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(tt.method, tt.target, nil))
+		if w.Code != tt.want {
+			t.Errorf("%s %s without configured keys = %d, want %d", tt.method, tt.target, w.Code, tt.want)
+		}
+	}
+}
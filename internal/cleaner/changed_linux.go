@@ -0,0 +1,22 @@
+//go:build linux
+
+package cleaner
+
+import (
+	"io/fs"
+	"syscall"
+	"time"
+)
+
+// changedAt retourne la dernière modification du contenu (mtime) ou des
+// liens (ctime) : un fichier que Radarr vient de délier de la bibliothèque
+// est aussi récent qu'un fichier en cours d'écriture.
+func changedAt(info fs.FileInfo) time.Time {
+	changed := info.ModTime()
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+		if ctime := time.Unix(stat.Ctim.Unix()); ctime.After(changed) {
+			changed = ctime
+		}
+	}
+	return changed
+}
@@ -0,0 +1,14 @@
+//go:build !linux
+
+package cleaner
+
+import (
+	"io/fs"
+	"time"
+)
+
+// changedAt retourne la dernière modification du contenu. Le ctime n'est
+// lu que sous Linux.
+func changedAt(info fs.FileInfo) time.Time {
+	return info.ModTime()
+}
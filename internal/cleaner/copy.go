@@ -0,0 +1,219 @@
+package cleaner
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"go.uber.org/zap"
+)
+
+// ─── Copy Detection ───────────────────────────────────────────────────
+
+// hashChunk est la taille lue au début et à la fin d'un fichier pour son
+// empreinte partielle.
+const hashChunk = 1 << 20
+
+// ImportSource fournit les imports connus d'un *arr : chemin téléchargé
+// vers chemin importé en bibliothèque.
+type ImportSource interface {
+	ImportedFiles() (map[string]string, error)
+}
+
+// CopyDetector détecte les orphelins quand les *arr copient les fichiers
+// au lieu de les lier (downloadDir et bibliothèque sur des disques
+// différents). Un fichier est gardé si :
+//   - l'historique d'un *arr l'a importé et la copie existe avec la même
+//     taille ;
+//   - ou un fichier des bibliothèques a la même taille et la même
+//     empreinte partielle (début, fin et taille).
+//
+// Il n'utilise que os.Stat et fonctionne donc aussi sous Windows.
+type CopyDetector struct {
+	mediaRoots []string
+	sources    []ImportSource
+	logger     *zap.Logger
+}
+
+// NewCopyDetector crée un CopyDetector sur les bibliothèques données.
+// Les sources sont optionnelles : sans elles, seul le contenu compte.
+func NewCopyDetector(mediaRoots []string, sources []ImportSource, logger *zap.Logger) *CopyDetector {
+	return &CopyDetector{
+		mediaRoots: mediaRoots,
+		sources:    sources,
+		logger:     logger,
+	}
+}
+
+// FindOrphans parcourt root et retourne les fichiers dont aucune copie
+// n'existe en bibliothèque.
+func (d *CopyDetector) FindOrphans(root string) ([]OrphanFile, error) {
+	var files []OrphanFile
+	err := filepath.WalkDir(root, func(path string, e fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if e.IsDir() {
+			return nil
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return fmt.Errorf("stat %s: %w", path, err)
+		}
+		files = append(files, OrphanFile{Path: path, Size: info.Size(), Links: 1})
+		return nil
+	})
+	if err != nil || len(files) == 0 {
+		return nil, err
+	}
+
+	imported := d.importedFiles()
+
+	var media *mediaIndex
+	var orphans []OrphanFile
+	for _, f := range files {
+		if d.importExists(f, imported) {
+			continue
+		}
+		if media == nil {
+			if media, err = d.indexMedia(); err != nil {
+				return nil, fmt.Errorf("scan media roots: %w", err)
+			}
+		}
+		ok, err := media.contains(f)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			continue
+		}
+		f.Reason = "no copy in media roots"
+		orphans = append(orphans, f)
+		d.logger.Info("orphan found, no copy in media roots",
+			zap.String("path", f.Path),
+			zap.Int64("size_bytes", f.Size),
+		)
+	}
+	return orphans, nil
+}
+
+// importedFiles fusionne l'historique des sources. Une source en erreur
+// est ignorée : la comparaison de contenu prend le relais.
+func (d *CopyDetector) importedFiles() map[string]string {
+	imported := make(map[string]string)
+	for _, src := range d.sources {
+		files, err := src.ImportedFiles()
+		if err != nil {
+			d.logger.Warn("failed to fetch import history", zap.Error(err))
+			continue
+		}
+		for dropped, path := range files {
+			imported[filepath.Clean(dropped)] = path
+		}
+	}
+	return imported
+}
+
+// importExists indique si la copie importée de f existe avec la même taille.
+func (d *CopyDetector) importExists(f OrphanFile, imported map[string]string) bool {
+	path, ok := imported[filepath.Clean(f.Path)]
+	if !ok {
+		return false
+	}
+	info, err := os.Stat(path)
+	return err == nil && info.Size() == f.Size
+}
+
+// indexMedia liste les fichiers des bibliothèques par taille.
+func (d *CopyDetector) indexMedia() (*mediaIndex, error) {
+	idx := &mediaIndex{
+		bySize: make(map[int64][]string),
+		hashes: make(map[string][]byte),
+	}
+	for _, root := range d.mediaRoots {
+		err := filepath.WalkDir(root, func(path string, e fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if e.IsDir() {
+				return nil
+			}
+			info, err := e.Info()
+			if err != nil {
+				return fmt.Errorf("stat %s: %w", path, err)
+			}
+			idx.bySize[info.Size()] = append(idx.bySize[info.Size()], path)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return idx, nil
+}
+
+// mediaIndex retrouve un fichier des bibliothèques par taille puis par
+// empreinte partielle, calculée à la demande et mise en cache.
+type mediaIndex struct {
+	bySize map[int64][]string
+	hashes map[string][]byte
+}
+
+func (m *mediaIndex) contains(f OrphanFile) (bool, error) {
+	candidates := m.bySize[f.Size]
+	if len(candidates) == 0 {
+		return false, nil
+	}
+
+	sum, err := partialHash(f.Path, f.Size)
+	if err != nil {
+		return false, err
+	}
+	for _, path := range candidates {
+		h, ok := m.hashes[path]
+		if !ok {
+			if h, err = partialHash(path, f.Size); err != nil {
+				return false, err
+			}
+			m.hashes[path] = h
+		}
+		if bytes.Equal(h, sum) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// partialHash retourne le sha256 de la taille, du premier et du dernier
+// MiB du fichier : assez pour distinguer deux releases de même taille sans
+// relire des dizaines de Go.
+func partialHash(path string, size int64) ([]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", path, err)
+	}
+	defer file.Close()
+
+	h := sha256.New()
+	if err := binary.Write(h, binary.LittleEndian, size); err != nil {
+		return nil, err
+	}
+	if _, err := io.CopyN(h, file, min(size, hashChunk)); err != nil {
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+	if size > hashChunk {
+		tail := max(size-hashChunk, hashChunk)
+		if _, err := file.Seek(tail, io.SeekStart); err != nil {
+			return nil, fmt.Errorf("seek %s: %w", path, err)
+		}
+		if _, err := io.CopyN(h, file, size-tail); err != nil {
+			return nil, fmt.Errorf("read %s: %w", path, err)
+		}
+	}
+	return h.Sum(nil), nil
+}
@@ -0,0 +1,109 @@
+package cleaner
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// fakeImports est une ImportSource figée.
+type fakeImports struct {
+	files map[string]string
+	err   error
+}
+
+func (f fakeImports) ImportedFiles() (map[string]string, error) {
+	return f.files, f.err
+}
+
+func writeFile(t *testing.T, path string, content []byte) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCopyDetector(t *testing.T) {
+	base := t.TempDir()
+	downloads := filepath.Join(base, "downloads")
+	media := filepath.Join(base, "media")
+
+	// Plus grand que deux blocs d'empreinte, pour lire début et fin.
+	big := make([]byte, 3*hashChunk)
+	big[len(big)-1] = 1
+
+	// Copié en bibliothèque sous un autre nom : retrouvé par contenu.
+	copied := filepath.Join(downloads, "radarr", "Dune.2021.mkv")
+	writeFile(t, copied, big)
+	writeFile(t, filepath.Join(media, "Dune (2021)", "Dune.mkv"), big)
+
+	// Même taille, fin différente : pas une copie.
+	other := make([]byte, len(big))
+	other[len(other)-1] = 2
+	different := filepath.Join(downloads, "radarr", "Heat.1995.mkv")
+	writeFile(t, different, other)
+
+	// Importé hors des bibliothèques puis retouché : seul l'historique
+	// le retrouve, la taille étant la même.
+	imported := filepath.Join(downloads, "tv", "Andor.S01E01.mkv")
+	importedCopy := filepath.Join(base, "elsewhere", "Andor - S01E01.mkv")
+	writeFile(t, imported, []byte("episode"))
+	writeFile(t, importedCopy, []byte("remuxed"))
+
+	// Importé, mais la copie a été supprimée depuis.
+	gone := filepath.Join(downloads, "tv", "Andor.S01E02.mkv")
+	writeFile(t, gone, []byte("episode 2"))
+
+	sources := []ImportSource{
+		fakeImports{files: map[string]string{
+			imported: importedCopy,
+			gone:     filepath.Join(base, "elsewhere", "Andor - S01E02.mkv"),
+		}},
+		fakeImports{err: errors.New("sonarr down")},
+	}
+
+	d := NewCopyDetector([]string{media}, sources, setupLogger(t))
+	orphans, err := d.FindOrphans(downloads)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got := make(map[string]bool)
+	for _, o := range orphans {
+		got[o.Path] = true
+	}
+	if len(orphans) != 2 || !got[different] || !got[gone] {
+		t.Fatalf("expected %s and %s as orphans, got %+v", different, gone, orphans)
+	}
+}
+
+func TestCopyDetector_AsCleanerDetector(t *testing.T) {
+	base := t.TempDir()
+	downloads := filepath.Join(base, "downloads")
+	media := filepath.Join(base, "media")
+
+	kept := filepath.Join(downloads, "Dune.2021.mkv")
+	orphan := filepath.Join(downloads, "Heat.1995.mkv")
+	writeFile(t, kept, []byte("dune"))
+	writeFile(t, filepath.Join(media, "Dune (2021)", "Dune.mkv"), []byte("dune"))
+	writeFile(t, orphan, []byte("heat"))
+
+	c := New(downloads, false, nil, setupLogger(t),
+		WithDetector(NewCopyDetector([]string{media}, nil, setupLogger(t))))
+
+	result, err := c.Cleanup(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.DeletedFiles) != 1 || result.DeletedFiles[0].Path != orphan {
+		t.Fatalf("expected only %s deleted, got %+v", orphan, result.DeletedFiles)
+	}
+	if _, err := os.Stat(kept); err != nil {
+		t.Errorf("copied file must be kept: %v", err)
+	}
+}
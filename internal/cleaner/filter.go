@@ -0,0 +1,167 @@
+package cleaner
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// IgnoreFile protège le dossier qui le contient, sous-dossiers compris.
+const IgnoreFile = ".clarrignore"
+
+// ─── Filter ───────────────────────────────────────────────────────────
+
+// Filter restreint les fichiers que le cleaner peut supprimer. Les
+// patterns sont des globs doublestar (**/*.nfo) sur le chemin relatif au
+// downloadDir, séparé par des /. Un pattern qui matche un dossier
+// s'applique à tout son contenu (incomplete, **/.stfolder).
+type Filter struct {
+	// Include limite le nettoyage aux chemins qui matchent ; vide = tout.
+	Include []string
+	// Exclude protège les chemins qui matchent, y compris de la
+	// suppression des dossiers vides.
+	Exclude []string
+	// Extensions limite le nettoyage à ces extensions (.mkv ou mkv) ;
+	// vide = toutes.
+	Extensions []string
+	// MinSize ignore les fichiers plus petits, en octets.
+	MinSize int64
+}
+
+// WithFilter restreint les fichiers nettoyés, en plus des .clarrignore
+// toujours respectés.
+func WithFilter(f Filter) Option {
+	return func(c *Cleaner) {
+		c.filter = f
+	}
+}
+
+// matcher applique le Filter et les .clarrignore pendant un run ; il
+// garde en cache les dossiers déjà examinés.
+type matcher struct {
+	filter  Filter
+	base    string
+	skip    string // corbeille, si elle est dans le downloadDir
+	kept    func(path string) bool
+	ignored map[string]bool
+}
+
+func (c *Cleaner) newMatcher() *matcher {
+	m := &matcher{
+		filter:  c.filter,
+		base:    filepath.Clean(c.downloadDir),
+		ignored: make(map[string]bool),
+	}
+	if c.trash != nil {
+		m.skip = c.trash.Dir()
+		m.kept = c.trash.Restored
+	}
+	return m
+}
+
+// apply retourne les fichiers que le cleaner peut supprimer.
+func (m *matcher) apply(files []OrphanFile) []OrphanFile {
+	kept := files[:0:0]
+	for _, f := range files {
+		if m.allows(f) {
+			kept = append(kept, f)
+		}
+	}
+	return kept
+}
+
+func (m *matcher) allows(f OrphanFile) bool {
+	path := filepath.Clean(f.Path)
+	if f.Size < m.filter.MinSize || !m.allowsExt(path) {
+		return false
+	}
+	if m.skipped(path) || m.ignoredDir(filepath.Dir(path)) {
+		return false
+	}
+	// Restauré depuis la corbeille : l'utilisateur veut le garder.
+	if m.kept != nil && m.kept(path) {
+		return false
+	}
+
+	rel, ok := m.rel(path)
+	if !ok {
+		return false
+	}
+	if matchAny(m.filter.Exclude, rel) {
+		return false
+	}
+	return len(m.filter.Include) == 0 || matchAny(m.filter.Include, rel)
+}
+
+// excludesDir indique si le dossier dir est protégé par un pattern
+// Exclude ou un .clarrignore.
+func (m *matcher) excludesDir(dir string) bool {
+	dir = filepath.Clean(dir)
+	if m.skipped(dir) || m.ignoredDir(dir) {
+		return true
+	}
+	rel, ok := m.rel(dir)
+	return ok && rel != "." && matchAny(m.filter.Exclude, rel)
+}
+
+func (m *matcher) skipped(path string) bool {
+	return m.skip != "" && within(path, m.skip)
+}
+
+func (m *matcher) allowsExt(path string) bool {
+	if len(m.filter.Extensions) == 0 {
+		return true
+	}
+	ext := strings.TrimPrefix(filepath.Ext(path), ".")
+	for _, e := range m.filter.Extensions {
+		if strings.EqualFold(strings.TrimPrefix(e, "."), ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// ignoredDir indique si dir ou un de ses parents jusqu'au downloadDir
+// contient un .clarrignore.
+func (m *matcher) ignoredDir(dir string) bool {
+	if ignored, ok := m.ignored[dir]; ok {
+		return ignored
+	}
+
+	_, err := os.Lstat(filepath.Join(dir, IgnoreFile))
+	ignored := err == nil || !errors.Is(err, fs.ErrNotExist)
+	if !ignored && dir != m.base && within(dir, m.base) {
+		ignored = m.ignoredDir(filepath.Dir(dir))
+	}
+	m.ignored[dir] = ignored
+	return ignored
+}
+
+func (m *matcher) rel(path string) (string, bool) {
+	rel, err := filepath.Rel(m.base, path)
+	if err != nil {
+		return "", false
+	}
+	return filepath.ToSlash(rel), true
+}
+
+// matchAny indique si un pattern matche rel ou un de ses dossiers parents.
+func matchAny(patterns []string, rel string) bool {
+	for _, p := range patterns {
+		for sub := rel; ; {
+			if ok, _ := doublestar.Match(p, sub); ok {
+				return true
+			}
+			i := strings.LastIndex(sub, "/")
+			if i < 0 {
+				break
+			}
+			sub = sub[:i]
+		}
+	}
+	return false
+}
@@ -0,0 +1,99 @@
+package cleaner
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+func TestFindOrphans_Filter(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]int{
+		"radarr/Dune.2021/Dune.2021.mkv":       100,
+		"radarr/Dune.2021/Dune.2021.nfo":       100,
+		"radarr/Dune.2021/Sample/sample.mkv":   10,
+		"incomplete/Heat.1995/Heat.1995.mkv":   100,
+		"music/.stfolder/marker.mkv":           100,
+		"manual/Keep/Keep.mkv":                 100,
+		"manual/Keep/Sub/Keep.Extras.mkv":      100,
+		"tv/Andor.S01E01/Andor.S01E01.mkv":     100,
+		"tv/Andor.S01E01/Andor.S01E01.en.srt":  100,
+		"tv/Andor.S01E01/Andor.S01E01.mkv.txt": 100,
+	}
+	for rel, size := range files {
+		writeFile(t, filepath.Join(dir, rel), make([]byte, size))
+	}
+	writeFile(t, filepath.Join(dir, "manual", IgnoreFile), nil)
+
+	c := New(dir, true, nil, setupLogger(t), WithFilter(Filter{
+		Exclude:    []string{"incomplete", "**/.stfolder"},
+		Extensions: []string{"mkv", ".MKV"},
+		MinSize:    50,
+	}))
+
+	orphans, err := c.FindOrphans()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var got []string
+	for _, o := range orphans {
+		rel, _ := filepath.Rel(dir, o.Path)
+		got = append(got, filepath.ToSlash(rel))
+	}
+	sort.Strings(got)
+
+	want := []string{"radarr/Dune.2021/Dune.2021.mkv", "tv/Andor.S01E01/Andor.S01E01.mkv"}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Fatalf("FindOrphans() = %v, want %v", got, want)
+	}
+}
+
+func TestFindOrphans_Include(t *testing.T) {
+	dir := t.TempDir()
+	movie := filepath.Join(dir, "radarr", "Dune.2021.mkv")
+	writeFile(t, movie, []byte("dune"))
+	writeFile(t, filepath.Join(dir, "tv", "Andor.S01E01.mkv"), []byte("andor"))
+
+	c := New(dir, true, nil, setupLogger(t), WithFilter(Filter{Include: []string{"radarr"}}))
+
+	orphans, err := c.FindOrphans()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(orphans) != 1 || orphans[0].Path != movie {
+		t.Fatalf("expected only %s, got %+v", movie, orphans)
+	}
+}
+
+func TestCleanup_KeepsExcludedEmptyDirs(t *testing.T) {
+	dir := t.TempDir()
+	for _, d := range []string{"incomplete", "manual/Empty", "radarr/Dune.2021"} {
+		if err := os.MkdirAll(filepath.Join(dir, d), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFile(t, filepath.Join(dir, "manual", IgnoreFile), nil)
+	writeFile(t, filepath.Join(dir, "radarr", "Dune.2021", "Dune.2021.mkv"), []byte("dune"))
+
+	c := New(dir, false, nil, setupLogger(t), WithFilter(Filter{Exclude: []string{"incomplete"}}))
+
+	result, err := c.Cleanup(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.DeletedFiles) != 1 {
+		t.Fatalf("expected 1 deleted file, got %+v", result.DeletedFiles)
+	}
+
+	for _, d := range []string{"incomplete", "manual/Empty"} {
+		if _, err := os.Stat(filepath.Join(dir, d)); err != nil {
+			t.Errorf("excluded dir %s must be kept: %v", d, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "radarr", "Dune.2021")); !os.IsNotExist(err) {
+		t.Error("emptied release dir should have been removed")
+	}
+}
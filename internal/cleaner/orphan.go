@@ -1,82 +1,314 @@
 package cleaner
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io/fs"
 	"os"
 	"path/filepath"
+	"sync"
+	"time"
 
-	"github.com/cleeryy/clarr/internal/qbittorrent"
+	"github.com/cleeryy/clarr/internal/audit"
+	"github.com/cleeryy/clarr/internal/download"
+	"github.com/cleeryy/clarr/internal/trash"
 	"go.uber.org/zap"
 )
 
+// ErrAlreadyRunning est retourné par Cleanup quand un autre run est en cours.
+var ErrAlreadyRunning = errors.New("cleaner: cleanup already running")
+
+// DownloadClient est ce dont le cleaner a besoin d'un client de
+// téléchargement : retrouver les éléments qui contiennent des fichiers,
+// les retirer et les mettre en pause.
+type DownloadClient interface {
+	// MatchPaths retourne les éléments contenant au moins un des paths,
+	// avec la liste complète de leurs fichiers.
+	MatchPaths(paths []string) ([]download.Item, error)
+	// Delete retire un élément par son ID ; deleteFiles supprime aussi
+	// ses fichiers du disque.
+	Delete(id string, deleteFiles bool) error
+	Pause(id string) error
+}
+
+// Detector décide quels fichiers d'un arbre du downloadDir sont orphelins.
+// Par défaut, un fichier est orphelin quand plus aucun hardlink ne le
+// relie à la bibliothèque.
+type Detector interface {
+	FindOrphans(root string) ([]OrphanFile, error)
+}
+
 type Cleaner struct {
 	downloadDir string
+	mediaRoots  []string
+	detector    Detector
+	filter      Filter
 	dryRun      bool
-	qbit        *qbittorrent.Client
+	client      DownloadClient
+	seeding     SeedingPolicy
+	safety      SafetyPolicy
+	sightings   *Sightings
+	trash       *trash.Trash
+	audit       *audit.Log
 	logger      *zap.Logger
+	running     sync.Mutex
+
+	statsTTL time.Duration
+	statsMu  sync.Mutex
+	stats    *Stats
+}
+
+// Option configure un Cleaner à sa création.
+type Option func(*Cleaner)
+
+// WithSeedingPolicy garde les fichiers orphelins des torrents qui doivent
+// encore seeder ; ils sont reportés comme différés.
+func WithSeedingPolicy(p SeedingPolicy) Option {
+	return func(c *Cleaner) {
+		c.seeding = p
+	}
+}
+
+// WithMediaRoots active la détection par inode : un fichier lié plusieurs
+// fois dans le downloadDir (cross-seed) est orphelin si aucun de ses liens
+// n'est dans les bibliothèques roots.
+func WithMediaRoots(roots ...string) Option {
+	return func(c *Cleaner) {
+		c.mediaRoots = roots
+	}
+}
+
+// WithDetector remplace la détection par hardlinks, par exemple par un
+// CopyDetector quand les *arr copient au lieu de lier.
+func WithDetector(d Detector) Option {
+	return func(c *Cleaner) {
+		c.detector = d
+	}
+}
+
+// WithTrash met les orphelins en corbeille au lieu de les supprimer ;
+// ils n'y sont supprimés qu'une fois la rétention écoulée.
+func WithTrash(t *trash.Trash) Option {
+	return func(c *Cleaner) {
+		c.trash = t
+	}
+}
+
+// WithAudit journalise chaque fichier supprimé ou mis en corbeille et
+// chaque téléchargement retiré du client.
+func WithAudit(l *audit.Log) Option {
+	return func(c *Cleaner) {
+		c.audit = l
+	}
 }
 
 type OrphanFile struct {
-	Path  string
-	Size  int64
-	Links uint64
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	Links  uint64 `json:"links"`
+	Reason string `json:"reason,omitempty"` // pourquoi le Detector le juge orphelin
+
+	id fileID // inode, pour ne compter qu'une fois les liens d'un même fichier
+}
+
+// fileID identifie un fichier sur le disque, quel que soit le lien.
+type fileID struct {
+	dev, ino uint64
+}
+
+// hardlinkDetector est le Detector par défaut : un fichier est orphelin
+// quand son link count tombe à 1 (plus aucun hardlink dans movies/ ou tv/).
+type hardlinkDetector struct {
+	downloadDir string
+	mediaRoots  []string
+	logger      *zap.Logger
+}
+
+// DeferredFile est un orphelin conservé pour ce run : son torrent doit
+// encore seeder, ou la SafetyPolicy le protège (Torrent est alors vide).
+type DeferredFile struct {
+	OrphanFile
+	Torrent string `json:"torrent"`
+	Reason  string `json:"reason"`
 }
 
 type CleanupResult struct {
-	ScannedFiles int
-	OrphanFiles  []OrphanFile
-	FreedBytes   int64
-	Errors       []error
+	DryRun          bool           `json:"dry_run"`
+	ScannedFiles    int            `json:"scanned_files"`
+	OrphanFiles     []OrphanFile   `json:"orphan_files"`
+	DeletedFiles    []OrphanFile   `json:"deleted_files"`
+	TrashedFiles    []OrphanFile   `json:"trashed_files"` // restaurables, l'espace n'est libéré qu'à la purge
+	DeferredFiles   []DeferredFile `json:"deferred_files"`
+	RemovedTorrents []string       `json:"removed_torrents"` // IDs retirés du client de téléchargement
+	FreedBytes      int64          `json:"freed_bytes"`
+	Errors          []error        `json:"-"`
 }
 
-func New(downloadDir string, dryRun bool, qbit *qbittorrent.Client, logger *zap.Logger) *Cleaner {
-	return &Cleaner{
+func New(downloadDir string, dryRun bool, client DownloadClient, logger *zap.Logger, opts ...Option) *Cleaner {
+	c := &Cleaner{
 		downloadDir: downloadDir,
 		dryRun:      dryRun,
-		qbit:        qbit,
+		client:      client,
 		logger:      logger,
 	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.detector == nil {
+		c.detector = &hardlinkDetector{downloadDir: downloadDir, mediaRoots: c.mediaRoots, logger: logger}
+	}
+	return c
 }
 
-// Cleanup supprime les fichiers orphelins, notifie qBittorrent
-// et nettoie les dossiers vides.
-func (c *Cleaner) Cleanup() (*CleanupResult, error) {
-	result := &CleanupResult{}
+// Cleanup supprime les fichiers orphelins, notifie le client de téléchargement
+// et nettoie les dossiers vides. Un seul run à la fois : deux runs
+// parallèles supprimeraient dans le même arbre et compteraient deux
+// fois l'espace libéré. Le déclencheur journalisé vient de ctx
+// (audit.WithTrigger).
+func (c *Cleaner) Cleanup(ctx context.Context) (*CleanupResult, error) {
+	return c.cleanup(ctx, []string{c.downloadDir})
+}
 
-	orphans, err := c.FindOrphans()
+// FindOrphans parcourt le downloadDir et retourne les fichiers orphelins
+// selon le Detector configuré, hors de ceux que le Filter et les
+// .clarrignore protègent.
+func (c *Cleaner) FindOrphans() ([]OrphanFile, error) {
+	orphans, err := c.detector.FindOrphans(c.downloadDir)
 	if err != nil {
-		return nil, fmt.Errorf("cleaner: find orphans: %w", err)
+		return nil, err
+	}
+	return c.newMatcher().apply(orphans), nil
+}
+
+// cleanup nettoie les arbres roots, tous situés dans le downloadDir.
+func (c *Cleaner) cleanup(ctx context.Context, roots []string) (*CleanupResult, error) {
+	if !c.running.TryLock() {
+		return nil, ErrAlreadyRunning
+	}
+	defer c.running.Unlock()
+
+	result := &CleanupResult{DryRun: c.dryRun}
+	m := c.newMatcher()
+
+	// Les fichiers dont la rétention est écoulée libèrent enfin leur place.
+	if c.trash != nil && !c.dryRun {
+		purged, errs := c.trash.PurgeExpired(ctx, time.Now())
+		result.FreedBytes += trash.TotalSize(purged)
+		result.Errors = append(result.Errors, errs...)
+	}
+
+	var orphans []OrphanFile
+	for _, root := range roots {
+		if _, err := os.Lstat(root); errors.Is(err, fs.ErrNotExist) {
+			c.logger.Info("cleanup path no longer exists", zap.String("path", root))
+			continue
+		}
+
+		found, err := c.detector.FindOrphans(root)
+		if err != nil {
+			return nil, fmt.Errorf("cleaner: find orphans: %w", err)
+		}
+		orphans = append(orphans, m.apply(found)...)
 	}
 
 	result.OrphanFiles = orphans
 
+	held, err := c.holdBack(ctx, roots, orphans, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("cleaner: %w", err)
+	}
+
+	matches, err := c.matchTorrents(orphans)
+	if err != nil {
+		// Sans savoir à quel torrent appartient un fichier, on ne peut pas
+		// garantir la politique de seed ni savoir s'il est complet : mieux
+		// vaut ne rien supprimer.
+		if c.seeding.enabled() || c.safety.SkipIncomplete {
+			return nil, fmt.Errorf("cleaner: match torrents: %w", err)
+		}
+		c.logger.Warn("download client items not matched", zap.Error(err))
+		result.Errors = append(result.Errors, err)
+	}
+
+	deferred := c.deferProtected(matches)
+
+	var deletable []OrphanFile
 	for _, f := range orphans {
 		result.ScannedFiles++
 
+		if reason, ok := held[filepath.Clean(f.Path)]; ok {
+			c.logger.Info("orphan deferred by safety policy",
+				zap.String("path", f.Path),
+				zap.String("reason", reason),
+			)
+			result.DeferredFiles = append(result.DeferredFiles, DeferredFile{OrphanFile: f, Reason: reason})
+			continue
+		}
+		if d, ok := deferred[filepath.Clean(f.Path)]; ok {
+			d.OrphanFile = f
+			c.logger.Info("orphan deferred, torrent protected",
+				zap.String("path", f.Path),
+				zap.String("torrent", d.Torrent),
+				zap.String("reason", d.Reason),
+			)
+			result.DeferredFiles = append(result.DeferredFiles, d)
+			continue
+		}
+		deletable = append(deletable, f)
+	}
+
+	if !c.dryRun {
+		c.removeTorrents(ctx, matches, deletable, result)
+	}
+
+	// Les liens d'un même inode ne libèrent l'espace qu'une fois.
+	counted := make(map[fileID]bool)
+	free := func(f OrphanFile) {
+		if f.id != (fileID{}) {
+			if counted[f.id] {
+				return
+			}
+			counted[f.id] = true
+		}
+		result.FreedBytes += f.Size
+	}
+
+	action := audit.ActionDeleteFile
+	if c.trash != nil {
+		action = audit.ActionTrashFile
+	}
+	for _, f := range deletable {
+		entry := audit.Entry{Action: action, Target: f.Path, Detail: f.Reason, DryRun: c.dryRun}
 		if c.dryRun {
 			c.logger.Info("dry-run: would delete",
 				zap.String("path", f.Path),
 				zap.Int64("size_bytes", f.Size),
 			)
-			result.FreedBytes += f.Size
+			c.record(ctx, entry, nil)
+			free(f)
 			continue
 		}
 
-		if c.qbit != nil {
-			if err := c.qbit.DeleteTorrentByPath(f.Path, false); err != nil {
-				c.logger.Warn("qbittorrent torrent not removed",
+		if c.trash != nil {
+			err := c.moveToTrash(f)
+			c.record(ctx, entry, err)
+			if err != nil {
+				c.logger.Error("failed to move orphan to trash",
 					zap.String("path", f.Path),
 					zap.Error(err),
 				)
-			} else {
-				c.logger.Info("qbittorrent torrent removed",
-					zap.String("path", f.Path),
-				)
+				result.Errors = append(result.Errors, err)
+				continue
 			}
+			result.TrashedFiles = append(result.TrashedFiles, f)
+			continue
 		}
 
-		if err := os.Remove(f.Path); err != nil {
+		err := os.Remove(f.Path)
+		c.record(ctx, entry, err)
+		if err != nil {
 			c.logger.Error("failed to delete orphan",
 				zap.String("path", f.Path),
 				zap.Error(err),
@@ -89,12 +321,25 @@ func (c *Cleaner) Cleanup() (*CleanupResult, error) {
 			zap.String("path", f.Path),
 			zap.Int64("size_bytes", f.Size),
 		)
-		result.FreedBytes += f.Size
+		result.DeletedFiles = append(result.DeletedFiles, f)
+		free(f)
+	}
+
+	if c.sightings != nil && len(result.DeletedFiles)+len(result.TrashedFiles) > 0 {
+		var deleted []string
+		for _, f := range append(result.DeletedFiles, result.TrashedFiles...) {
+			deleted = append(deleted, f.Path)
+		}
+		if err := c.sightings.forget(deleted); err != nil {
+			c.logger.Warn("failed to forget deleted orphans", zap.Error(err))
+		}
 	}
 
 	if !c.dryRun {
-		if err := c.removeEmptyDirs(); err != nil {
-			c.logger.Warn("failed to remove empty dirs", zap.Error(err))
+		for _, root := range roots {
+			if err := c.removeEmptyDirs(root, m); err != nil {
+				c.logger.Warn("failed to remove empty dirs", zap.String("root", root), zap.Error(err))
+			}
 		}
 	}
 
@@ -108,6 +353,126 @@ func (c *Cleaner) Cleanup() (*CleanupResult, error) {
 	return result, nil
 }
 
+// moveToTrash met f en corbeille sous son chemin relatif au downloadDir.
+func (c *Cleaner) moveToTrash(f OrphanFile) error {
+	rel, err := filepath.Rel(c.downloadDir, f.Path)
+	if err != nil {
+		return err
+	}
+	_, err = c.trash.Move(f.Path, rel, f.Size, f.Reason)
+	return err
+}
+
+// matchTorrents associe les orphelins aux éléments qui les contiennent.
+func (c *Cleaner) matchTorrents(orphans []OrphanFile) ([]download.Item, error) {
+	if c.client == nil || len(orphans) == 0 {
+		return nil, nil
+	}
+
+	paths := make([]string, len(orphans))
+	for i, f := range orphans {
+		paths[i] = f.Path
+	}
+	return c.client.MatchPaths(paths)
+}
+
+// deferProtected retourne, par chemin, les fichiers des téléchargements
+// pas encore terminés ou que la politique de seed protège encore.
+func (c *Cleaner) deferProtected(matches []download.Item) map[string]DeferredFile {
+	deferred := make(map[string]DeferredFile)
+	for _, m := range matches {
+		reason := c.seeding.Protects(m)
+		if c.safety.SkipIncomplete && m.Incomplete {
+			reason = "download incomplete"
+		}
+		if reason == "" {
+			continue
+		}
+		for _, p := range m.Files {
+			deferred[p] = DeferredFile{Torrent: m.Name, Reason: reason}
+		}
+	}
+	return deferred
+}
+
+// removeTorrents retire du client de téléchargement les éléments dont
+// tous les fichiers vont être supprimés, avant la suppression des fichiers.
+func (c *Cleaner) removeTorrents(ctx context.Context, matches []download.Item, deletable []OrphanFile, result *CleanupResult) {
+	paths := make([]string, len(deletable))
+	for i, f := range deletable {
+		paths[i] = f.Path
+	}
+	orphans := download.PathSet(paths)
+
+	for _, m := range matches {
+		if !download.AllIn(m.Files, orphans) {
+			continue
+		}
+
+		err := c.client.Delete(m.ID, false)
+		c.record(ctx, audit.Entry{Action: audit.ActionRemoveDownload, Target: m.ID, Detail: m.Name}, err)
+		if err != nil {
+			c.logger.Warn("download client item not removed",
+				zap.String("id", m.ID),
+				zap.String("name", m.Name),
+				zap.Error(err),
+			)
+			result.Errors = append(result.Errors, err)
+			continue
+		}
+
+		c.logger.Info("download client item removed",
+			zap.String("id", m.ID),
+			zap.String("name", m.Name),
+		)
+		result.RemovedTorrents = append(result.RemovedTorrents, m.ID)
+	}
+}
+
+// record journalise une action si l'audit est activé.
+func (c *Cleaner) record(ctx context.Context, e audit.Entry, err error) {
+	if c.audit != nil {
+		c.audit.Record(ctx, e, err)
+	}
+}
+
+// TotalSize retourne la place occupée par des orphelins ; les fichiers
+// qui partagent un inode ne sont comptés qu'une fois.
+func TotalSize(files []OrphanFile) int64 {
+	var total int64
+	seen := make(map[fileID]bool)
+	for _, f := range files {
+		if f.id != (fileID{}) {
+			if seen[f.id] {
+				continue
+			}
+			seen[f.id] = true
+		}
+		total += f.Size
+	}
+	return total
+}
+
+// Backlog retourne les orphelins restés dans le downloadDir : ni
+// supprimés ni mis en corbeille (dry-run, différés, en erreur).
+func (r *CleanupResult) Backlog() []OrphanFile {
+	removed := make(map[string]bool, len(r.DeletedFiles)+len(r.TrashedFiles))
+	for _, f := range r.DeletedFiles {
+		removed[f.Path] = true
+	}
+	for _, f := range r.TrashedFiles {
+		removed[f.Path] = true
+	}
+
+	var left []OrphanFile
+	for _, f := range r.OrphanFiles {
+		if !removed[f.Path] {
+			left = append(left, f)
+		}
+	}
+	return left
+}
+
 // FreedBytesHuman retourne la taille libérée en format lisible.
 func (r *CleanupResult) FreedBytesHuman() string {
 	const unit = 1024
@@ -123,11 +488,43 @@ func (r *CleanupResult) FreedBytesHuman() string {
 	return fmt.Sprintf("%.1f %cB", float64(b)/float64(div), "KMGTPE"[exp])
 }
 
-func (c *Cleaner) removeEmptyDirs() error {
-	return filepath.WalkDir(c.downloadDir, func(path string, d fs.DirEntry, err error) error {
+// MarshalJSON ajoute la taille lisible et expose les erreurs sous forme
+// de messages, error n'étant pas sérialisable en JSON.
+func (r *CleanupResult) MarshalJSON() ([]byte, error) {
+	type alias CleanupResult
+
+	errs := make([]string, len(r.Errors))
+	for i, err := range r.Errors {
+		errs[i] = err.Error()
+	}
+
+	return json.Marshal(struct {
+		*alias
+		Freed  string   `json:"freed"`
+		Errors []string `json:"errors"`
+	}{
+		alias:  (*alias)(r),
+		Freed:  r.FreedBytesHuman(),
+		Errors: errs,
+	})
+}
+
+// removeEmptyDirs supprime les dossiers vides sous root, root compris
+// s'il n'est pas le downloadDir lui-même. Les dossiers exclus par le
+// Filter ou un .clarrignore ne sont pas parcourus.
+func (c *Cleaner) removeEmptyDirs(root string, m *matcher) error {
+	// Une release d'un seul fichier n'existe plus une fois supprimée.
+	if _, err := os.Lstat(root); errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
 		if err != nil || !d.IsDir() || path == c.downloadDir {
 			return err
 		}
+		if m.excludesDir(path) {
+			return filepath.SkipDir
+		}
 
 		entries, err := os.ReadDir(path)
 		if err != nil {
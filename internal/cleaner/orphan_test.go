@@ -1,10 +1,17 @@
 package cleaner
 
 import (
+	"context"
+	"errors"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/cleeryy/clarr/internal/audit"
+	"github.com/cleeryy/clarr/internal/download"
+	"github.com/cleeryy/clarr/internal/trash"
 	"go.uber.org/zap"
 )
 
@@ -38,7 +45,7 @@ func TestCleanup_DryRun_DoesNotDelete(t *testing.T) {
 	// nil pour qbit — pas besoin en dry-run.
 	c := New(dir, true, nil, setupLogger(t))
 
-	result, err := c.Cleanup()
+	result, err := c.Cleanup(context.Background())
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -64,7 +71,7 @@ func TestCleanup_DeletesOrphan(t *testing.T) {
 	// dry_run = false, qbit = nil.
 	c := New(dir, false, nil, setupLogger(t))
 
-	result, err := c.Cleanup()
+	result, err := c.Cleanup(context.Background())
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -83,6 +90,18 @@ func TestCleanup_DeletesOrphan(t *testing.T) {
 	}
 }
 
+func TestCleanup_RejectsConcurrentRun(t *testing.T) {
+	c := New(t.TempDir(), true, nil, setupLogger(t))
+
+	// Simule un run en cours.
+	c.running.Lock()
+	defer c.running.Unlock()
+
+	if _, err := c.Cleanup(context.Background()); !errors.Is(err, ErrAlreadyRunning) {
+		t.Errorf("expected ErrAlreadyRunning, got %v", err)
+	}
+}
+
 func TestFreedBytesHuman(t *testing.T) {
 	tests := []struct {
 		bytes    int64
@@ -101,3 +120,210 @@ func TestFreedBytesHuman(t *testing.T) {
 		}
 	}
 }
+
+func TestCleanup_MovesToTrash(t *testing.T) {
+	dir := t.TempDir()
+	bin, err := trash.Open(filepath.Join(dir, ".clarr-trash"), time.Hour, setupLogger(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	movie := filepath.Join(dir, "radarr", "Dune.2021", "Dune.2021.mkv")
+	if err := os.MkdirAll(filepath.Dir(movie), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(movie, []byte("fake video content"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := New(dir, false, nil, setupLogger(t), WithTrash(bin))
+
+	result, err := c.Cleanup(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.TrashedFiles) != 1 || len(result.DeletedFiles) != 0 || result.FreedBytes != 0 {
+		t.Fatalf("expected 1 trashed file and nothing freed, got %+v", result)
+	}
+
+	entries := bin.List()
+	if len(entries) != 1 || entries[0].Path != movie || entries[0].Reason == "" {
+		t.Fatalf("unexpected trash entries: %+v", entries)
+	}
+
+	// La corbeille est dans le downloadDir : elle n'est ni rescannée ni
+	// vidée comme un dossier d'orphelins.
+	result, err = c.Cleanup(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.ScannedFiles != 0 || len(bin.List()) != 1 {
+		t.Fatalf("trash was cleaned up: %+v", result)
+	}
+}
+
+func TestCleanup_KeepsRestoredFiles(t *testing.T) {
+	dir := t.TempDir()
+	trashDir := filepath.Join(dir, ".clarr-trash")
+	bin, err := trash.Open(trashDir, time.Hour, setupLogger(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	movie := filepath.Join(dir, "radarr", "Dune.2021", "Dune.2021.mkv")
+	if err := os.MkdirAll(filepath.Dir(movie), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(movie, []byte("fake video content"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := New(dir, false, nil, setupLogger(t), WithTrash(bin))
+	if _, err := c.Cleanup(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	entries := bin.List()
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 trash entry, got %+v", entries)
+	}
+	if _, errs := bin.Restore(context.Background(), []string{entries[0].ID}); len(errs) != 0 {
+		t.Fatalf("restore: %v", errs)
+	}
+
+	// Toujours sans hardlink, le fichier restauré reste en place, même
+	// après un redémarrage.
+	bin, err = trash.Open(trashDir, time.Hour, setupLogger(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c = New(dir, false, nil, setupLogger(t), WithTrash(bin))
+	result, err := c.Cleanup(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.TrashedFiles) != 0 || len(bin.List()) != 0 {
+		t.Fatalf("restored file moved to trash again: %+v", result)
+	}
+	if _, err := os.Stat(movie); err != nil {
+		t.Fatalf("restored file: %v", err)
+	}
+}
+
+func TestCleanup_RecordsAudit(t *testing.T) {
+	dir := t.TempDir()
+	log, err := audit.Open(t.TempDir(), setupLogger(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer log.Close()
+
+	movie := filepath.Join(dir, "Dune.2021.mkv")
+	if err := os.WriteFile(movie, []byte("fake video content"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := audit.WithTrigger(context.Background(), audit.TriggerAPI)
+	for _, dryRun := range []bool{true, false} {
+		c := New(dir, dryRun, nil, setupLogger(t), WithAudit(log))
+		if _, err := c.Cleanup(ctx); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	var entries []audit.Entry
+	if _, err := log.Query(audit.Filter{}, func(e audit.Entry) error {
+		entries = append(entries, e)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 audit entries, got %+v", entries)
+	}
+	for i, outcome := range []audit.Outcome{audit.OutcomeSuccess, audit.OutcomeSimulated} {
+		e := entries[i]
+		if e.Action != audit.ActionDeleteFile || e.Target != movie || e.Trigger != audit.TriggerAPI || e.Outcome != outcome || e.Detail == "" {
+			t.Errorf("entry %d = %+v, want %s delete of %s", i, e, outcome, movie)
+		}
+	}
+}
+
+func TestCleanupResult_Backlog(t *testing.T) {
+	result := &CleanupResult{
+		OrphanFiles: []OrphanFile{
+			{Path: "/downloads/Dune.2021.mkv", Size: 10},
+			{Path: "/downloads/Andor.S01E01.mkv", Size: 20},
+			{Path: "/downloads/Andor.S01E02.mkv", Size: 30},
+		},
+		DeletedFiles: []OrphanFile{{Path: "/downloads/Dune.2021.mkv", Size: 10}},
+		TrashedFiles: []OrphanFile{{Path: "/downloads/Andor.S01E01.mkv", Size: 20}},
+	}
+
+	backlog := result.Backlog()
+	if len(backlog) != 1 || backlog[0].Path != "/downloads/Andor.S01E02.mkv" {
+		t.Fatalf("expected only the file left in place, got %+v", backlog)
+	}
+}
+
+func TestRemoveTorrents(t *testing.T) {
+	// Éléments tels que MatchPaths les retourne : avec tous leurs fichiers.
+	matches := []download.Item{
+		{ID: "single", Name: "Movie.2021.mkv", Files: []string{"/downloads/complete/Movie.2021.mkv"}},
+		{ID: "neighbour", Name: "Other.2020.mkv", Files: []string{"/downloads/complete/Other.2020.mkv"}},
+		{ID: "pack", Name: "Show.S01", Files: []string{
+			"/downloads/complete/Show.S01/Show.S01E01.mkv",
+			"/downloads/complete/Show.S01/Show.S01E02.mkv",
+		}},
+	}
+
+	tests := []struct {
+		name      string
+		deletable []string
+		want      []string
+	}{
+		{
+			name:      "single file only removes its own torrent",
+			deletable: []string{"/downloads/complete/Movie.2021.mkv"},
+			want:      []string{"single"},
+		},
+		{
+			name:      "multi-file torrent kept while one file is still linked",
+			deletable: []string{"/downloads/complete/Show.S01/Show.S01E01.mkv"},
+			want:      nil,
+		},
+		{
+			name: "multi-file torrent removed when all files are orphaned",
+			deletable: []string{
+				"/downloads/complete/Show.S01/Show.S01E01.mkv",
+				"/downloads/complete/Show.S01/Show.S01E02.mkv",
+			},
+			want: []string{"pack"},
+		},
+		{
+			name:      "uncleaned paths still match",
+			deletable: []string{"/downloads//complete/./Other.2020.mkv"},
+			want:      []string{"neighbour"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &fakeDownloads{}
+			c := New("/downloads", false, client, setupLogger(t))
+
+			deletable := make([]OrphanFile, len(tt.deletable))
+			for i, p := range tt.deletable {
+				deletable[i] = OrphanFile{Path: p}
+			}
+			result := &CleanupResult{}
+			c.removeTorrents(context.Background(), matches, deletable, result)
+
+			if strings.Join(result.RemovedTorrents, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("removed %v, want %v", result.RemovedTorrents, tt.want)
+			}
+			if strings.Join(client.deleted, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("client saw deletes %v, want %v", client.deleted, tt.want)
+			}
+		})
+	}
+}
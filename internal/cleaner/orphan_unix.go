@@ -12,12 +12,18 @@ import (
 	"go.uber.org/zap"
 )
 
-// FindOrphans parcourt le downloadDir et retourne les fichiers
-// avec un link count == 1 (plus aucun hardlink dans movies/ ou tv/).
-func (c *Cleaner) FindOrphans() ([]OrphanFile, error) {
-	var orphans []OrphanFile
+// FindOrphans parcourt root et retourne les fichiers avec un link
+// count == 1 (plus aucun hardlink dans movies/ ou tv/).
+//
+// Avec des media roots, un fichier lié plusieurs fois est aussi orphelin
+// si tous ses liens ont été vus sous root et qu'aucun n'est dans une
+// bibliothèque. Un lien introuvable (hors de root et des bibliothèques)
+// garde le fichier.
+func (h *hardlinkDetector) FindOrphans(root string) ([]OrphanFile, error) {
+	var orphans, linked []OrphanFile
+	seen := make(map[fileID]uint64)
 
-	err := filepath.WalkDir(c.downloadDir, func(path string, d fs.DirEntry, err error) error {
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
@@ -36,20 +42,80 @@ func (c *Cleaner) FindOrphans() ([]OrphanFile, error) {
 			return fmt.Errorf("cannot read syscall stat for %s", path)
 		}
 
+		f := OrphanFile{
+			Path:  path,
+			Size:  info.Size(),
+			Links: uint64(stat.Nlink),
+			id:    fileID{dev: uint64(stat.Dev), ino: stat.Ino},
+		}
+
 		if stat.Nlink == 1 {
-			orphans = append(orphans, OrphanFile{
-				Path:  path,
-				Size:  info.Size(),
-				Links: uint64(stat.Nlink),
-			})
-			c.logger.Info("orphan found",
+			f.Reason = "no hardlink left"
+			orphans = append(orphans, f)
+			h.logger.Info("orphan found",
 				zap.String("path", path),
 				zap.Int64("size_bytes", info.Size()),
 			)
+		} else if len(h.mediaRoots) > 0 {
+			linked = append(linked, f)
+			seen[f.id]++
 		}
 
 		return nil
 	})
+	if err != nil || len(linked) == 0 {
+		return orphans, err
+	}
+
+	media, err := h.mediaInodes()
+	if err != nil {
+		return nil, fmt.Errorf("scan media roots: %w", err)
+	}
+
+	for _, f := range linked {
+		if media[f.id] || seen[f.id] != f.Links {
+			continue
+		}
+		f.Reason = "only linked inside download dir"
+		orphans = append(orphans, f)
+		h.logger.Info("orphan found, only linked inside download dir",
+			zap.String("path", f.Path),
+			zap.Int64("size_bytes", f.Size),
+			zap.Uint64("links", f.Links),
+		)
+	}
+	return orphans, nil
+}
+
+// mediaInodes retourne les inodes des fichiers des bibliothèques qui ont
+// d'autres liens, les seuls qui peuvent pointer vers le downloadDir.
+func (h *hardlinkDetector) mediaInodes() (map[fileID]bool, error) {
+	inodes := make(map[fileID]bool)
+	for _, root := range h.mediaRoots {
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				// Le downloadDir peut être sous une bibliothèque (/data/downloads).
+				if within(path, h.downloadDir) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
 
-	return orphans, err
+			info, err := os.Stat(path)
+			if err != nil {
+				return fmt.Errorf("stat %s: %w", path, err)
+			}
+			if stat, ok := info.Sys().(*syscall.Stat_t); ok && stat.Nlink > 1 {
+				inodes[fileID{dev: uint64(stat.Dev), ino: stat.Ino}] = true
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return inodes, nil
 }
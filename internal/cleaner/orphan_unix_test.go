@@ -0,0 +1,108 @@
+//go:build !windows
+
+package cleaner
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeLinked crée path puis un hardlink de path pour chaque links.
+func writeLinked(t *testing.T, path string, size int, links ...string) {
+	t.Helper()
+	for _, p := range append([]string{path}, links...) {
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(path, make([]byte, size), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, l := range links {
+		if err := os.Link(path, l); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestFindOrphans_MediaRoots(t *testing.T) {
+	base := t.TempDir()
+	downloads := filepath.Join(base, "downloads")
+	media := filepath.Join(base, "media")
+	if err := os.MkdirAll(media, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	// Cross-seed : deux liens dans le downloadDir, aucun en bibliothèque.
+	crossSeed := filepath.Join(downloads, "radarr", "Dune.2021.mkv")
+	crossSeedLink := filepath.Join(downloads, "cross-seed", "Dune.2021.mkv")
+	writeLinked(t, crossSeed, 100, crossSeedLink)
+	// Importé en bibliothèque.
+	writeLinked(t, filepath.Join(downloads, "radarr", "Arrival.2016.mkv"), 200, filepath.Join(media, "Arrival (2016)", "Arrival.mkv"))
+	// Lié hors du downloadDir et des bibliothèques : on ne sait pas, on garde.
+	writeLinked(t, filepath.Join(downloads, "radarr", "Heat.1995.mkv"), 300, filepath.Join(base, "elsewhere", "Heat.mkv"))
+
+	t.Run("nlink only", func(t *testing.T) {
+		c := New(downloads, true, nil, setupLogger(t))
+		orphans, err := c.FindOrphans()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(orphans) != 0 {
+			t.Fatalf("expected no orphans without media roots, got %+v", orphans)
+		}
+	})
+
+	t.Run("inode aware", func(t *testing.T) {
+		c := New(downloads, true, nil, setupLogger(t), WithMediaRoots(media))
+		orphans, err := c.FindOrphans()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		got := make(map[string]bool)
+		for _, o := range orphans {
+			got[o.Path] = true
+		}
+		if len(orphans) != 2 || !got[crossSeed] || !got[crossSeedLink] {
+			t.Fatalf("expected both cross-seed links as orphans, got %+v", orphans)
+		}
+		if size := TotalSize(orphans); size != 100 {
+			t.Errorf("TotalSize() = %d, want 100 (shared inode counted once)", size)
+		}
+	})
+
+	t.Run("cleanup counts shared inode once", func(t *testing.T) {
+		c := New(downloads, true, nil, setupLogger(t), WithMediaRoots(media))
+		result, err := c.Cleanup(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.FreedBytes != 100 {
+			t.Errorf("FreedBytes = %d, want 100", result.FreedBytes)
+		}
+	})
+}
+
+func TestFindOrphans_ScopedRunKeepsLinksOutsideScope(t *testing.T) {
+	base := t.TempDir()
+	downloads := filepath.Join(base, "downloads")
+	media := filepath.Join(base, "media")
+	if err := os.MkdirAll(media, 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeLinked(t, filepath.Join(downloads, "radarr", "Dune.2021.mkv"), 100, filepath.Join(downloads, "cross-seed", "Dune.2021.mkv"))
+
+	c := New(downloads, false, nil, setupLogger(t), WithMediaRoots(media))
+
+	// Le lien cross-seed n'est pas dans le périmètre : il n'a pas été vu.
+	result, err := c.CleanupPaths(context.Background(), []string{"radarr"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.OrphanFiles) != 0 {
+		t.Fatalf("expected no orphans, got %+v", result.OrphanFiles)
+	}
+}
@@ -4,7 +4,7 @@ package cleaner
 
 // FindOrphans is not supported on Windows.
 // Hardlink detection requires Unix syscalls.
-func (c *Cleaner) FindOrphans() ([]OrphanFile, error) {
-	c.logger.Warn("orphan detection is not supported on Windows")
+func (h *hardlinkDetector) FindOrphans(root string) ([]OrphanFile, error) {
+	h.logger.Warn("orphan detection is not supported on Windows")
 	return nil, nil
 }
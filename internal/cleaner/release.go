@@ -0,0 +1,94 @@
+package cleaner
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// ─── Releases ─────────────────────────────────────────────────────────
+
+// CleanupReleases nettoie uniquement les releases nommées, au lieu de
+// parcourir tout le downloadDir. Une release est un fichier ou un dossier
+// à la racine du downloadDir ou dans un sous-dossier de catégorie, dont
+// le nom (sans extension) est celui de la release.
+//
+// Sans release trouvée, rien n'est supprimé et le résultat est vide : les
+// orphelins restent à la charge du prochain Cleanup complet.
+func (c *Cleaner) CleanupReleases(ctx context.Context, names []string) (*CleanupResult, error) {
+	roots, err := c.locateReleases(names)
+	if err != nil {
+		return nil, err
+	}
+	if len(roots) == 0 {
+		c.logger.Info("no release found in download dir", zap.Strings("releases", names))
+		return &CleanupResult{DryRun: c.dryRun}, nil
+	}
+
+	return c.CleanupPaths(ctx, roots)
+}
+
+// locateReleases retourne les chemins des releases nommées, en ne lisant
+// que les deux premiers niveaux du downloadDir.
+func (c *Cleaner) locateReleases(names []string) ([]string, error) {
+	wanted := make(map[string]bool, len(names))
+	for _, n := range names {
+		if key := releaseKey(n); key != "" {
+			wanted[key] = true
+		}
+	}
+	if len(wanted) == 0 {
+		return nil, nil
+	}
+
+	entries, err := os.ReadDir(c.downloadDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var roots []string
+	for _, e := range entries {
+		p := filepath.Join(c.downloadDir, e.Name())
+		if wanted[releaseKey(e.Name())] {
+			roots = append(roots, p)
+			continue
+		}
+		if !e.IsDir() {
+			continue
+		}
+
+		// Dossier de catégorie (radarr/, tv-sonarr/...) : on regarde dedans.
+		sub, err := os.ReadDir(p)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return nil, err
+		}
+		for _, s := range sub {
+			if wanted[releaseKey(s.Name())] {
+				roots = append(roots, filepath.Join(p, s.Name()))
+			}
+		}
+	}
+	return roots, nil
+}
+
+// releaseKey normalise un nom de release pour la comparaison : casse
+// ignorée et extension de fichier vidéo retirée.
+func releaseKey(name string) string {
+	name = strings.ToLower(strings.TrimSpace(filepath.Base(name)))
+	if name == "." || name == string(filepath.Separator) {
+		return ""
+	}
+	switch filepath.Ext(name) {
+	case ".mkv", ".mp4", ".avi", ".m4v", ".ts", ".wmv", ".mov":
+		name = strings.TrimSuffix(name, filepath.Ext(name))
+	}
+	return name
+}
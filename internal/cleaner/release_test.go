@@ -0,0 +1,70 @@
+package cleaner
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCleanupReleases_OnlyTouchesNamedReleases(t *testing.T) {
+	dir := t.TempDir()
+
+	release := filepath.Join(dir, "radarr", "Dune.2021.2160p.WEB-DL")
+	if err := os.MkdirAll(release, 0755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"released": filepath.Join(release, "Dune.2021.2160p.WEB-DL.mkv"),
+		"single":   filepath.Join(dir, "Arrival.2016.1080p.BluRay.mkv"),
+		"other":    filepath.Join(dir, "radarr", "Other.Movie.2020.mkv"),
+	}
+	for _, f := range files {
+		if err := os.WriteFile(f, []byte("fake video content"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c := New(dir, false, nil, setupLogger(t))
+
+	result, err := c.CleanupReleases(context.Background(), []string{"dune.2021.2160p.web-dl", "Arrival.2016.1080p.BluRay"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(result.DeletedFiles) != 2 {
+		t.Fatalf("expected 2 deleted files, got %+v", result.DeletedFiles)
+	}
+	for _, name := range []string{"released", "single"} {
+		if _, err := os.Stat(files[name]); !os.IsNotExist(err) {
+			t.Errorf("%s release file should have been deleted", name)
+		}
+	}
+	if _, err := os.Stat(release); !os.IsNotExist(err) {
+		t.Error("empty release dir should have been removed")
+	}
+	if _, err := os.Stat(files["other"]); err != nil {
+		t.Errorf("file outside the releases must be kept: %v", err)
+	}
+}
+
+func TestCleanupReleases_UnknownRelease(t *testing.T) {
+	dir := t.TempDir()
+	orphan := filepath.Join(dir, "orphan.mkv")
+	if err := os.WriteFile(orphan, []byte("fake video content"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := New(dir, false, nil, setupLogger(t))
+
+	result, err := c.CleanupReleases(context.Background(), []string{"Not.Downloaded.Here"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.ScannedFiles != 0 {
+		t.Errorf("expected nothing scanned, got %d", result.ScannedFiles)
+	}
+	if _, err := os.Stat(orphan); err != nil {
+		t.Errorf("orphan outside the releases must be kept: %v", err)
+	}
+}
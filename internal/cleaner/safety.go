@@ -0,0 +1,128 @@
+package cleaner
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/cleeryy/clarr/internal/audit"
+)
+
+// ─── Safety ───────────────────────────────────────────────────────────
+
+// SafetyPolicy protège les fichiers qui ne sont orphelins qu'en
+// apparence : encore en cours d'écriture, pas encore importés ou sortis
+// du downloadDir depuis trop peu de temps. Un fichier protégé est reporté
+// comme différé et réexaminé au prochain run.
+type SafetyPolicy struct {
+	// MinAge est l'âge minimum d'un orphelin, calculé depuis la dernière
+	// modification de son contenu ou de ses liens (mtime/ctime). Il ne
+	// s'applique qu'aux scans complets : un cleanup ciblé (webhook, API
+	// avec paths) vise justement des fichiers que l'on vient de délier.
+	MinAge time.Duration
+	// SkipIncomplete garde les fichiers des téléchargements non terminés.
+	SkipIncomplete bool
+	// TempSuffixes sont les extensions des fichiers temporaires des
+	// clients de téléchargement (.!qB, .part), jamais supprimés.
+	TempSuffixes []string
+	// GraceScans est le nombre de scans planifiés consécutifs où un
+	// fichier doit être vu orphelin avant d'être supprimé ; 0 ou 1 le
+	// supprime dès le premier. Seuls les scans complets du cron, hors
+	// dry-run, comptent : deux cleanups déclenchés coup sur coup par des
+	// webhooks ou l'API n'écourtent pas la période. Nécessite WithSightings.
+	GraceScans int
+}
+
+// WithSafetyPolicy active les protections de p.
+func WithSafetyPolicy(p SafetyPolicy) Option {
+	return func(c *Cleaner) {
+		c.safety = p
+	}
+}
+
+// WithSightings persiste les orphelins vus à chaque scan, pour la
+// période de grâce de la SafetyPolicy.
+func WithSightings(s *Sightings) Option {
+	return func(c *Cleaner) {
+		c.sightings = s
+	}
+}
+
+// holdBack retourne, par chemin, pourquoi la SafetyPolicy garde un
+// orphelin pour ce run. Les scans sont enregistrés pour tous les
+// orphelins, protégés ou non, pour que la période de grâce court dès
+// qu'un fichier devient orphelin.
+func (c *Cleaner) holdBack(ctx context.Context, roots []string, orphans []OrphanFile, now time.Time) (map[string]string, error) {
+	held := make(map[string]string)
+
+	minAge := c.safety.MinAge
+	if !c.fullScan(roots) {
+		minAge = 0
+	}
+
+	grace := c.safety.GraceScans > 1 && c.sightings != nil
+	var seen map[string]Sighting
+	if grace {
+		var err error
+		if seen, err = c.sightings.observe(roots, orphans, now, c.countsSighting(ctx, roots)); err != nil {
+			return nil, fmt.Errorf("record sightings: %w", err)
+		}
+	}
+
+	for _, f := range orphans {
+		path := filepath.Clean(f.Path)
+		if reason := c.checkFile(f, now, minAge); reason != "" {
+			held[path] = reason
+			continue
+		}
+		if !grace {
+			continue
+		}
+		switch s, ok := seen[path]; {
+		case !ok:
+			held[path] = fmt.Sprintf("seen orphaned in 0/%d scans", c.safety.GraceScans)
+		case s.Scans < c.safety.GraceScans:
+			held[path] = fmt.Sprintf("seen orphaned in %d/%d scans since %s",
+				s.Scans, c.safety.GraceScans, s.FirstSeen.Format(time.RFC3339))
+		}
+	}
+	return held, nil
+}
+
+// countsSighting indique si ce scan compte pour la période de grâce : un
+// scan complet du downloadDir lancé par le cron, hors dry-run.
+func (c *Cleaner) countsSighting(ctx context.Context, roots []string) bool {
+	return c.fullScan(roots) && !c.dryRun && audit.TriggerFrom(ctx) == audit.TriggerCron
+}
+
+// fullScan indique si roots couvre tout le downloadDir.
+func (c *Cleaner) fullScan(roots []string) bool {
+	return len(roots) == 1 && filepath.Clean(roots[0]) == filepath.Clean(c.downloadDir)
+}
+
+// checkFile applique les règles qui ne dépendent que du fichier. minAge
+// vaut 0 quand l'âge ne doit pas être vérifié.
+func (c *Cleaner) checkFile(f OrphanFile, now time.Time, minAge time.Duration) string {
+	name := strings.ToLower(f.Path)
+	for _, suffix := range c.safety.TempSuffixes {
+		if suffix != "" && strings.HasSuffix(name, strings.ToLower(suffix)) {
+			return "temporary download file"
+		}
+	}
+
+	if minAge <= 0 {
+		return ""
+	}
+	info, err := os.Lstat(f.Path)
+	if err != nil {
+		// Le fichier a bougé pendant le run : on le reverra au prochain.
+		return fmt.Sprintf("cannot stat: %v", err)
+	}
+	if age := now.Sub(changedAt(info)); age < minAge {
+		return fmt.Sprintf("changed %s ago, younger than %s", age.Round(time.Second), minAge)
+	}
+	return ""
+}
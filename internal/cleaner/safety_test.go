@@ -0,0 +1,281 @@
+package cleaner
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cleeryy/clarr/internal/audit"
+	"github.com/cleeryy/clarr/internal/download"
+)
+
+// fakeDownloads est un client de téléchargement figé.
+type fakeDownloads struct {
+	items   []download.Item
+	deleted []string
+}
+
+func (f *fakeDownloads) MatchPaths(paths []string) ([]download.Item, error) {
+	wanted := download.PathSet(paths)
+	var matches []download.Item
+	for _, it := range f.items {
+		if download.AnyIn(it.Files, wanted) {
+			matches = append(matches, it)
+		}
+	}
+	return matches, nil
+}
+
+func (f *fakeDownloads) Delete(id string, _ bool) error {
+	f.deleted = append(f.deleted, id)
+	return nil
+}
+
+func (f *fakeDownloads) Pause(string) error { return nil }
+
+func deferredReasons(result *CleanupResult) map[string]string {
+	reasons := make(map[string]string)
+	for _, d := range result.DeferredFiles {
+		reasons[d.Path] = d.Reason
+	}
+	return reasons
+}
+
+func TestHoldBack(t *testing.T) {
+	dir := t.TempDir()
+	movie := filepath.Join(dir, "Dune.2021.mkv")
+	partial := filepath.Join(dir, "Arrival.2016.mkv.!qb")
+	writeFile(t, movie, []byte("dune"))
+	writeFile(t, partial, []byte("arrival"))
+	orphans := []OrphanFile{{Path: movie}, {Path: partial}}
+
+	c := New(dir, true, nil, setupLogger(t), WithSafetyPolicy(SafetyPolicy{
+		MinAge:       time.Hour,
+		TempSuffixes: []string{".!qB", ".part"},
+	}))
+
+	held, err := c.holdBack(context.Background(), []string{dir}, orphans, time.Now())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(held[movie], "younger than 1h") {
+		t.Errorf("fresh file: reason %q, want younger than 1h", held[movie])
+	}
+	if held[partial] != "temporary download file" {
+		t.Errorf("temp file: reason %q, want temporary download file", held[partial])
+	}
+
+	held, err = c.holdBack(context.Background(), []string{dir}, orphans, time.Now().Add(2*time.Hour))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := held[movie]; ok {
+		t.Errorf("old file must not be held: %q", held[movie])
+	}
+	if _, ok := held[partial]; !ok {
+		t.Error("temp file must be held whatever its age")
+	}
+}
+
+func TestCleanup_MinAgeOnlyOnFullScans(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "downloads")
+	library := filepath.Join(root, "library")
+	release := filepath.Join(dir, "Dune.2021.2160p.WEB-DL")
+	video := filepath.Join(release, "Dune.2021.2160p.WEB-DL.mkv")
+	other := filepath.Join(dir, "Heat.1995.mkv")
+	writeFile(t, video, []byte("dune"))
+	writeFile(t, other, []byte("heat"))
+
+	// Radarr supprime le film : le lien de la bibliothèque disparaît, ce
+	// qui rafraîchit le ctime du fichier téléchargé.
+	writeFile(t, filepath.Join(library, "keep"), nil)
+	link := filepath.Join(library, "Dune (2021).mkv")
+	if err := os.Link(video, link); err != nil {
+		t.Skipf("hardlinks unsupported: %v", err)
+	}
+	if err := os.Remove(link); err != nil {
+		t.Fatal(err)
+	}
+
+	c := New(dir, false, nil, setupLogger(t), WithSafetyPolicy(SafetyPolicy{MinAge: time.Hour}))
+
+	ctx := audit.WithTrigger(context.Background(), audit.WebhookTrigger("radarr", 1))
+	result, err := c.CleanupReleases(ctx, []string{"Dune.2021.2160p.WEB-DL"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.DeletedFiles) != 1 || result.DeletedFiles[0].Path != video {
+		t.Fatalf("webhook cleanup: deleted %+v, deferred %+v, want %s deleted", result.DeletedFiles, result.DeferredFiles, video)
+	}
+
+	result, err = c.Cleanup(audit.WithTrigger(context.Background(), audit.TriggerCron))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.DeletedFiles) != 0 || !strings.Contains(deferredReasons(result)[other], "younger than 1h") {
+		t.Errorf("full scan: deleted %+v, deferred %+v, want %s held by min_age", result.DeletedFiles, result.DeferredFiles, other)
+	}
+}
+
+func TestCleanup_SkipsIncompleteDownloads(t *testing.T) {
+	dir := t.TempDir()
+	downloading := filepath.Join(dir, "Dune.2021", "Dune.2021.mkv")
+	done := filepath.Join(dir, "Heat.1995.mkv")
+	writeFile(t, downloading, []byte("dune"))
+	writeFile(t, done, []byte("heat"))
+
+	client := &fakeDownloads{items: []download.Item{
+		{ID: "a", Name: "Dune.2021", Incomplete: true, Files: []string{downloading}},
+		{ID: "b", Name: "Heat.1995", Files: []string{done}},
+	}}
+	c := New(dir, false, client, setupLogger(t), WithSafetyPolicy(SafetyPolicy{SkipIncomplete: true}))
+
+	result, err := c.Cleanup(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if reason := deferredReasons(result)[downloading]; reason != "download incomplete" {
+		t.Errorf("reason = %q, want download incomplete", reason)
+	}
+	if len(result.DeletedFiles) != 1 || result.DeletedFiles[0].Path != done {
+		t.Errorf("expected only %s deleted, got %+v", done, result.DeletedFiles)
+	}
+	if len(client.deleted) != 1 || client.deleted[0] != "b" {
+		t.Errorf("expected only torrent b removed, got %v", client.deleted)
+	}
+}
+
+func TestCleanup_GracePeriod(t *testing.T) {
+	dir := t.TempDir()
+	sightings, err := OpenSightings(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sightings.Close() })
+
+	movie := filepath.Join(dir, "Dune.2021.mkv")
+	writeFile(t, movie, []byte("dune"))
+
+	c := New(dir, false, nil, setupLogger(t),
+		WithSafetyPolicy(SafetyPolicy{GraceScans: 2}),
+		WithSightings(sightings))
+
+	cron := audit.WithTrigger(context.Background(), audit.TriggerCron)
+	result, err := c.Cleanup(cron)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if reason := deferredReasons(result)[movie]; !strings.HasPrefix(reason, "seen orphaned in 1/2 scans") {
+		t.Fatalf("first scan: reason %q, want seen orphaned in 1/2 scans", reason)
+	}
+
+	// Un autre Cleaner sur la même base : les scans survivent au redémarrage.
+	c = New(dir, false, nil, setupLogger(t),
+		WithSafetyPolicy(SafetyPolicy{GraceScans: 2}),
+		WithSightings(sightings))
+
+	result, err = c.Cleanup(cron)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.DeletedFiles) != 1 {
+		t.Fatalf("second scan: expected %s deleted, got %+v", movie, result)
+	}
+
+	// Un fichier du même nom qui réapparaît repart de zéro.
+	writeFile(t, movie, []byte("dune again"))
+	result, err = c.Cleanup(cron)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.DeletedFiles) != 0 {
+		t.Fatalf("new orphan deleted on its first scan: %+v", result.DeletedFiles)
+	}
+}
+
+func TestCleanup_GracePeriodCountsScheduledScansOnly(t *testing.T) {
+	dir := t.TempDir()
+	sightings, err := OpenSightings(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sightings.Close() })
+
+	release := filepath.Join(dir, "Dune.2021")
+	movie := filepath.Join(release, "Dune.2021.mkv")
+	writeFile(t, movie, []byte("dune"))
+
+	policy := WithSafetyPolicy(SafetyPolicy{GraceScans: 2})
+	c := New(dir, false, nil, setupLogger(t), policy, WithSightings(sightings))
+	hook := audit.WithTrigger(context.Background(), audit.WebhookTrigger("radarr", 1))
+
+	// Deux cleanups ciblés coup sur coup, puis un complet lancé par l'API
+	// et un dry-run du cron : aucun ne compte.
+	for _, run := range []func() (*CleanupResult, error){
+		func() (*CleanupResult, error) { return c.CleanupPaths(hook, []string{release}) },
+		func() (*CleanupResult, error) { return c.CleanupReleases(hook, []string{"Dune.2021"}) },
+		func() (*CleanupResult, error) {
+			return c.Cleanup(audit.WithTrigger(context.Background(), audit.TriggerAPI))
+		},
+		func() (*CleanupResult, error) {
+			dry := New(dir, true, nil, setupLogger(t), policy, WithSightings(sightings))
+			return dry.Cleanup(audit.WithTrigger(context.Background(), audit.TriggerCron))
+		},
+	} {
+		result, err := run()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(result.DeletedFiles) != 0 {
+			t.Fatalf("grace period satisfied by unscheduled scans: %+v", result.DeletedFiles)
+		}
+		if reason := deferredReasons(result)[movie]; reason != "seen orphaned in 0/2 scans" {
+			t.Errorf("reason = %q, want seen orphaned in 0/2 scans", reason)
+		}
+	}
+
+	// Le premier scan du cron compte pour 1 : le fichier reste protégé.
+	result, err := c.Cleanup(audit.WithTrigger(context.Background(), audit.TriggerCron))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.DeletedFiles) != 0 {
+		t.Fatalf("deleted after a single scheduled scan: %+v", result.DeletedFiles)
+	}
+}
+
+func TestSightings_ResetWhenNoLongerOrphan(t *testing.T) {
+	sightings, err := OpenSightings(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sightings.Close() })
+
+	now := time.Now()
+	movie := OrphanFile{Path: "/downloads/radarr/Dune.2021.mkv"}
+	show := OrphanFile{Path: "/downloads/tv/Andor.S01E01.mkv"}
+
+	if _, err := sightings.observe([]string{"/downloads"}, []OrphanFile{movie, show}, now, true); err != nil {
+		t.Fatal(err)
+	}
+	// Scan ciblé de radarr/ où Dune n'est plus orphelin : Andor, hors du
+	// périmètre, garde son compte.
+	if _, err := sightings.observe([]string{"/downloads/radarr"}, nil, now, false); err != nil {
+		t.Fatal(err)
+	}
+
+	seen, err := sightings.observe([]string{"/downloads"}, []OrphanFile{movie, show}, now.Add(time.Hour), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := seen[movie.Path]; got.Scans != 1 || !got.FirstSeen.Equal(now.Add(time.Hour)) {
+		t.Errorf("movie sighting = %+v, want reset to 1 scan", got)
+	}
+	if got := seen[show.Path]; got.Scans != 2 || !got.FirstSeen.Equal(now) {
+		t.Errorf("show sighting = %+v, want 2 scans since first run", got)
+	}
+}
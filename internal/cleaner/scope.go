@@ -0,0 +1,74 @@
+package cleaner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// ErrOutsideDownloadDir est retourné quand un chemin à nettoyer n'est pas
+// dans le downloadDir.
+var ErrOutsideDownloadDir = errors.New("cleaner: path outside download dir")
+
+// ─── Scope ────────────────────────────────────────────────────────────
+
+// Scope valide et normalise les chemins d'un cleanup ciblé. Un chemin
+// relatif l'est au downloadDir ; un chemin hors du downloadDir est refusé.
+// Les chemins contenus dans un autre sont retirés, pour ne pas compter
+// deux fois leurs fichiers.
+func (c *Cleaner) Scope(paths []string) ([]string, error) {
+	base := filepath.Clean(c.downloadDir)
+
+	roots := make([]string, 0, len(paths))
+	for _, p := range paths {
+		if strings.TrimSpace(p) == "" {
+			continue
+		}
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(base, p)
+		}
+		p = filepath.Clean(p)
+		if !within(p, base) {
+			return nil, fmt.Errorf("%w: %s", ErrOutsideDownloadDir, p)
+		}
+		roots = append(roots, p)
+	}
+
+	sort.Strings(roots)
+	var scoped []string
+	for _, p := range roots {
+		if n := len(scoped); n > 0 && within(p, scoped[n-1]) {
+			continue
+		}
+		scoped = append(scoped, p)
+	}
+	return scoped, nil
+}
+
+// CleanupPaths nettoie uniquement les fichiers et dossiers donnés, par
+// exemple le dossier d'une release ou le contenu d'un torrent, au lieu de
+// parcourir tout le downloadDir. Les chemins passent par Scope ; ceux qui
+// n'existent plus sont ignorés.
+func (c *Cleaner) CleanupPaths(ctx context.Context, paths []string) (*CleanupResult, error) {
+	roots, err := c.Scope(paths)
+	if err != nil {
+		return nil, err
+	}
+	if len(roots) == 0 {
+		return &CleanupResult{DryRun: c.dryRun}, nil
+	}
+
+	c.logger.Info("targeted cleanup", zap.Strings("roots", roots))
+	return c.cleanup(ctx, roots)
+}
+
+// within indique si p est root ou se trouve sous root.
+func within(p, root string) bool {
+	rel, err := filepath.Rel(root, p)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
@@ -0,0 +1,88 @@
+package cleaner
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestScope(t *testing.T) {
+	dir := t.TempDir()
+	c := New(dir, true, nil, setupLogger(t))
+
+	tests := []struct {
+		name    string
+		paths   []string
+		want    []string
+		wantErr bool
+	}{
+		{"relative to download dir", []string{"radarr/Dune"}, []string{filepath.Join(dir, "radarr/Dune")}, false},
+		{"absolute", []string{filepath.Join(dir, "tv")}, []string{filepath.Join(dir, "tv")}, false},
+		{"nested paths merged", []string{filepath.Join(dir, "radarr/Dune/Dune.mkv"), "radarr/Dune", "radarr/Dune2"},
+			[]string{filepath.Join(dir, "radarr/Dune"), filepath.Join(dir, "radarr/Dune2")}, false},
+		{"blank ignored", []string{" "}, nil, false},
+		{"outside", []string{"/etc"}, nil, true},
+		{"escapes with ..", []string{"../elsewhere"}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := c.Scope(tt.paths)
+			if tt.wantErr {
+				if !errors.Is(err, ErrOutsideDownloadDir) {
+					t.Fatalf("err = %v, want ErrOutsideDownloadDir", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("Scope() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCleanupPaths_OnlyScansGivenPaths(t *testing.T) {
+	dir := t.TempDir()
+
+	scoped := filepath.Join(dir, "radarr", "Dune.2021", "Dune.2021.mkv")
+	outside := filepath.Join(dir, "radarr", "Arrival.2016", "Arrival.2016.mkv")
+	for _, f := range []string{scoped, outside} {
+		if err := os.MkdirAll(filepath.Dir(f), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(f, []byte("fake video content"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c := New(dir, false, nil, setupLogger(t))
+
+	result, err := c.CleanupPaths(context.Background(), []string{"radarr/Dune.2021", "radarr/Already.Gone"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if result.ScannedFiles != 1 || len(result.DeletedFiles) != 1 {
+		t.Fatalf("expected 1 scanned and deleted file, got %+v", result)
+	}
+	if _, err := os.Stat(scoped); !os.IsNotExist(err) {
+		t.Error("orphan in scope should have been deleted")
+	}
+	if _, err := os.Stat(outside); err != nil {
+		t.Errorf("orphan out of scope must be kept: %v", err)
+	}
+}
+
+func TestCleanupPaths_RejectsOutsideDownloadDir(t *testing.T) {
+	c := New(t.TempDir(), false, nil, setupLogger(t))
+
+	if _, err := c.CleanupPaths(context.Background(), []string{"/"}); !errors.Is(err, ErrOutsideDownloadDir) {
+		t.Fatalf("err = %v, want ErrOutsideDownloadDir", err)
+	}
+}
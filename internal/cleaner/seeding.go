@@ -0,0 +1,107 @@
+package cleaner
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/cleeryy/clarr/internal/download"
+)
+
+// SeedingPolicy décide si un torrent doit continuer à seeder avant que
+// ses fichiers orphelins puissent être supprimés. Les minimums globaux
+// s'appliquent à tous les torrents, les règles à ceux qui matchent leur
+// catégorie ou leur tracker. Toutes les contraintes applicables doivent
+// être satisfaites.
+type SeedingPolicy struct {
+	MinRatio       float64
+	MinSeedingTime time.Duration
+	Rules          []SeedingRule
+}
+
+// SeedingRule s'applique aux torrents d'une catégorie et/ou d'un tracker.
+// Un champ vide matche tout ; une règle sans Category ni Tracker est ignorée.
+type SeedingRule struct {
+	Category       string
+	Tracker        string // hôte du tracker, sous-domaines inclus
+	MinRatio       float64
+	MinSeedingTime time.Duration
+	AlwaysKeep     bool
+}
+
+// Protects retourne pourquoi le torrent doit être conservé, ou "" si ses
+// fichiers peuvent être supprimés.
+func (p SeedingPolicy) Protects(t download.Item) string {
+	if reason := checkMinimums(t, p.MinRatio, p.MinSeedingTime); reason != "" {
+		return reason
+	}
+
+	for _, r := range p.Rules {
+		if !r.matches(t) {
+			continue
+		}
+		if r.AlwaysKeep {
+			return fmt.Sprintf("always kept (%s)", r.describe())
+		}
+		if reason := checkMinimums(t, r.MinRatio, r.MinSeedingTime); reason != "" {
+			return fmt.Sprintf("%s (%s)", reason, r.describe())
+		}
+	}
+
+	return ""
+}
+
+// enabled indique si la politique peut protéger un torrent.
+func (p SeedingPolicy) enabled() bool {
+	return p.MinRatio > 0 || p.MinSeedingTime > 0 || len(p.Rules) > 0
+}
+
+func (r SeedingRule) matches(t download.Item) bool {
+	if r.Category == "" && r.Tracker == "" {
+		return false
+	}
+	if r.Category != "" && !strings.EqualFold(r.Category, t.Category) {
+		return false
+	}
+	if r.Tracker != "" && !trackerMatches(t.Tracker, r.Tracker) {
+		return false
+	}
+	return true
+}
+
+func (r SeedingRule) describe() string {
+	var parts []string
+	if r.Category != "" {
+		parts = append(parts, "category "+r.Category)
+	}
+	if r.Tracker != "" {
+		parts = append(parts, "tracker "+r.Tracker)
+	}
+	return strings.Join(parts, ", ")
+}
+
+func checkMinimums(t download.Item, minRatio float64, minSeeding time.Duration) string {
+	if minRatio > 0 && t.Ratio < minRatio {
+		return fmt.Sprintf("ratio %.2f below %.2f", t.Ratio, minRatio)
+	}
+	if minSeeding > 0 && t.SeedingTime < minSeeding {
+		return fmt.Sprintf("seeding time %s below %s", t.SeedingTime, minSeeding)
+	}
+	return ""
+}
+
+// trackerMatches compare l'hôte de l'URL du tracker à host,
+// "tracker.example.org" matchant aussi "announce.tracker.example.org".
+func trackerMatches(trackerURL, host string) bool {
+	if trackerURL == "" {
+		return false
+	}
+	u, err := url.Parse(trackerURL)
+	if err != nil || u.Hostname() == "" {
+		return false
+	}
+
+	got, want := strings.ToLower(u.Hostname()), strings.ToLower(host)
+	return got == want || strings.HasSuffix(got, "."+want)
+}
@@ -0,0 +1,115 @@
+package cleaner
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cleeryy/clarr/internal/download"
+	"github.com/cleeryy/clarr/internal/qbittorrent"
+)
+
+func TestSeedingPolicy_Protects(t *testing.T) {
+	policy := SeedingPolicy{
+		MinSeedingTime: time.Hour,
+		Rules: []SeedingRule{
+			{Category: "radarr-private", MinRatio: 1.0, MinSeedingTime: 72 * time.Hour},
+			{Tracker: "tracker.example.org", AlwaysKeep: true},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		torrent   download.Item
+		protected bool
+	}{
+		{"public torrent past global minimum", download.Item{Category: "radarr", SeedingTime: 2 * time.Hour}, false},
+		{"global minimum not reached", download.Item{Category: "radarr", SeedingTime: time.Minute}, true},
+		{"category ratio not reached", download.Item{Category: "radarr-private", Ratio: 0.4, SeedingTime: 300 * time.Hour}, true},
+		{"category seed time not reached", download.Item{Category: "Radarr-Private", Ratio: 2, SeedingTime: 24 * time.Hour}, true},
+		{"category rule satisfied", download.Item{Category: "radarr-private", Ratio: 1.2, SeedingTime: 100 * time.Hour}, false},
+		{"always keep tracker", download.Item{Tracker: "https://tracker.example.org/announce?passkey=x", Ratio: 10, SeedingTime: 1e6 * time.Second}, true},
+		{"always keep tracker subdomain", download.Item{Tracker: "udp://announce.tracker.example.org:1337", Ratio: 10, SeedingTime: 1e6 * time.Second}, true},
+		{"tracker suffix is not a subdomain", download.Item{Tracker: "https://eviltracker.example.org/announce", Ratio: 10, SeedingTime: 1e6 * time.Second}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reason := policy.Protects(tt.torrent)
+			if (reason != "") != tt.protected {
+				t.Errorf("Protects() = %q, want protected=%v", reason, tt.protected)
+			}
+		})
+	}
+}
+
+func TestCleanup_DefersSeedingTorrents(t *testing.T) {
+	dir := t.TempDir()
+	seeding := filepath.Join(dir, "Private.Movie.mkv")
+	done := filepath.Join(dir, "Public.Movie.mkv")
+	for _, p := range []string{seeding, done} {
+		if err := os.WriteFile(p, []byte("fake video content"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	torrents := []qbittorrent.Torrent{
+		{Hash: "private", Name: "Private.Movie.mkv", SavePath: dir, ContentPath: seeding, Category: "private", Ratio: 0.2},
+		{Hash: "public", Name: "Public.Movie.mkv", SavePath: dir, ContentPath: done, Category: "public", Ratio: 0.2},
+	}
+	var deleted []string
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v2/auth/login", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("Ok."))
+	})
+	mux.HandleFunc("/api/v2/torrents/info", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(torrents)
+	})
+	mux.HandleFunc("/api/v2/torrents/files", func(w http.ResponseWriter, r *http.Request) {
+		for _, tr := range torrents {
+			if tr.Hash == r.URL.Query().Get("hash") {
+				_ = json.NewEncoder(w).Encode([]qbittorrent.TorrentFile{{Name: tr.Name}})
+			}
+		}
+	})
+	mux.HandleFunc("/api/v2/torrents/delete", func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		deleted = append(deleted, r.PostForm.Get("hashes"))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	qbit, err := qbittorrent.New(srv.URL, "admin", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := New(dir, false, qbit, setupLogger(t), WithSeedingPolicy(SeedingPolicy{
+		Rules: []SeedingRule{{Category: "private", MinRatio: 1.0}},
+	}))
+
+	result, err := c.Cleanup(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := os.Stat(seeding); err != nil {
+		t.Error("file of a seeding torrent should be kept")
+	}
+	if _, err := os.Stat(done); !os.IsNotExist(err) {
+		t.Error("file of a finished torrent should be deleted")
+	}
+	if len(result.DeferredFiles) != 1 || result.DeferredFiles[0].Path != seeding {
+		t.Errorf("expected %s deferred, got %+v", seeding, result.DeferredFiles)
+	}
+	if strings.Join(deleted, ",") != "public" {
+		t.Errorf("expected only the public torrent removed, got %v", deleted)
+	}
+}
@@ -0,0 +1,139 @@
+package cleaner
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var sightingsBucket = []byte("sightings")
+
+// Sightings persiste, par chemin, depuis quand un fichier est vu orphelin
+// et dans combien de scans planifiés consécutifs. Un fichier qui n'est
+// plus orphelin au scan suivant, quel qu'il soit, repart de zéro.
+type Sightings struct {
+	db *bolt.DB
+}
+
+// Sighting est l'historique d'un orphelin.
+type Sighting struct {
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+	Scans     int       `json:"scans"`
+}
+
+// OpenSightings ouvre (ou crée) le stockage des orphelins dans dataDir.
+func OpenSightings(dataDir string) (*Sightings, error) {
+	if err := os.MkdirAll(dataDir, 0o755); err != nil {
+		return nil, fmt.Errorf("cleaner: create data dir: %w", err)
+	}
+
+	db, err := bolt.Open(filepath.Join(dataDir, "cleaner.db"), 0o600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("cleaner: open db: %w", err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(sightingsBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("cleaner: create bucket: %w", err)
+	}
+
+	return &Sightings{db: db}, nil
+}
+
+// Close ferme la base.
+func (s *Sightings) Close() error {
+	return s.db.Close()
+}
+
+// observe enregistre un scan de roots : les chemins sous roots qui ne sont
+// plus orphelins sont oubliés, ceux hors de roots n'ont pas été regardés et
+// restent tels quels. Avec counted, les orphelins gagnent un scan ; sinon
+// leur historique est seulement lu, et ceux jamais comptés sont absents.
+func (s *Sightings) observe(roots []string, orphans []OrphanFile, now time.Time, counted bool) (map[string]Sighting, error) {
+	current := make(map[string]bool, len(orphans))
+	for _, f := range orphans {
+		current[filepath.Clean(f.Path)] = true
+	}
+
+	seen := make(map[string]Sighting, len(orphans))
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(sightingsBucket)
+
+		var stale [][]byte
+		err := b.ForEach(func(k, _ []byte) error {
+			path := string(k)
+			if !current[path] && inRoots(path, roots) {
+				stale = append(stale, append([]byte(nil), k...))
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, k := range stale {
+			if err := b.Delete(k); err != nil {
+				return err
+			}
+		}
+
+		for path := range current {
+			sg := Sighting{FirstSeen: now}
+			v := b.Get([]byte(path))
+			if v != nil {
+				if err := json.Unmarshal(v, &sg); err != nil {
+					return fmt.Errorf("decode sighting %s: %w", path, err)
+				}
+			}
+			if !counted {
+				if v != nil {
+					seen[path] = sg
+				}
+				continue
+			}
+			sg.Scans++
+			sg.LastSeen = now
+
+			data, err := json.Marshal(sg)
+			if err != nil {
+				return err
+			}
+			if err := b.Put([]byte(path), data); err != nil {
+				return err
+			}
+			seen[path] = sg
+		}
+		return nil
+	})
+	return seen, err
+}
+
+// forget retire des chemins supprimés.
+func (s *Sightings) forget(paths []string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(sightingsBucket)
+		for _, p := range paths {
+			if err := b.Delete([]byte(filepath.Clean(p))); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func inRoots(path string, roots []string) bool {
+	for _, r := range roots {
+		if within(path, r) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,45 @@
+package cleaner
+
+import (
+	"time"
+)
+
+// ─── Stats ────────────────────────────────────────────────────────────
+
+// Stats résume les orphelins qui attendent le prochain cleanup.
+type Stats struct {
+	OrphanCount int
+	OrphanSize  int64
+	CheckedAt   time.Time
+}
+
+// WithStatsCache garde le résultat de Stats pendant ttl : /api/stats peut
+// être appelé sans clé et ne doit pas relancer un parcours complet du
+// downloadDir à chaque requête.
+func WithStatsCache(ttl time.Duration) Option {
+	return func(c *Cleaner) {
+		c.statsTTL = ttl
+	}
+}
+
+// Stats retourne les orphelins en attente, depuis le cache tant qu'il a
+// moins de ttl. Les appels concurrents attendent le même parcours.
+func (c *Cleaner) Stats() (Stats, error) {
+	c.statsMu.Lock()
+	defer c.statsMu.Unlock()
+
+	if c.stats != nil && time.Since(c.stats.CheckedAt) < c.statsTTL {
+		return *c.stats, nil
+	}
+
+	orphans, err := c.FindOrphans()
+	if err != nil {
+		return Stats{}, err
+	}
+	c.stats = &Stats{
+		OrphanCount: len(orphans),
+		OrphanSize:  TotalSize(orphans),
+		CheckedAt:   time.Now(),
+	}
+	return *c.stats, nil
+}
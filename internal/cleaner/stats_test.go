@@ -0,0 +1,32 @@
+package cleaner
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStats_Cache(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "Dune.2021.mkv"), []byte("dune"))
+
+	c := New(dir, true, nil, setupLogger(t), WithStatsCache(time.Hour))
+	first, err := c.Stats()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if first.OrphanCount != 1 || first.OrphanSize != 4 {
+		t.Fatalf("stats = %+v, want 1 orphan of 4 bytes", first)
+	}
+
+	// Un nouvel orphelin n'apparaît qu'une fois le cache expiré.
+	writeFile(t, filepath.Join(dir, "Heat.1995.mkv"), []byte("heat"))
+	if got, _ := c.Stats(); got != first {
+		t.Errorf("stats = %+v within the ttl, want the cached %+v", got, first)
+	}
+
+	uncached := New(dir, true, nil, setupLogger(t))
+	if got, _ := uncached.Stats(); got.OrphanCount != 2 {
+		t.Errorf("stats without cache = %+v, want 2 orphans", got)
+	}
+}
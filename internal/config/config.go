@@ -1,16 +1,34 @@
 package config
 
 import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/bmatcuk/doublestar/v4"
 	"github.com/ilyakaznacheev/cleanenv"
 )
 
 type Config struct {
-	Server      ServerConfig      `yaml:"server"`
-	Jellyfin    JellyfinConfig    `yaml:"jellyfin"`
-	Radarr      RadarrConfig      `yaml:"radarr"`
-	Sonarr      SonarrConfig      `yaml:"sonarr"`
-	Qbittorrent QbittorrentConfig `yaml:"qbittorrent"`
-	Cleaner     CleanerConfig     `yaml:"cleaner"`
+	Server         ServerConfig       `yaml:"server"`
+	API            APIConfig          `yaml:"api"`
+	Jellyfin       JellyfinConfig     `yaml:"jellyfin"`
+	Plex           PlexConfig         `yaml:"plex"`
+	Emby           EmbyConfig         `yaml:"emby"`
+	ArrWebhook     ArrWebhookConfig   `yaml:"arr_webhook"`
+	Radarr         RadarrConfig       `yaml:"radarr"`
+	Sonarr         SonarrConfig       `yaml:"sonarr"`
+	Lidarr         LidarrConfig       `yaml:"lidarr"`
+	Readarr        ReadarrConfig      `yaml:"readarr"`
+	DownloadClient string             `yaml:"download_client" env:"CLARR_DOWNLOAD_CLIENT" env-default:"qbittorrent"` // qbittorrent | transmission | deluge | sabnzbd
+	Qbittorrent    QbittorrentConfig  `yaml:"qbittorrent"`
+	Transmission   TransmissionConfig `yaml:"transmission"`
+	Deluge         DelugeConfig       `yaml:"deluge"`
+	Sabnzbd        SabnzbdConfig      `yaml:"sabnzbd"`
+	Cleaner        CleanerConfig      `yaml:"cleaner"`
+	Data           DataConfig         `yaml:"data"`
+	Queue          QueueConfig        `yaml:"queue"`
+	Health         HealthConfig       `yaml:"health"`
 }
 
 type ServerConfig struct {
@@ -18,30 +36,217 @@ type ServerConfig struct {
 	Port string `yaml:"port" env:"CLARR_SERVER_PORT" env-default:"8090"`
 }
 
+// APIConfig protège /api/* par clé d'API, comme les *arr : en-tête
+// X-Api-Key ou paramètre apikey. La clé principale, que l'environnement
+// peut fournir, a le scope admin ; Keys ajoute des clés nommées. Sans
+// aucune clé, l'API est en lecture seule, sauf si AuthDisabled l'ouvre
+// explicitement à tous. /api/stats sert le même comptage d'orphelins
+// pendant StatsCacheTTL.
+type APIConfig struct {
+	Key           string        `yaml:"key"             env:"CLARR_API_KEY"`
+	Keys          []APIKey      `yaml:"keys"`
+	AuthDisabled  bool          `yaml:"auth_disabled"   env:"CLARR_API_AUTH_DISABLED"   env-default:"false"`
+	StatsCacheTTL time.Duration `yaml:"stats_cache_ttl" env:"CLARR_API_STATS_CACHE_TTL" env-default:"1m"`
+}
+
+// APIKey est une clé nommée. Le scope read donne accès aux GET, admin
+// à toute l'API.
+type APIKey struct {
+	Name  string `yaml:"name"`
+	Key   string `yaml:"key"`
+	Scope string `yaml:"scope"` // read | admin
+}
+
+// All retourne les clés d'API, principale (nommée "default") en tête.
+func (c APIConfig) All() []APIKey {
+	var keys []APIKey
+	if c.Key != "" {
+		keys = append(keys, APIKey{Name: "default", Key: c.Key, Scope: "admin"})
+	}
+	return append(keys, c.Keys...)
+}
+
 type JellyfinConfig struct {
-	WebhookSecret string `yaml:"webhook_secret" env:"CLARR_JELLYFIN_WEBHOOK_SECRET" env-required:"true"`
+	WebhookSecret  string `yaml:"webhook_secret"  env:"CLARR_JELLYFIN_WEBHOOK_SECRET"  env-required:"true"`
+	UnmonitorSweep bool   `yaml:"unmonitor_sweep" env:"CLARR_JELLYFIN_UNMONITOR_SWEEP" env-default:"false"`
+}
+
+// Plex et Emby sont optionnels : leur webhook n'est exposé que si un
+// token est configuré, à passer dans l'URL du webhook.
+
+type PlexConfig struct {
+	WebhookToken string `yaml:"webhook_token" env:"CLARR_PLEX_WEBHOOK_TOKEN"`
+}
+
+type EmbyConfig struct {
+	WebhookToken string `yaml:"webhook_token" env:"CLARR_EMBY_WEBHOOK_TOKEN"`
+}
+
+// ArrWebhookConfig protège /webhook/radarr et /webhook/sonarr par basic
+// auth, à renseigner dans la connexion Webhook de Radarr/Sonarr. Sans mot
+// de passe, ces webhooks ne sont pas exposés.
+type ArrWebhookConfig struct {
+	Username string `yaml:"username" env:"CLARR_ARR_WEBHOOK_USERNAME" env-default:"clarr"`
+	Password string `yaml:"password" env:"CLARR_ARR_WEBHOOK_PASSWORD"`
 }
 
+// Chaque application *arr a une instance principale (url, api_key, que
+// les variables d'environnement peuvent fournir) et des instances
+// supplémentaires nommées, par exemple un Radarr 4K à côté du 1080p.
+
 type RadarrConfig struct {
-	URL    string `yaml:"url"     env:"CLARR_RADARR_URL"     env-required:"true"`
-	APIKey string `yaml:"api_key" env:"CLARR_RADARR_API_KEY" env-required:"true"`
+	Name      string        `yaml:"name"      env:"CLARR_RADARR_NAME"    env-default:"radarr"`
+	URL       string        `yaml:"url"       env:"CLARR_RADARR_URL"`
+	APIKey    string        `yaml:"api_key"   env:"CLARR_RADARR_API_KEY"`
+	Instances []ArrInstance `yaml:"instances"`
 }
 
 type SonarrConfig struct {
-	URL    string `yaml:"url"     env:"CLARR_SONARR_URL"     env-required:"true"`
-	APIKey string `yaml:"api_key" env:"CLARR_SONARR_API_KEY" env-required:"true"`
+	Name      string        `yaml:"name"      env:"CLARR_SONARR_NAME"    env-default:"sonarr"`
+	URL       string        `yaml:"url"       env:"CLARR_SONARR_URL"`
+	APIKey    string        `yaml:"api_key"   env:"CLARR_SONARR_API_KEY"`
+	Instances []ArrInstance `yaml:"instances"`
+}
+
+// Lidarr et Readarr sont optionnels : sans instance, les suppressions de
+// musique et de livres sont ignorées.
+
+type LidarrConfig struct {
+	Name      string        `yaml:"name"      env:"CLARR_LIDARR_NAME"    env-default:"lidarr"`
+	URL       string        `yaml:"url"       env:"CLARR_LIDARR_URL"`
+	APIKey    string        `yaml:"api_key"   env:"CLARR_LIDARR_API_KEY"`
+	Instances []ArrInstance `yaml:"instances"`
+}
+
+type ReadarrConfig struct {
+	Name      string        `yaml:"name"      env:"CLARR_READARR_NAME"   env-default:"readarr"`
+	URL       string        `yaml:"url"       env:"CLARR_READARR_URL"`
+	APIKey    string        `yaml:"api_key"   env:"CLARR_READARR_API_KEY"`
+	Instances []ArrInstance `yaml:"instances"`
+}
+
+// ArrInstance est une instance nommée d'une application *arr.
+type ArrInstance struct {
+	Name   string `yaml:"name"`
+	URL    string `yaml:"url"`
+	APIKey string `yaml:"api_key"`
+}
+
+// All retourne les instances Radarr, principale en tête.
+func (c RadarrConfig) All() []ArrInstance { return allInstances(c.Name, c.URL, c.APIKey, c.Instances) }
+
+// All retourne les instances Sonarr, principale en tête.
+func (c SonarrConfig) All() []ArrInstance { return allInstances(c.Name, c.URL, c.APIKey, c.Instances) }
+
+// All retourne les instances Lidarr, principale en tête.
+func (c LidarrConfig) All() []ArrInstance { return allInstances(c.Name, c.URL, c.APIKey, c.Instances) }
+
+// All retourne les instances Readarr, principale en tête.
+func (c ReadarrConfig) All() []ArrInstance { return allInstances(c.Name, c.URL, c.APIKey, c.Instances) }
+
+// allInstances place l'instance principale, si elle est configurée, devant
+// les instances supplémentaires.
+func allInstances(name, url, apiKey string, extra []ArrInstance) []ArrInstance {
+	var all []ArrInstance
+	if url != "" {
+		all = append(all, ArrInstance{Name: name, URL: url, APIKey: apiKey})
+	}
+	return append(all, extra...)
 }
 
+// Seule la section du client choisi par download_client est requise.
+
 type QbittorrentConfig struct {
-	URL      string `yaml:"url"      env:"CLARR_QBITTORRENT_URL"      env-required:"true"`
+	URL      string `yaml:"url"      env:"CLARR_QBITTORRENT_URL"`
 	Username string `yaml:"username" env:"CLARR_QBITTORRENT_USERNAME" env-default:"admin"`
-	Password string `yaml:"password" env:"CLARR_QBITTORRENT_PASSWORD" env-required:"true"`
+	Password string `yaml:"password" env:"CLARR_QBITTORRENT_PASSWORD"`
+}
+
+type TransmissionConfig struct {
+	URL      string `yaml:"url"      env:"CLARR_TRANSMISSION_URL"`
+	Username string `yaml:"username" env:"CLARR_TRANSMISSION_USERNAME"`
+	Password string `yaml:"password" env:"CLARR_TRANSMISSION_PASSWORD"`
+}
+
+type DelugeConfig struct {
+	URL      string `yaml:"url"      env:"CLARR_DELUGE_URL"`
+	Password string `yaml:"password" env:"CLARR_DELUGE_PASSWORD"`
+}
+
+type SabnzbdConfig struct {
+	URL    string `yaml:"url"     env:"CLARR_SABNZBD_URL"`
+	APIKey string `yaml:"api_key" env:"CLARR_SABNZBD_API_KEY"`
 }
 
 type CleanerConfig struct {
-	DownloadDir string `yaml:"download_dir" env:"CLARR_CLEANER_DOWNLOAD_DIR" env-required:"true"`
-	DryRun      bool   `yaml:"dry_run"      env:"CLARR_CLEANER_DRY_RUN"      env-default:"true"`
-	Schedule    string `yaml:"schedule"     env:"CLARR_CLEANER_SCHEDULE"     env-default:"0 3 * * *"`
+	DownloadDir string        `yaml:"download_dir" env:"CLARR_CLEANER_DOWNLOAD_DIR" env-required:"true"`
+	DryRun      bool          `yaml:"dry_run"      env:"CLARR_CLEANER_DRY_RUN"      env-default:"true"`
+	Schedule    string        `yaml:"schedule"     env:"CLARR_CLEANER_SCHEDULE"     env-default:"0 3 * * *"`
+	MediaRoots  []string      `yaml:"media_roots"  env:"CLARR_CLEANER_MEDIA_ROOTS"  env-separator:","`      // bibliothèques, pour la détection par inode
+	Detection   string        `yaml:"detection"    env:"CLARR_CLEANER_DETECTION"    env-default:"hardlink"` // hardlink | copy
+	Include     []string      `yaml:"include"      env:"CLARR_CLEANER_INCLUDE"      env-separator:","`      // globs doublestar relatifs au download_dir
+	Exclude     []string      `yaml:"exclude"      env:"CLARR_CLEANER_EXCLUDE"      env-separator:","`
+	Extensions  []string      `yaml:"extensions"   env:"CLARR_CLEANER_EXTENSIONS"   env-separator:","`
+	MinSize     int64         `yaml:"min_size"     env:"CLARR_CLEANER_MIN_SIZE"` // octets
+	Seeding     SeedingConfig `yaml:"seeding"`
+	Safety      SafetyConfig  `yaml:"safety"`
+	Trash       TrashConfig   `yaml:"trash"`
+}
+
+// TrashConfig déplace les orphelins dans une corbeille au lieu de les
+// supprimer. Dir doit être sur le même système de fichiers que
+// download_dir ; par défaut <download_dir>/.clarr-trash.
+type TrashConfig struct {
+	Enabled   bool          `yaml:"enabled"   env:"CLARR_CLEANER_TRASH_ENABLED"`
+	Dir       string        `yaml:"dir"       env:"CLARR_CLEANER_TRASH_DIR"`
+	Retention time.Duration `yaml:"retention" env:"CLARR_CLEANER_TRASH_RETENTION" env-default:"168h"` // 0 = purge manuelle uniquement
+}
+
+// SafetyConfig protège les orphelins apparents : fichiers récents, en
+// cours de téléchargement ou temporaires, et ceux pas encore vus dans
+// grace_scans scans planifiés consécutifs.
+type SafetyConfig struct {
+	MinAge         time.Duration `yaml:"min_age"         env:"CLARR_CLEANER_SAFETY_MIN_AGE"         env-default:"1h"`
+	SkipIncomplete bool          `yaml:"skip_incomplete" env:"CLARR_CLEANER_SAFETY_SKIP_INCOMPLETE" env-default:"true"`
+	TempSuffixes   []string      `yaml:"temp_suffixes"   env:"CLARR_CLEANER_SAFETY_TEMP_SUFFIXES"   env-default:".!qB,.part" env-separator:","`
+	GraceScans     int           `yaml:"grace_scans"     env:"CLARR_CLEANER_SAFETY_GRACE_SCANS"     env-default:"1"`
+}
+
+type SeedingConfig struct {
+	MinRatio       float64       `yaml:"min_ratio"        env:"CLARR_CLEANER_SEEDING_MIN_RATIO"`
+	MinSeedingTime time.Duration `yaml:"min_seeding_time" env:"CLARR_CLEANER_SEEDING_MIN_SEEDING_TIME"`
+	Rules          []SeedingRule `yaml:"rules"`
+}
+
+// SeedingRule cible une catégorie qBittorrent et/ou un tracker (hôte).
+type SeedingRule struct {
+	Category       string        `yaml:"category"`
+	Tracker        string        `yaml:"tracker"`
+	MinRatio       float64       `yaml:"min_ratio"`
+	MinSeedingTime time.Duration `yaml:"min_seeding_time"`
+	AlwaysKeep     bool          `yaml:"always_keep"`
+}
+
+type DataConfig struct {
+	Dir string `yaml:"dir" env:"CLARR_DATA_DIR" env-default:"data"`
+}
+
+type QueueConfig struct {
+	Workers       int           `yaml:"workers"         env:"CLARR_QUEUE_WORKERS"         env-default:"2"`
+	MaxAttempts   int           `yaml:"max_attempts"    env:"CLARR_QUEUE_MAX_ATTEMPTS"    env-default:"8"`
+	RetryDelay    time.Duration `yaml:"retry_delay"     env:"CLARR_QUEUE_RETRY_DELAY"     env-default:"30s"`
+	MaxRetryDelay time.Duration `yaml:"max_retry_delay" env:"CLARR_QUEUE_MAX_RETRY_DELAY" env-default:"30m"`
+	DeadRetention time.Duration `yaml:"dead_retention"  env:"CLARR_QUEUE_DEAD_RETENTION"  env-default:"720h"`
+}
+
+// HealthConfig règle /health/ready. Chaque dépendance est critique, sauf
+// celles listées dans Optional (download_dir, qbittorrent, radarr/<nom>...),
+// qui ne font que dégrader le statut.
+type HealthConfig struct {
+	CacheTTL     time.Duration `yaml:"cache_ttl"     env:"CLARR_HEALTH_CACHE_TTL"     env-default:"30s"`
+	Timeout      time.Duration `yaml:"timeout"       env:"CLARR_HEALTH_TIMEOUT"       env-default:"5s"`
+	RequireMount bool          `yaml:"require_mount" env:"CLARR_HEALTH_REQUIRE_MOUNT" env-default:"false"` // download_dir doit être un montage distinct de /
+	Optional     []string      `yaml:"optional"      env:"CLARR_HEALTH_OPTIONAL"      env-separator:","`
 }
 
 func Load(path string) (*Config, error) {
@@ -49,5 +254,156 @@ func Load(path string) (*Config, error) {
 	if err := cleanenv.ReadConfig(path, &cfg); err != nil {
 		return nil, err
 	}
+	if err := cfg.validateDownloadClient(); err != nil {
+		return nil, err
+	}
+	if err := cfg.API.validate(); err != nil {
+		return nil, err
+	}
+	if err := cfg.Cleaner.validateDetection(); err != nil {
+		return nil, err
+	}
+	if err := cfg.Cleaner.validatePatterns(); err != nil {
+		return nil, err
+	}
+	if cfg.Cleaner.Trash.Dir == "" {
+		cfg.Cleaner.Trash.Dir = filepath.Join(cfg.Cleaner.DownloadDir, ".clarr-trash")
+	}
+	for _, app := range []struct {
+		name      string
+		instances []ArrInstance
+		required  bool
+	}{
+		{"radarr", cfg.Radarr.All(), true},
+		{"sonarr", cfg.Sonarr.All(), true},
+		{"lidarr", cfg.Lidarr.All(), false},
+		{"readarr", cfg.Readarr.All(), false},
+	} {
+		if err := validateInstances(app.name, app.instances, app.required); err != nil {
+			return nil, err
+		}
+	}
 	return &cfg, nil
 }
+
+// validateInstances vérifie que chaque instance est complète et que leurs
+// noms, qui apparaissent dans les logs et /api/rescan, sont uniques.
+func validateInstances(app string, instances []ArrInstance, required bool) error {
+	if required && len(instances) == 0 {
+		return fmt.Errorf("config: %s.url is required", app)
+	}
+
+	seen := make(map[string]bool, len(instances))
+	for i, inst := range instances {
+		switch {
+		case inst.Name == "":
+			return fmt.Errorf("config: %s instance %d has no name", app, i)
+		case inst.URL == "":
+			return fmt.Errorf("config: %s instance %q has no url", app, inst.Name)
+		case inst.APIKey == "":
+			return fmt.Errorf("config: %s instance %q has no api_key", app, inst.Name)
+		case seen[inst.Name]:
+			return fmt.Errorf("config: %s instance name %q is used twice", app, inst.Name)
+		}
+		seen[inst.Name] = true
+	}
+	return nil
+}
+
+// validate vérifie que chaque clé d'API est complète. Noms et clés sont
+// uniques : le nom identifie la clé dans les logs.
+func (c APIConfig) validate() error {
+	names := make(map[string]bool)
+	secrets := make(map[string]bool)
+	for i, k := range c.All() {
+		switch {
+		case k.Name == "":
+			return fmt.Errorf("config: api key %d has no name", i)
+		case k.Key == "":
+			return fmt.Errorf("config: api key %q has no key", k.Name)
+		case k.Scope != "read" && k.Scope != "admin":
+			return fmt.Errorf("config: api key %q has unknown scope %q, want read or admin", k.Name, k.Scope)
+		case names[k.Name]:
+			return fmt.Errorf("config: api key name %q is used twice", k.Name)
+		case secrets[k.Key]:
+			return fmt.Errorf("config: api key %q reuses the key of another one", k.Name)
+		}
+		names[k.Name] = true
+		secrets[k.Key] = true
+	}
+	return nil
+}
+
+// validateDetection vérifie le mode de détection des orphelins. Le mode
+// copy compare le contenu aux bibliothèques, qui doivent donc être connues.
+func (c *CleanerConfig) validateDetection() error {
+	switch c.Detection {
+	case "hardlink":
+		return nil
+	case "copy":
+		if len(c.MediaRoots) == 0 {
+			return fmt.Errorf("config: cleaner.media_roots is required with detection %q", c.Detection)
+		}
+		return nil
+	default:
+		return fmt.Errorf("config: unknown cleaner.detection %q", c.Detection)
+	}
+}
+
+// validatePatterns vérifie la syntaxe des globs include et exclude, qui
+// sinon ne matcheraient jamais sans le dire.
+func (c *CleanerConfig) validatePatterns() error {
+	for _, list := range []struct {
+		name     string
+		patterns []string
+	}{
+		{"include", c.Include},
+		{"exclude", c.Exclude},
+	} {
+		for _, p := range list.patterns {
+			if !doublestar.ValidatePattern(p) {
+				return fmt.Errorf("config: invalid cleaner.%s pattern %q", list.name, p)
+			}
+		}
+	}
+	return nil
+}
+
+// validateDownloadClient vérifie que le client choisi est connu et configuré.
+func (cfg *Config) validateDownloadClient() error {
+	var missing string
+	switch cfg.DownloadClient {
+	case "qbittorrent":
+		switch {
+		case cfg.Qbittorrent.URL == "":
+			missing = "qbittorrent.url"
+		case cfg.Qbittorrent.Password == "":
+			missing = "qbittorrent.password"
+		}
+	case "transmission":
+		if cfg.Transmission.URL == "" {
+			missing = "transmission.url"
+		}
+	case "deluge":
+		switch {
+		case cfg.Deluge.URL == "":
+			missing = "deluge.url"
+		case cfg.Deluge.Password == "":
+			missing = "deluge.password"
+		}
+	case "sabnzbd":
+		switch {
+		case cfg.Sabnzbd.URL == "":
+			missing = "sabnzbd.url"
+		case cfg.Sabnzbd.APIKey == "":
+			missing = "sabnzbd.api_key"
+		}
+	default:
+		return fmt.Errorf("config: unknown download_client %q", cfg.DownloadClient)
+	}
+
+	if missing != "" {
+		return fmt.Errorf("config: %s is required for download_client %s", missing, cfg.DownloadClient)
+	}
+	return nil
+}
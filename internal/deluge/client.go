@@ -0,0 +1,309 @@
+package deluge
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/cookiejar"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cleeryy/clarr/internal/download"
+)
+
+// errNotAuthenticated est le code renvoyé par deluge-web quand la session
+// a expiré.
+const errNotAuthenticated = 1
+
+type Client struct {
+	baseURL    string
+	password   string
+	httpClient *http.Client
+
+	mu       sync.Mutex
+	loggedIn bool
+	nextID   int
+}
+
+// New crée le client de l'interface web de Deluge (ex. http://deluge:8112).
+// La connexion est établie au premier appel, et rétablie si la session
+// expire.
+func New(baseURL, password string) (*Client, error) {
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{
+		baseURL:  strings.TrimSuffix(baseURL, "/"),
+		password: password,
+		httpClient: &http.Client{
+			Jar:     jar,
+			Timeout: 30 * time.Second,
+		},
+	}, nil
+}
+
+// SetTransport remplace le transport HTTP du client, par exemple pour
+// mesurer les requêtes.
+func (c *Client) SetTransport(rt http.RoundTripper) {
+	c.httpClient.Transport = rt
+}
+
+// ─── Models ───────────────────────────────────────────────────────────
+
+type Torrent struct {
+	Hash        string  `json:"hash"`
+	Name        string  `json:"name"`
+	SavePath    string  `json:"save_path"`
+	Ratio       float64 `json:"ratio"`
+	SeedingTime int64   `json:"seeding_time"` // secondes
+	TotalDone   int64   `json:"total_done"`
+	TotalWanted int64   `json:"total_wanted"`
+	Tracker     string  `json:"tracker"`
+	Label       string  `json:"label"` // plugin Label
+	Files       []File  `json:"files"`
+}
+
+type File struct {
+	Index int    `json:"index"`
+	Path  string `json:"path"` // relatif au save_path du torrent
+	Size  int64  `json:"size"`
+}
+
+// torrentKeys sont les champs demandés à core.get_torrents_status.
+var torrentKeys = []string{"name", "save_path", "ratio", "seeding_time", "total_done", "total_wanted", "tracker", "label", "files"}
+
+type request struct {
+	Method string `json:"method"`
+	Params []any  `json:"params"`
+	ID     int    `json:"id"`
+}
+
+type response struct {
+	Result json.RawMessage `json:"result"`
+	Error  *RPCError       `json:"error"`
+}
+
+// ─── Errors ───────────────────────────────────────────────────────────
+
+// ErrAuthFailed est retourné quand Deluge refuse le mot de passe.
+var ErrAuthFailed = errors.New("deluge: login failed — check password")
+
+// ErrNoDaemon est retourné quand deluge-web n'a aucun démon auquel se connecter.
+var ErrNoDaemon = errors.New("deluge: web UI has no daemon to connect to")
+
+// APIError est retourné quand Deluge répond avec un statut >= 400.
+type APIError struct {
+	StatusCode int
+	Method     string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("deluge: unexpected status %d on %s", e.StatusCode, e.Method)
+}
+
+// Temporary indique si la requête peut être réessayée plus tard.
+func (e *APIError) Temporary() bool {
+	return e.StatusCode >= 500 || e.StatusCode == http.StatusTooManyRequests
+}
+
+// RPCError est l'erreur renvoyée par une méthode JSON-RPC.
+type RPCError struct {
+	Method  string `json:"-"`
+	Message string `json:"message"`
+	Code    int    `json:"code"`
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("deluge: %s failed: %s (code %d)", e.Method, e.Message, e.Code)
+}
+
+// ─── Auth ─────────────────────────────────────────────────────────────
+
+// login ouvre une session et connecte deluge-web à un démon si besoin.
+// À appeler sous c.mu.
+func (c *Client) login() error {
+	c.loggedIn = false
+
+	var ok bool
+	if err := c.send("auth.login", []any{c.password}, &ok); err != nil {
+		return err
+	}
+	if !ok {
+		return ErrAuthFailed
+	}
+
+	var connected bool
+	if err := c.send("web.connected", []any{}, &connected); err != nil {
+		return err
+	}
+	if !connected {
+		// Chaque hôte est [id, adresse, port, ...].
+		var hosts [][]any
+		if err := c.send("web.get_hosts", []any{}, &hosts); err != nil {
+			return err
+		}
+		if len(hosts) == 0 || len(hosts[0]) == 0 {
+			return ErrNoDaemon
+		}
+		if err := c.send("web.connect", []any{hosts[0][0]}, nil); err != nil {
+			return err
+		}
+	}
+
+	c.loggedIn = true
+	return nil
+}
+
+// ─── RPC Helper ───────────────────────────────────────────────────────
+
+// call appelle une méthode authentifiée. Si la session a expiré, il se
+// reconnecte et rejoue l'appel une fois.
+func (c *Client) call(method string, params []any, out any) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.loggedIn {
+		if err := c.login(); err != nil {
+			return err
+		}
+	}
+
+	err := c.send(method, params, out)
+	var rpcErr *RPCError
+	if errors.As(err, &rpcErr) && rpcErr.Code == errNotAuthenticated {
+		if err := c.login(); err != nil {
+			return err
+		}
+		err = c.send(method, params, out)
+	}
+	return err
+}
+
+// send envoie un appel JSON-RPC et décode son résultat dans out.
+func (c *Client) send(method string, params []any, out any) error {
+	c.nextID++
+	body, err := json.Marshal(request{Method: method, Params: params, ID: c.nextID})
+	if err != nil {
+		return fmt.Errorf("deluge: encode %s: %w", method, err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, c.baseURL+"/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("deluge: build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("deluge: request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return &APIError{StatusCode: resp.StatusCode, Method: method}
+	}
+
+	var r response
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return fmt.Errorf("deluge: decode %s: %w", method, err)
+	}
+	if r.Error != nil {
+		r.Error.Method = method
+		return r.Error
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(r.Result, out); err != nil {
+		return fmt.Errorf("deluge: decode %s result: %w", method, err)
+	}
+	return nil
+}
+
+// ─── Methods ──────────────────────────────────────────────────────────
+
+// Version retourne la version du démon Deluge, ce qui vérifie au passage
+// le mot de passe et la connexion de deluge-web au démon.
+func (c *Client) Version() (string, error) {
+	var version string
+	if err := c.call("daemon.info", []any{}, &version); err != nil {
+		return "", err
+	}
+	return version, nil
+}
+
+// GetTorrents retourne tous les torrents avec leurs fichiers.
+func (c *Client) GetTorrents() ([]Torrent, error) {
+	var status map[string]Torrent
+	if err := c.call("core.get_torrents_status", []any{map[string]any{}, torrentKeys}, &status); err != nil {
+		return nil, err
+	}
+
+	torrents := make([]Torrent, 0, len(status))
+	for hash, t := range status {
+		t.Hash = hash
+		torrents = append(torrents, t)
+	}
+	return torrents, nil
+}
+
+// MatchPaths retourne les torrents contenant au moins un des paths,
+// avec la liste complète de leurs fichiers.
+func (c *Client) MatchPaths(paths []string) ([]download.Item, error) {
+	wanted := download.PathSet(paths)
+
+	torrents, err := c.GetTorrents()
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []download.Item
+	for _, t := range torrents {
+		item := t.Item()
+		if download.AnyIn(item.Files, wanted) {
+			matches = append(matches, item)
+		}
+	}
+	return matches, nil
+}
+
+// Delete supprime un torrent par son hash.
+// deleteFiles = true supprime aussi les fichiers du disque.
+func (c *Client) Delete(hash string, deleteFiles bool) error {
+	var ok bool
+	if err := c.call("core.remove_torrent", []any{hash, deleteFiles}, &ok); err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("deluge: torrent %s not removed", hash)
+	}
+	return nil
+}
+
+// Pause met en pause un torrent par son hash.
+func (c *Client) Pause(hash string) error {
+	return c.call("core.pause_torrents", []any{[]string{hash}}, nil)
+}
+
+// Item convertit le torrent pour le cleaner.
+func (t Torrent) Item() download.Item {
+	item := download.Item{
+		ID:          t.Hash,
+		Name:        t.Name,
+		Category:    t.Label,
+		Tracker:     t.Tracker,
+		Ratio:       t.Ratio,
+		SeedingTime: time.Duration(t.SeedingTime) * time.Second,
+		Incomplete:  t.TotalDone < t.TotalWanted,
+	}
+	for _, f := range t.Files {
+		item.Files = append(item.Files, filepath.Join(t.SavePath, f.Path))
+	}
+	return item
+}
@@ -0,0 +1,140 @@
+package deluge
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// fakeDeluge simule deluge-web : une session par cookie, expirable, et un
+// démon à connecter après le login.
+type fakeDeluge struct {
+	t        *testing.T
+	password string
+	session  string
+	logins   int
+	torrents map[string]Torrent
+	removed  []any
+}
+
+func (f *fakeDeluge) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Method string `json:"method"`
+		Params []any  `json:"params"`
+		ID     int    `json:"id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		f.t.Errorf("decode rpc request: %v", err)
+	}
+
+	reply := func(result any) {
+		_ = json.NewEncoder(w).Encode(map[string]any{"result": result, "error": nil, "id": req.ID})
+	}
+
+	if req.Method == "auth.login" {
+		if req.Params[0] != f.password {
+			reply(false)
+			return
+		}
+		f.logins++
+		f.session = fmt.Sprintf("s%d", f.logins)
+		http.SetCookie(w, &http.Cookie{Name: "_session_id", Value: f.session, Path: "/"})
+		reply(true)
+		return
+	}
+
+	if cookie, err := r.Cookie("_session_id"); err != nil || cookie.Value != f.session {
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"result": nil,
+			"error":  map[string]any{"message": "Not authenticated", "code": 1},
+			"id":     req.ID,
+		})
+		return
+	}
+
+	switch req.Method {
+	case "web.connected":
+		reply(false)
+	case "web.get_hosts":
+		reply([][]any{{"daemon1", "127.0.0.1", 58846, "Online"}})
+	case "web.connect":
+		reply(nil)
+	case "core.get_torrents_status":
+		reply(f.torrents)
+	case "core.remove_torrent":
+		f.removed = append(f.removed, req.Params...)
+		reply(true)
+	default:
+		f.t.Errorf("unexpected method %s", req.Method)
+	}
+}
+
+func newFake(t *testing.T) (*fakeDeluge, *Client) {
+	t.Helper()
+	fake := &fakeDeluge{
+		t:        t,
+		password: "deluge",
+		torrents: map[string]Torrent{
+			"abc": {
+				Name: "Show.S01", SavePath: "/downloads", Ratio: 0.5, SeedingTime: 120,
+				Tracker: "https://tracker.example.org/announce", Label: "sonarr",
+				Files: []File{{Path: "Show.S01/E01.mkv"}, {Path: "Show.S01/E02.mkv"}},
+			},
+			"def": {Name: "Other.mkv", SavePath: "/downloads", Files: []File{{Path: "Other.mkv"}}},
+		},
+	}
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+
+	c, err := New(srv.URL, "deluge")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fake, c
+}
+
+func TestMatchPaths(t *testing.T) {
+	_, c := newFake(t)
+
+	items, err := c.MatchPaths([]string{"/downloads/Show.S01/E02.mkv"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(items) != 1 {
+		t.Fatalf("got %d items, want 1", len(items))
+	}
+	if items[0].ID != "abc" || items[0].Category != "sonarr" || len(items[0].Files) != 2 {
+		t.Errorf("unexpected item %+v", items[0])
+	}
+}
+
+func TestReloginOnExpiredSession(t *testing.T) {
+	fake, c := newFake(t)
+
+	if _, err := c.GetTorrents(); err != nil {
+		t.Fatalf("first call: %v", err)
+	}
+	fake.session = "expired"
+
+	if err := c.Delete("def", false); err != nil {
+		t.Fatalf("call after expiry: %v", err)
+	}
+	if fake.logins != 2 {
+		t.Errorf("logins = %d, want 2", fake.logins)
+	}
+	if len(fake.removed) != 2 || fake.removed[0] != "def" || fake.removed[1] != false {
+		t.Errorf("remove params = %v, want [def false]", fake.removed)
+	}
+}
+
+func TestWrongPassword(t *testing.T) {
+	fake, c := newFake(t)
+	fake.password = "other"
+
+	if _, err := c.GetTorrents(); !errors.Is(err, ErrAuthFailed) {
+		t.Errorf("got %v, want ErrAuthFailed", err)
+	}
+}
@@ -0,0 +1,72 @@
+// Package download regroupe ce que les clients de téléchargement
+// (qBittorrent, Transmission, Deluge, SABnzbd) exposent au cleaner.
+package download
+
+import (
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Item est un torrent ou un NZB, avec la liste complète de ses fichiers.
+type Item struct {
+	ID          string        `json:"id"` // hash du torrent ou nzo_id
+	Name        string        `json:"name"`
+	Category    string        `json:"category"`
+	Tracker     string        `json:"tracker"` // URL du tracker, vide pour l'usenet
+	Ratio       float64       `json:"ratio"`
+	SeedingTime time.Duration `json:"seeding_time"`
+	Incomplete  bool          `json:"incomplete"` // téléchargement ou post-traitement en cours
+	Files       []string      `json:"files"`      // chemins absolus nettoyés
+}
+
+// PathSet retourne les paths nettoyés sous forme d'ensemble.
+func PathSet(paths []string) map[string]bool {
+	set := make(map[string]bool, len(paths))
+	for _, p := range paths {
+		set[filepath.Clean(p)] = true
+	}
+	return set
+}
+
+// ContainsAny indique si au moins un des paths est root ou se trouve sous
+// root, en comparant segment par segment ("/dl/Movie" ne contient pas
+// "/dl/Movie 2/file.mkv").
+func ContainsAny(root string, paths []string) bool {
+	if root == "" {
+		return false
+	}
+	root = filepath.Clean(root)
+	prefix := strings.TrimSuffix(root, string(filepath.Separator)) + string(filepath.Separator)
+
+	for _, p := range paths {
+		p = filepath.Clean(p)
+		if p == root || strings.HasPrefix(p, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// AnyIn indique si au moins un des fichiers est dans l'ensemble set.
+func AnyIn(files []string, set map[string]bool) bool {
+	for _, f := range files {
+		if set[f] {
+			return true
+		}
+	}
+	return false
+}
+
+// AllIn indique si tous les fichiers sont dans l'ensemble set.
+func AllIn(files []string, set map[string]bool) bool {
+	if len(files) == 0 {
+		return false
+	}
+	for _, f := range files {
+		if !set[f] {
+			return false
+		}
+	}
+	return true
+}
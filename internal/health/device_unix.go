@@ -0,0 +1,21 @@
+//go:build !windows
+
+package health
+
+import (
+	"os"
+	"syscall"
+)
+
+// device retourne le périphérique qui porte path.
+func device(path string) (uint64, bool) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, false
+	}
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, false
+	}
+	return uint64(stat.Dev), true
+}
@@ -0,0 +1,9 @@
+//go:build windows
+
+package health
+
+// device n'est pas disponible sous Windows : les vérifications de système
+// de fichiers sont ignorées.
+func device(string) (uint64, bool) {
+	return 0, false
+}
@@ -0,0 +1,203 @@
+// Package health vérifie les dépendances de clarr pour /health/ready :
+// *arr, client de téléchargement et dossier de téléchargement.
+package health
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Statuts d'une vérification et du rapport.
+const (
+	StatusOK       = "ok"
+	StatusDegraded = "degraded" // une dépendance optionnelle est en échec
+	StatusFailed   = "failed"
+)
+
+// ─── Models ───────────────────────────────────────────────────────────
+
+// Check est une dépendance à vérifier. Probe retourne un détail affiché
+// quand elle répond (une version, un chemin).
+type Check struct {
+	Name     string
+	Critical bool
+	Probe    func() (string, error)
+}
+
+// Result est l'issue d'une vérification.
+type Result struct {
+	Name      string  `json:"name"`
+	Status    string  `json:"status"`
+	Critical  bool    `json:"critical"`
+	LatencyMS float64 `json:"latency_ms"`
+	Detail    string  `json:"detail,omitempty"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Report est l'état de toutes les dépendances.
+type Report struct {
+	Status    string    `json:"status"`
+	CheckedAt time.Time `json:"checked_at"`
+	Checks    []Result  `json:"checks"`
+}
+
+// ─── Checker ──────────────────────────────────────────────────────────
+
+// Checker exécute les vérifications et garde le rapport ttl, pour qu'un
+// healthcheck fréquent ne sollicite pas les *arr à chaque appel.
+type Checker struct {
+	checks  []Check
+	ttl     time.Duration
+	timeout time.Duration
+
+	mu     sync.Mutex
+	report *Report
+}
+
+// New crée un Checker. Chaque vérification qui dépasse timeout échoue.
+func New(ttl, timeout time.Duration, checks ...Check) *Checker {
+	return &Checker{checks: checks, ttl: ttl, timeout: timeout}
+}
+
+// Report retourne le rapport en cache, ou relance les vérifications s'il
+// a expiré. Les appels concurrents attendent le même rapport.
+func (c *Checker) Report() Report {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.report != nil && time.Since(c.report.CheckedAt) < c.ttl {
+		return *c.report
+	}
+
+	report := Report{
+		Status:    StatusOK,
+		CheckedAt: time.Now(),
+		Checks:    make([]Result, len(c.checks)),
+	}
+
+	var wg sync.WaitGroup
+	for i, check := range c.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			report.Checks[i] = c.run(check)
+		}()
+	}
+	wg.Wait()
+
+	for _, r := range report.Checks {
+		switch {
+		case r.Status == StatusOK:
+		case r.Critical:
+			report.Status = StatusFailed
+		case report.Status == StatusOK:
+			report.Status = StatusDegraded
+		}
+	}
+
+	c.report = &report
+	return report
+}
+
+// run exécute une vérification. Une probe trop lente est abandonnée : elle
+// finit en arrière-plan, bornée par le timeout de son client HTTP.
+func (c *Checker) run(check Check) Result {
+	type outcome struct {
+		detail string
+		err    error
+	}
+	done := make(chan outcome, 1)
+	start := time.Now()
+	go func() {
+		detail, err := check.Probe()
+		done <- outcome{detail, err}
+	}()
+
+	var o outcome
+	select {
+	case o = <-done:
+	case <-time.After(c.timeout):
+		o.err = fmt.Errorf("no answer after %s", c.timeout)
+	}
+
+	r := Result{
+		Name:      check.Name,
+		Status:    StatusOK,
+		Critical:  check.Critical,
+		LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+		Detail:    o.detail,
+	}
+	if o.err != nil {
+		r.Status = StatusFailed
+		r.Error = o.err.Error()
+	}
+	return r
+}
+
+// Register expose /health/live, qui répond tant que le process tourne, et
+// /health/ready, en 503 quand une dépendance critique est en échec.
+func (c *Checker) Register(r *gin.Engine) {
+	r.GET("/health/live", func(ctx *gin.Context) {
+		ctx.JSON(http.StatusOK, gin.H{"status": StatusOK})
+	})
+
+	r.GET("/health/ready", func(ctx *gin.Context) {
+		report := c.Report()
+		status := http.StatusOK
+		if report.Status == StatusFailed {
+			status = http.StatusServiceUnavailable
+		}
+		ctx.JSON(status, report)
+	})
+}
+
+// ─── Download Dir ─────────────────────────────────────────────────────
+
+// DownloadDir vérifie que dir existe et est lisible. Il doit être sur le
+// même système de fichiers que sameFS (les bibliothèques, pour que les
+// hardlinks soient possibles) et, avec requireMount, sur un autre que / :
+// un volume Docker non monté laisse un dossier vide dans le conteneur.
+func DownloadDir(dir string, sameFS []string, requireMount bool) func() (string, error) {
+	return func() (string, error) {
+		info, err := os.Stat(dir)
+		if err != nil {
+			return "", err
+		}
+		if !info.IsDir() {
+			return "", fmt.Errorf("%s is not a directory", dir)
+		}
+
+		f, err := os.Open(dir)
+		if err != nil {
+			return "", err
+		}
+		defer f.Close()
+		if _, err := f.Readdirnames(1); err != nil && !errors.Is(err, io.EOF) {
+			return "", fmt.Errorf("read %s: %w", dir, err)
+		}
+
+		dev, ok := device(dir)
+		if !ok {
+			return dir, nil
+		}
+		for _, root := range sameFS {
+			if rootDev, ok := device(root); ok && rootDev != dev {
+				return "", fmt.Errorf("%s is not on the same filesystem as %s, hardlinks are impossible", dir, root)
+			}
+		}
+		if requireMount {
+			if rootDev, ok := device(string(filepath.Separator)); ok && rootDev == dev {
+				return "", fmt.Errorf("%s is on the root filesystem, is the volume mounted?", dir)
+			}
+		}
+		return dir, nil
+	}
+}
@@ -0,0 +1,155 @@
+package health
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// probe retourne une probe qui compte ses appels.
+func probe(calls *atomic.Int32, detail string, err error) func() (string, error) {
+	return func() (string, error) {
+		calls.Add(1)
+		return detail, err
+	}
+}
+
+func TestChecker_Status(t *testing.T) {
+	var calls atomic.Int32
+	down := errors.New("connection refused")
+
+	tests := []struct {
+		name   string
+		checks []Check
+		want   string
+	}{
+		{"all ok", []Check{
+			{Name: "radarr/default", Critical: true, Probe: probe(&calls, "5.2.6", nil)},
+			{Name: "qbittorrent", Critical: true, Probe: probe(&calls, "v4.6.0", nil)},
+		}, StatusOK},
+		{"optional down", []Check{
+			{Name: "radarr/default", Critical: true, Probe: probe(&calls, "5.2.6", nil)},
+			{Name: "lidarr/default", Probe: probe(&calls, "", down)},
+		}, StatusDegraded},
+		{"critical down", []Check{
+			{Name: "radarr/default", Critical: true, Probe: probe(&calls, "", down)},
+			{Name: "lidarr/default", Probe: probe(&calls, "", down)},
+		}, StatusFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := New(time.Minute, time.Second, tt.checks...).Report()
+			if report.Status != tt.want {
+				t.Fatalf("status = %s, want %s", report.Status, tt.want)
+			}
+			if len(report.Checks) != len(tt.checks) {
+				t.Fatalf("expected %d results, got %d", len(tt.checks), len(report.Checks))
+			}
+			for i, r := range report.Checks {
+				if r.Name != tt.checks[i].Name {
+					t.Errorf("result %d = %s, want %s (order not kept)", i, r.Name, tt.checks[i].Name)
+				}
+				if r.Status == StatusFailed && r.Error != down.Error() {
+					t.Errorf("%s: error = %q", r.Name, r.Error)
+				}
+			}
+		})
+	}
+}
+
+func TestChecker_Cache(t *testing.T) {
+	var calls atomic.Int32
+	c := New(50*time.Millisecond, time.Second, Check{Name: "sonarr/default", Critical: true, Probe: probe(&calls, "4.0.0", nil)})
+
+	first := c.Report()
+	c.Report()
+	if got := calls.Load(); got != 1 {
+		t.Fatalf("probe called %d times within the ttl, want 1", got)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if c.Report().CheckedAt.Equal(first.CheckedAt) || calls.Load() != 2 {
+		t.Errorf("report not refreshed after the ttl (%d calls)", calls.Load())
+	}
+}
+
+func TestChecker_Timeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	c := New(time.Minute, 20*time.Millisecond, Check{Name: "radarr/4k", Critical: true, Probe: func() (string, error) {
+		<-release
+		return "5.2.6", nil
+	}})
+
+	start := time.Now()
+	report := c.Report()
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("report took %s, the timeout was not applied", elapsed)
+	}
+	if report.Status != StatusFailed || report.Checks[0].Error == "" {
+		t.Errorf("slow probe not failed: %+v", report.Checks[0])
+	}
+}
+
+func TestRegister(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var calls atomic.Int32
+
+	get := func(c *Checker, target string) *httptest.ResponseRecorder {
+		r := gin.New()
+		c.Register(r)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		return w
+	}
+
+	failed := New(time.Minute, time.Second, Check{Name: "qbittorrent", Critical: true, Probe: probe(&calls, "", errors.New("forbidden"))})
+	if w := get(failed, "/health/live"); w.Code != http.StatusOK {
+		t.Errorf("GET /health/live = %d, want 200 whatever the dependencies", w.Code)
+	}
+
+	w := get(failed, "/health/ready")
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("GET /health/ready = %d, want 503", w.Code)
+	}
+	var report Report
+	if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+	if report.Status != StatusFailed || len(report.Checks) != 1 || report.Checks[0].Error != "forbidden" {
+		t.Errorf("unexpected report %s", w.Body)
+	}
+
+	degraded := New(time.Minute, time.Second, Check{Name: "readarr/default", Probe: probe(&calls, "", errors.New("timeout"))})
+	if w := get(degraded, "/health/ready"); w.Code != http.StatusOK {
+		t.Errorf("GET /health/ready = %d with an optional dependency down, want 200", w.Code)
+	}
+}
+
+func TestDownloadDir(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "Dune.2021.mkv")
+	if err := os.WriteFile(file, []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if detail, err := DownloadDir(dir, []string{dir}, false)(); err != nil || detail != dir {
+		t.Errorf("DownloadDir(%s) = %q, %v", dir, detail, err)
+	}
+	if _, err := DownloadDir(filepath.Join(dir, "missing"), nil, false)(); err == nil {
+		t.Error("expected an error for a missing dir")
+	}
+	if _, err := DownloadDir(file, nil, false)(); err == nil {
+		t.Error("expected an error for a file")
+	}
+}
@@ -0,0 +1,260 @@
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// Tracker garde en mémoire le cycle de vie des runs déclenchés
+// (cleanup, rescan) pour qu'on puisse les suivre via l'API.
+type Tracker struct {
+	mu     sync.RWMutex
+	jobs   map[string]*Job
+	order  []string // du plus ancien au plus récent
+	limit  int
+	logger *zap.Logger
+}
+
+// New crée un tracker qui conserve au plus limit jobs terminés.
+func New(limit int, logger *zap.Logger) *Tracker {
+	return &Tracker{
+		jobs:   make(map[string]*Job),
+		limit:  limit,
+		logger: logger,
+	}
+}
+
+// ─── Models ───────────────────────────────────────────────────────────
+
+type Status string
+
+const (
+	StatusQueued    Status = "queued"
+	StatusRunning   Status = "running"
+	StatusSucceeded Status = "succeeded"
+	StatusFailed    Status = "failed"
+)
+
+type Job struct {
+	ID         string     `json:"id"`
+	Kind       string     `json:"kind"`    // "cleanup" | "rescan"
+	Trigger    string     `json:"trigger"` // "api" | "cron"
+	Status     Status     `json:"status"`
+	CreatedAt  time.Time  `json:"created_at"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	DurationMs int64      `json:"duration_ms,omitempty"`
+	Error      string     `json:"error,omitempty"`
+	Result     any        `json:"result,omitempty"`
+
+	done chan struct{}
+}
+
+// Func est le travail exécuté par un job ; son résultat est exposé tel quel.
+type Func func() (any, error)
+
+// ─── Methods ──────────────────────────────────────────────────────────
+
+// Start crée un job et l'exécute en arrière-plan. Retourne une copie du
+// job à l'état queued, dont l'ID permet de suivre son avancement.
+func (t *Tracker) Start(kind, trigger string, fn Func) Job {
+	t.mu.Lock()
+	job := t.add(kind, trigger)
+	snapshot := *job
+	t.mu.Unlock()
+
+	go t.run(job, fn)
+
+	return snapshot
+}
+
+// TryStart démarre le job seulement si aucun job du même kind n'est en
+// attente ou en cours. Sinon, retourne ce job actif et false.
+func (t *Tracker) TryStart(kind, trigger string, fn Func) (Job, bool) {
+	t.mu.Lock()
+	for _, id := range t.order {
+		if active := t.jobs[id]; active.Kind == kind && !active.finished() {
+			t.mu.Unlock()
+			return *active, false
+		}
+	}
+	job := t.add(kind, trigger)
+	snapshot := *job
+	t.mu.Unlock()
+
+	go t.run(job, fn)
+
+	return snapshot, true
+}
+
+// Wait bloque jusqu'à la fin du job.
+func (t *Tracker) Wait(id string) {
+	t.mu.RLock()
+	job, ok := t.jobs[id]
+	t.mu.RUnlock()
+
+	if ok {
+		<-job.done
+	}
+}
+
+// Drain attend la fin de tous les jobs en attente ou en cours, y compris
+// ceux démarrés pendant l'attente, ou l'expiration de ctx. À appeler à
+// l'arrêt, une fois l'API, le cron et la file de jobs arrêtés, avant de
+// fermer les bases que les jobs utilisent.
+func (t *Tracker) Drain(ctx context.Context) error {
+	for {
+		t.mu.RLock()
+		var active []chan struct{}
+		for _, id := range t.order {
+			if job := t.jobs[id]; !job.finished() {
+				active = append(active, job.done)
+			}
+		}
+		t.mu.RUnlock()
+
+		if len(active) == 0 {
+			return nil
+		}
+		for _, done := range active {
+			select {
+			case <-done:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+}
+
+// Get retourne une copie du job, ou false s'il est inconnu.
+func (t *Tracker) Get(id string) (Job, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	job, ok := t.jobs[id]
+	if !ok {
+		return Job{}, false
+	}
+	return *job, true
+}
+
+// List retourne les jobs du plus récent au plus ancien.
+func (t *Tracker) List() []Job {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	list := make([]Job, 0, len(t.order))
+	for i := len(t.order) - 1; i >= 0; i-- {
+		list = append(list, *t.jobs[t.order[i]])
+	}
+	return list
+}
+
+// Register enregistre les routes de consultation des jobs.
+func (t *Tracker) Register(r *gin.Engine) {
+	r.GET("/api/jobs", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"jobs": t.List()})
+	})
+
+	r.GET("/api/jobs/:id", func(c *gin.Context) {
+		job, ok := t.Get(c.Param("id"))
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{"error": "job not found"})
+			return
+		}
+		c.JSON(http.StatusOK, job)
+	})
+}
+
+// ─── Private ──────────────────────────────────────────────────────────
+
+// add enregistre un nouveau job queued. À appeler sous t.mu.
+func (t *Tracker) add(kind, trigger string) *Job {
+	job := &Job{
+		ID:        newID(),
+		Kind:      kind,
+		Trigger:   trigger,
+		Status:    StatusQueued,
+		CreatedAt: time.Now().UTC(),
+		done:      make(chan struct{}),
+	}
+
+	t.jobs[job.ID] = job
+	t.order = append(t.order, job.ID)
+	t.prune()
+	return job
+}
+
+func (j *Job) finished() bool {
+	return j.Status == StatusSucceeded || j.Status == StatusFailed
+}
+
+func (t *Tracker) run(job *Job, fn Func) {
+	t.mu.Lock()
+	started := time.Now().UTC()
+	job.Status = StatusRunning
+	job.StartedAt = &started
+	t.mu.Unlock()
+
+	result, err := fn()
+
+	t.mu.Lock()
+	finished := time.Now().UTC()
+	job.FinishedAt = &finished
+	job.DurationMs = finished.Sub(started).Milliseconds()
+	job.Result = result
+	job.Status = StatusSucceeded
+	if err != nil {
+		job.Status = StatusFailed
+		job.Error = err.Error()
+	}
+	t.mu.Unlock()
+	close(job.done)
+
+	if err != nil {
+		t.logger.Error("job failed",
+			zap.String("job_id", job.ID),
+			zap.String("kind", job.Kind),
+			zap.Error(err),
+		)
+		return
+	}
+	t.logger.Info("job succeeded",
+		zap.String("job_id", job.ID),
+		zap.String("kind", job.Kind),
+		zap.Duration("duration", finished.Sub(started)),
+	)
+}
+
+// prune oublie les plus anciens jobs terminés au-delà de la limite.
+// Les jobs en cours ne sont jamais oubliés. À appeler sous t.mu.
+func (t *Tracker) prune() {
+	excess := len(t.order) - t.limit
+	if excess <= 0 {
+		return
+	}
+
+	kept := t.order[:0]
+	for _, id := range t.order {
+		if excess > 0 && t.jobs[id].finished() {
+			delete(t.jobs, id)
+			excess--
+			continue
+		}
+		kept = append(kept, id)
+	}
+	t.order = kept
+}
+
+func newID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,152 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+func waitDone(t *testing.T, tr *Tracker, id string) Job {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		job, ok := tr.Get(id)
+		if !ok {
+			t.Fatalf("job %s not found", id)
+		}
+		if job.Status == StatusSucceeded || job.Status == StatusFailed {
+			return job
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("job %s not finished", id)
+	return Job{}
+}
+
+func TestTracker_Lifecycle(t *testing.T) {
+	tr := New(10, zap.NewNop())
+
+	release := make(chan struct{})
+	job := tr.Start("cleanup", "api", func() (any, error) {
+		<-release
+		return map[string]int{"orphans": 3}, nil
+	})
+	if job.Status != StatusQueued {
+		t.Errorf("expected queued, got %s", job.Status)
+	}
+
+	close(release)
+	done := waitDone(t, tr, job.ID)
+	if done.Status != StatusSucceeded || done.StartedAt == nil || done.FinishedAt == nil {
+		t.Errorf("unexpected job state: %+v", done)
+	}
+
+	failed := waitDone(t, tr, tr.Start("rescan", "api", func() (any, error) {
+		return nil, errors.New("radarr down")
+	}).ID)
+	if failed.Status != StatusFailed || failed.Error != "radarr down" {
+		t.Errorf("unexpected failed job: %+v", failed)
+	}
+
+	if list := tr.List(); len(list) != 2 || list[0].ID != failed.ID {
+		t.Errorf("expected newest job first, got %+v", list)
+	}
+}
+
+func TestTracker_PruneKeepsRunningJobs(t *testing.T) {
+	tr := New(1, zap.NewNop())
+
+	release := make(chan struct{})
+	defer close(release)
+	running := tr.Start("cleanup", "cron", func() (any, error) {
+		<-release
+		return nil, nil
+	})
+
+	for i := 0; i < 3; i++ {
+		waitDone(t, tr, tr.Start("rescan", "api", func() (any, error) { return nil, nil }).ID)
+	}
+
+	if _, ok := tr.Get(running.ID); !ok {
+		t.Error("running job must not be pruned")
+	}
+	if n := len(tr.List()); n != 2 {
+		t.Errorf("expected running job + latest finished job, got %d jobs", n)
+	}
+}
+
+func TestTracker_UnknownJob(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	New(10, zap.NewNop()).Register(r)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/jobs/nope", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", w.Code)
+	}
+}
+
+func TestTracker_TryStartSingleFlight(t *testing.T) {
+	tr := New(10, zap.NewNop())
+
+	release := make(chan struct{})
+	first, started := tr.TryStart("cleanup", "cron", func() (any, error) {
+		<-release
+		return nil, nil
+	})
+	if !started {
+		t.Fatal("first cleanup should start")
+	}
+
+	second, started := tr.TryStart("cleanup", "api", func() (any, error) { return nil, nil })
+	if started || second.ID != first.ID {
+		t.Fatalf("second cleanup should return running job %s, got %s (started=%v)", first.ID, second.ID, started)
+	}
+
+	// Un autre kind n'est pas bloqué.
+	if _, started := tr.TryStart("rescan", "api", func() (any, error) { return nil, nil }); !started {
+		t.Error("rescan should not be blocked by a running cleanup")
+	}
+
+	close(release)
+	tr.Wait(first.ID)
+
+	if _, started := tr.TryStart("cleanup", "api", func() (any, error) { return nil, nil }); !started {
+		t.Error("cleanup should start once the previous one finished")
+	}
+}
+
+func TestTracker_Drain(t *testing.T) {
+	tr := New(10, zap.NewNop())
+
+	release := make(chan struct{})
+	var finished atomic.Bool
+	tr.Start("cleanup", "cron", func() (any, error) {
+		<-release
+		finished.Store(true)
+		return nil, nil
+	})
+
+	// Un job bloqué fait expirer le drain.
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := tr.Drain(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Drain with a running job = %v, want deadline exceeded", err)
+	}
+
+	close(release)
+	if err := tr.Drain(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !finished.Load() {
+		t.Error("Drain returned before the running job finished")
+	}
+}
@@ -0,0 +1,143 @@
+package lidarr
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/cleeryy/clarr/internal/arr"
+)
+
+// Client parle à l'API v1 de Lidarr ; les requêtes, commandes et dossiers
+// racine viennent du client commun.
+type Client struct {
+	*arr.Client
+}
+
+func New(baseURL, apiKey string) *Client {
+	return &Client{Client: arr.NewClient("lidarr", baseURL, apiKey)}
+}
+
+// ─── Models ─────────────────────────────────────────────────────────
+
+type Artist struct {
+	ID              int    `json:"id"`
+	ArtistName      string `json:"artistName"`
+	Monitored       bool   `json:"monitored"`
+	Path            string `json:"path"`
+	ForeignArtistID string `json:"foreignArtistId"` // MusicBrainz artist ID
+}
+
+type Album struct {
+	ID             int       `json:"id"`
+	Title          string    `json:"title"`
+	ArtistID       int       `json:"artistId"`
+	Monitored      bool      `json:"monitored"`
+	ForeignAlbumID string    `json:"foreignAlbumId"` // MusicBrainz release group ID
+	ReleaseDate    time.Time `json:"releaseDate"`
+	Artist         struct {
+		ArtistName string `json:"artistName"`
+		Path       string `json:"path"`
+	} `json:"artist"`
+	Statistics struct {
+		TrackFileCount int `json:"trackFileCount"`
+	} `json:"statistics"`
+}
+
+type AlbumsMonitor struct {
+	AlbumIDs  []int `json:"albumIds"`
+	Monitored bool  `json:"monitored"`
+}
+
+type Command struct {
+	Name     string `json:"name"`
+	ArtistID int    `json:"artistId,omitempty"`
+}
+
+// Types communs à Lidarr et Readarr.
+type (
+	APIError      = arr.APIError
+	RootFolder    = arr.RootFolder
+	CommandStatus = arr.CommandStatus
+	SystemStatus  = arr.SystemStatus
+)
+
+// ─── Artist Methods ─────────────────────────────────────────────────
+
+// GetAllArtists retourne tous les artistes de la bibliothèque Lidarr.
+func (c *Client) GetAllArtists() ([]Artist, error) {
+	var artists []Artist
+	if err := c.Get("/api/v1/artist", "artists", &artists); err != nil {
+		return nil, err
+	}
+	return artists, nil
+}
+
+// RescanArtist force un rescan des fichiers d'un artiste.
+// Retourne l'ID de la commande, à passer à WaitForCommand.
+func (c *Client) RescanArtist(artistID int) (int, error) {
+	return c.StartCommand(Command{
+		Name:     "RefreshArtist",
+		ArtistID: artistID,
+	})
+}
+
+// RescanAll force un rescan complet des dossiers racine.
+// Retourne l'ID de la commande, à passer à WaitForCommand.
+func (c *Client) RescanAll() (int, error) {
+	return c.StartCommand(Command{
+		Name: "RescanFolders",
+	})
+}
+
+// ─── Album Methods ──────────────────────────────────────────────────
+
+// GetAllAlbums retourne tous les albums, artiste inclus.
+func (c *Client) GetAllAlbums() ([]Album, error) {
+	var albums []Album
+	if err := c.Get("/api/v1/album?includeAllArtistAlbums=true", "albums", &albums); err != nil {
+		return nil, err
+	}
+	return albums, nil
+}
+
+// GetAlbum retourne un album par son ID.
+func (c *Client) GetAlbum(albumID int) (*Album, error) {
+	var album Album
+	if err := c.Get(fmt.Sprintf("/api/v1/album/%d", albumID), "album", &album); err != nil {
+		return nil, err
+	}
+	return &album, nil
+}
+
+// GetMissingAlbums retourne les albums sans aucun fichier sur le disque.
+func (c *Client) GetMissingAlbums() ([]Album, error) {
+	albums, err := c.GetAllAlbums()
+	if err != nil {
+		return nil, err
+	}
+
+	var missing []Album
+	for _, a := range albums {
+		if a.Statistics.TrackFileCount == 0 {
+			missing = append(missing, a)
+		}
+	}
+	return missing, nil
+}
+
+// UnmonitorAlbums désactive le monitoring d'une liste d'albums
+// en une seule requête. L'artiste reste monitoré.
+func (c *Client) UnmonitorAlbums(albumIDs []int) error {
+	if len(albumIDs) == 0 {
+		return nil
+	}
+	return c.Put("/api/v1/album/monitor", AlbumsMonitor{
+		AlbumIDs:  albumIDs,
+		Monitored: false,
+	})
+}
+
+// DeleteAlbum supprime un album de Lidarr (et optionnellement ses fichiers).
+func (c *Client) DeleteAlbum(albumID int, deleteFiles bool) error {
+	return c.Delete(fmt.Sprintf("/api/v1/album/%d?deleteFiles=%v&addImportListExclusion=false", albumID, deleteFiles))
+}
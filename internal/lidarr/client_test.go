@@ -0,0 +1,73 @@
+package lidarr
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// albumServer sert une liste d'albums et capture le corps du PUT
+// /api/v1/album/monitor.
+func albumServer(t *testing.T, albums string, monitor *AlbumsMonitor) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Api-Key") != "key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v1/album":
+			fmt.Fprint(w, albums)
+		case r.Method == http.MethodPut && r.URL.Path == "/api/v1/album/monitor":
+			if err := json.NewDecoder(r.Body).Decode(monitor); err != nil {
+				t.Errorf("decode monitor body: %v", err)
+			}
+			w.WriteHeader(http.StatusAccepted)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestGetMissingAlbums(t *testing.T) {
+	albums := `[
+		{"id": 1, "title": "OK Computer", "artistId": 3, "monitored": true, "statistics": {"trackFileCount": 12}},
+		{"id": 2, "title": "Kid A", "artistId": 3, "monitored": true, "statistics": {"trackFileCount": 0}},
+		{"id": 3, "title": "Amnesiac", "artistId": 3, "monitored": false}
+	]`
+	c := New(albumServer(t, albums, nil).URL, "key")
+
+	missing, err := c.GetMissingAlbums()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(missing) != 2 || missing[0].ID != 2 || missing[1].ID != 3 {
+		t.Errorf("got %+v, want albums 2 and 3", missing)
+	}
+}
+
+func TestUnmonitorAlbums(t *testing.T) {
+	var monitor AlbumsMonitor
+	c := New(albumServer(t, "[]", &monitor).URL, "key")
+
+	if err := c.UnmonitorAlbums([]int{2, 3}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if monitor.Monitored || len(monitor.AlbumIDs) != 2 || monitor.AlbumIDs[0] != 2 || monitor.AlbumIDs[1] != 3 {
+		t.Errorf("got %+v, want albums [2 3] unmonitored", monitor)
+	}
+}
+
+func TestAPIError(t *testing.T) {
+	c := New(albumServer(t, "[]", nil).URL, "wrong")
+
+	_, err := c.GetAllAlbums()
+	apiErr, ok := err.(*APIError)
+	if !ok || apiErr.StatusCode != http.StatusUnauthorized || apiErr.Temporary() {
+		t.Errorf("got %v, want permanent 401 APIError", err)
+	}
+}
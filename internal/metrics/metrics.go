@@ -0,0 +1,206 @@
+// Package metrics expose les métriques Prometheus de clarr sur /metrics :
+// requêtes HTTP, runs de cleanup, webhooks reçus et requêtes vers les
+// *arr et clients de téléchargement.
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "clarr"
+
+// Modes de cleanup, pour les labels mode.
+const (
+	ModeDryRun = "dry_run"
+	ModeTrash  = "trash"
+	ModeDelete = "delete"
+)
+
+// Metrics regroupe les collecteurs de clarr dans un registre dédié.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	httpDuration *prometheus.HistogramVec
+
+	cleanupRuns     *prometheus.CounterVec
+	cleanupDuration *prometheus.HistogramVec
+	orphansFound    *prometheus.CounterVec
+	freedBytes      *prometheus.CounterVec
+	backlogFiles    prometheus.Gauge
+	backlogBytes    prometheus.Gauge
+
+	webhookEvents *prometheus.CounterVec
+
+	upstreamRequests *prometheus.CounterVec
+	upstreamDuration *prometheus.HistogramVec
+}
+
+// New crée les collecteurs, avec ceux du runtime Go et du process.
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Duration of HTTP requests served by clarr.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+
+		cleanupRuns: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "cleanup_runs_total",
+			Help:      "Cleanup runs by trigger, mode and outcome.",
+		}, []string{"trigger", "mode", "outcome"}),
+		cleanupDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "cleanup_duration_seconds",
+			Help:      "Duration of cleanup runs.",
+			Buckets:   []float64{0.1, 0.5, 1, 5, 15, 30, 60, 120, 300, 600},
+		}, []string{"mode", "outcome"}),
+		orphansFound: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "orphans_found_total",
+			Help:      "Orphan files found by cleanup runs.",
+		}, []string{"mode"}),
+		freedBytes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "freed_bytes_total",
+			Help:      "Bytes freed by cleanup runs; simulated in dry_run mode.",
+		}, []string{"mode"}),
+		backlogFiles: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "orphan_backlog_files",
+			Help:      "Orphan files left in the download dir after the last full scan.",
+		}),
+		backlogBytes: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "orphan_backlog_bytes",
+			Help:      "Size of the orphan files left in the download dir after the last full scan.",
+		}),
+
+		webhookEvents: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "webhook_events_total",
+			Help:      "Webhook events received by source, type and result.",
+		}, []string{"source", "type", "result"}),
+
+		upstreamRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "upstream_requests_total",
+			Help:      "Requests sent to *arr apps and download clients, by status code (\"error\" when no response).",
+		}, []string{"upstream", "instance", "code"}),
+		upstreamDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "upstream_request_duration_seconds",
+			Help:      "Duration of requests sent to *arr apps and download clients.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"upstream", "instance"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpDuration,
+		m.cleanupRuns,
+		m.cleanupDuration,
+		m.orphansFound,
+		m.freedBytes,
+		m.backlogFiles,
+		m.backlogBytes,
+		m.webhookEvents,
+		m.upstreamRequests,
+		m.upstreamDuration,
+	)
+	return m
+}
+
+// Register expose GET /metrics.
+func (m *Metrics) Register(r *gin.Engine) {
+	r.GET("/metrics", gin.WrapH(promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})))
+}
+
+// ─── Observers ────────────────────────────────────────────────────────
+
+// ObserveRequest mesure une requête servie. route est le pattern Gin
+// (/api/jobs/:id), pas le chemin, pour borner le nombre de séries.
+func (m *Metrics) ObserveRequest(method, route string, status int, d time.Duration) {
+	if route == "" {
+		route = "unmatched"
+	}
+	m.httpDuration.WithLabelValues(method, route, strconv.Itoa(status)).Observe(d.Seconds())
+}
+
+// ObserveCleanup compte un run de cleanup terminé. Un run en échec ne
+// compte ni orphelins ni octets.
+func (m *Metrics) ObserveCleanup(trigger, mode string, d time.Duration, orphans int, freed int64, err error) {
+	outcome := "succeeded"
+	if err != nil {
+		outcome = "failed"
+	}
+	m.cleanupRuns.WithLabelValues(trigger, mode, outcome).Inc()
+	m.cleanupDuration.WithLabelValues(mode, outcome).Observe(d.Seconds())
+	if err != nil {
+		return
+	}
+	m.orphansFound.WithLabelValues(mode).Add(float64(orphans))
+	m.freedBytes.WithLabelValues(mode).Add(float64(freed))
+}
+
+// SkipCleanup compte un run non lancé parce qu'un autre était en cours.
+func (m *Metrics) SkipCleanup(trigger, mode string) {
+	m.cleanupRuns.WithLabelValues(trigger, mode, "skipped").Inc()
+}
+
+// SetBacklog met à jour les orphelins restant dans le downloadDir.
+func (m *Metrics) SetBacklog(files int, bytes int64) {
+	m.backlogFiles.Set(float64(files))
+	m.backlogBytes.Set(float64(bytes))
+}
+
+// WebhookEvent compte un webhook reçu. result vaut queued, ignored,
+// unauthorized, invalid ou failed.
+func (m *Metrics) WebhookEvent(source, eventType, result string) {
+	m.webhookEvents.WithLabelValues(source, eventType, result).Inc()
+}
+
+// ─── Transport ────────────────────────────────────────────────────────
+
+// Transport mesure les requêtes envoyées par next à une instance
+// d'upstream (radarr, sonarr, qbittorrent...).
+func (m *Metrics) Transport(upstream, instance string, next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &transport{
+		next:     next,
+		requests: m.upstreamRequests.MustCurryWith(prometheus.Labels{"upstream": upstream, "instance": instance}),
+		duration: m.upstreamDuration.WithLabelValues(upstream, instance),
+	}
+}
+
+type transport struct {
+	next     http.RoundTripper
+	requests *prometheus.CounterVec
+	duration prometheus.Observer
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	t.duration.Observe(time.Since(start).Seconds())
+
+	code := "error"
+	if err == nil {
+		code = strconv.Itoa(resp.StatusCode)
+	}
+	t.requests.WithLabelValues(code).Inc()
+	return resp, err
+}
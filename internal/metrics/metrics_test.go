@@ -0,0 +1,111 @@
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// scrape retourne la page /metrics.
+func scrape(t *testing.T, m *Metrics) string {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	m.Register(r)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET /metrics = %d", w.Code)
+	}
+	return w.Body.String()
+}
+
+func TestMetrics_Cleanup(t *testing.T) {
+	m := New()
+	m.ObserveCleanup("cron", ModeTrash, 2*time.Second, 3, 1024, nil)
+	m.ObserveCleanup("api", ModeTrash, time.Second, 5, 4096, errors.New("qbittorrent unavailable"))
+	m.SkipCleanup("cron", ModeTrash)
+	m.SetBacklog(2, 2048)
+
+	if got := testutil.ToFloat64(m.orphansFound.WithLabelValues(ModeTrash)); got != 3 {
+		t.Errorf("orphans found = %v, want 3 (failed runs not counted)", got)
+	}
+	if got := testutil.ToFloat64(m.freedBytes.WithLabelValues(ModeTrash)); got != 1024 {
+		t.Errorf("freed bytes = %v, want 1024", got)
+	}
+
+	body := scrape(t, m)
+	for _, want := range []string{
+		`clarr_cleanup_runs_total{mode="trash",outcome="succeeded",trigger="cron"} 1`,
+		`clarr_cleanup_runs_total{mode="trash",outcome="failed",trigger="api"} 1`,
+		`clarr_cleanup_runs_total{mode="trash",outcome="skipped",trigger="cron"} 1`,
+		`clarr_cleanup_duration_seconds_count{mode="trash",outcome="succeeded"} 1`,
+		`clarr_orphan_backlog_files 2`,
+		`clarr_orphan_backlog_bytes 2048`,
+		`go_goroutines`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("/metrics does not contain %q", want)
+		}
+	}
+}
+
+func TestMetrics_Transport(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer upstream.Close()
+
+	m := New()
+	client := &http.Client{Transport: m.Transport("radarr", "4k", nil)}
+	for _, path := range []string{"/api/v3/movie", "/api/v3/movie", "/missing"} {
+		resp, err := client.Get(upstream.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	// Connexion refusée : pas de code HTTP.
+	upstream.Close()
+	if _, err := client.Get(upstream.URL); err == nil {
+		t.Fatal("expected an error from a closed server")
+	}
+
+	for code, want := range map[string]float64{"200": 2, "404": 1, "error": 1} {
+		if got := testutil.ToFloat64(m.upstreamRequests.WithLabelValues("radarr", "4k", code)); got != want {
+			t.Errorf("requests with code %s = %v, want %v", code, got, want)
+		}
+	}
+	if !strings.Contains(scrape(t, m), `clarr_upstream_request_duration_seconds_count{instance="4k",upstream="radarr"} 4`) {
+		t.Error("upstream latencies not observed")
+	}
+}
+
+func TestMetrics_RequestsAndWebhooks(t *testing.T) {
+	m := New()
+	m.ObserveRequest(http.MethodGet, "/api/jobs/:id", http.StatusOK, 10*time.Millisecond)
+	m.ObserveRequest(http.MethodGet, "", http.StatusNotFound, time.Millisecond)
+	m.WebhookEvent("jellyfin", "movie", "queued")
+
+	body := scrape(t, m)
+	for _, want := range []string{
+		`clarr_http_request_duration_seconds_count{method="GET",route="/api/jobs/:id",status="200"} 1`,
+		`clarr_http_request_duration_seconds_count{method="GET",route="unmatched",status="404"} 1`,
+		`clarr_webhook_events_total{result="queued",source="jellyfin",type="movie"} 1`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("/metrics does not contain %q", want)
+		}
+	}
+}
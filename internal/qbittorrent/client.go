@@ -2,13 +2,18 @@ package qbittorrent
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/http/cookiejar"
 	"net/url"
+	"path/filepath"
 	"strings"
+	"sync"
 	"time"
+
+	"github.com/cleeryy/clarr/internal/download"
 )
 
 type Client struct {
@@ -16,8 +21,14 @@ type Client struct {
 	username   string
 	password   string
 	httpClient *http.Client
+
+	mu       sync.Mutex
+	loggedIn bool
+	lastErr  error
 }
 
+// New crée le client sans contacter qBittorrent : la connexion est
+// établie au premier appel, et rétablie si la session expire.
 func New(baseURL, username, password string) (*Client, error) {
 	jar, err := cookiejar.New(nil)
 	if err != nil {
@@ -34,13 +45,15 @@ func New(baseURL, username, password string) (*Client, error) {
 		},
 	}
 
-	if err := c.login(); err != nil {
-		return nil, err
-	}
-
 	return c, nil
 }
 
+// SetTransport remplace le transport HTTP du client, par exemple pour
+// mesurer les requêtes.
+func (c *Client) SetTransport(rt http.RoundTripper) {
+	c.httpClient.Transport = rt
+}
+
 // ─── Models ───────────────────────────────────────────────────────────
 
 type Torrent struct {
@@ -52,11 +65,74 @@ type Torrent struct {
 	Ratio       float64 `json:"ratio"`
 	AmountLeft  int64   `json:"amount_left"`
 	Completed   int64   `json:"completed"`
+	SeedingTime int64   `json:"seeding_time"` // secondes
+	Category    string  `json:"category"`
+	Tags        string  `json:"tags"`    // séparés par des virgules
+	Tracker     string  `json:"tracker"` // tracker actif
+}
+
+type TorrentFile struct {
+	Index int    `json:"index"`
+	Name  string `json:"name"` // relatif au save_path du torrent
+	Size  int64  `json:"size"`
+}
+
+// Item convertit le torrent, sans ses fichiers, pour le cleaner.
+func (t Torrent) Item() download.Item {
+	return download.Item{
+		ID:          t.Hash,
+		Name:        t.Name,
+		Category:    t.Category,
+		Tracker:     t.Tracker,
+		Ratio:       t.Ratio,
+		SeedingTime: time.Duration(t.SeedingTime) * time.Second,
+		Incomplete:  t.AmountLeft > 0,
+	}
+}
+
+// ─── Errors ───────────────────────────────────────────────────────────
+
+// ErrAuthFailed est retourné quand qBittorrent refuse les identifiants.
+var ErrAuthFailed = errors.New("qbittorrent: login failed — check credentials")
+
+// APIError est retourné quand qBittorrent répond avec un statut >= 400.
+type APIError struct {
+	StatusCode int
+	Method     string
+	Endpoint   string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("qbittorrent: unexpected status %d on %s %s", e.StatusCode, e.Method, e.Endpoint)
+}
+
+// Temporary indique si la requête peut être réessayée plus tard.
+func (e *APIError) Temporary() bool {
+	return e.StatusCode >= 500 || e.StatusCode == http.StatusTooManyRequests
 }
 
 // ─── Auth ─────────────────────────────────────────────────────────────
 
+// Login ouvre une session. Les autres méthodes l'appellent au besoin ;
+// l'appeler au démarrage permet seulement de vérifier la configuration.
+func (c *Client) Login() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.setErr(c.login())
+}
+
+// LastError retourne l'erreur du dernier échange avec qBittorrent,
+// ou nil si la dernière requête a abouti.
+func (c *Client) LastError() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lastErr
+}
+
+// login ouvre une session. À appeler sous c.mu.
 func (c *Client) login() error {
+	c.loggedIn = false
+
 	resp, err := c.httpClient.PostForm(c.baseURL+"/api/v2/auth/login", url.Values{
 		"username": {c.username},
 		"password": {c.password},
@@ -66,16 +142,106 @@ func (c *Client) login() error {
 	}
 	defer resp.Body.Close()
 
+	// Trop d'échecs : qBittorrent bannit l'IP et répond 403.
+	if resp.StatusCode >= 400 {
+		return &APIError{StatusCode: resp.StatusCode, Method: http.MethodPost, Endpoint: "/api/v2/auth/login"}
+	}
+
 	body, _ := io.ReadAll(resp.Body)
 	if string(body) != "Ok." {
-		return fmt.Errorf("qbittorrent: login failed — check credentials")
+		return ErrAuthFailed
 	}
 
+	c.loggedIn = true
 	return nil
 }
 
+// ─── HTTP Helper ──────────────────────────────────────────────────────
+
+// do envoie une requête authentifiée. Sur 403 (session expirée ou
+// qBittorrent redémarré), il se reconnecte et rejoue la requête une fois.
+func (c *Client) do(method, endpoint string, form url.Values) (*http.Response, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.loggedIn {
+		if err := c.login(); err != nil {
+			return nil, c.setErr(err)
+		}
+	}
+
+	resp, err := c.send(method, endpoint, form)
+	if err != nil {
+		return nil, c.setErr(err)
+	}
+
+	if resp.StatusCode == http.StatusForbidden {
+		resp.Body.Close()
+		if err := c.login(); err != nil {
+			return nil, c.setErr(err)
+		}
+		if resp, err = c.send(method, endpoint, form); err != nil {
+			return nil, c.setErr(err)
+		}
+	}
+
+	if resp.StatusCode >= 400 {
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusForbidden {
+			c.loggedIn = false
+		}
+		return nil, c.setErr(&APIError{StatusCode: resp.StatusCode, Method: method, Endpoint: endpoint})
+	}
+
+	c.setErr(nil)
+	return resp, nil
+}
+
+func (c *Client) send(method, endpoint string, form url.Values) (*http.Response, error) {
+	var body io.Reader
+	if form != nil {
+		body = strings.NewReader(form.Encode())
+	}
+
+	req, err := http.NewRequest(method, c.baseURL+endpoint, body)
+	if err != nil {
+		return nil, fmt.Errorf("qbittorrent: build request: %w", err)
+	}
+	if form != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("qbittorrent: request failed: %w", err)
+	}
+	return resp, nil
+}
+
+// setErr mémorise l'issue du dernier échange pour LastError. À appeler sous c.mu.
+func (c *Client) setErr(err error) error {
+	c.lastErr = err
+	return err
+}
+
 // ─── Methods ──────────────────────────────────────────────────────────
 
+// Version retourne la version de qBittorrent, ce qui vérifie au passage
+// l'URL et les identifiants.
+func (c *Client) Version() (string, error) {
+	resp, err := c.do(http.MethodGet, "/api/v2/app/version", nil)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("qbittorrent: read version: %w", err)
+	}
+	return strings.TrimSpace(string(body)), nil
+}
+
 // GetTorrents retourne tous les torrents (optionnellement filtrés par état).
 func (c *Client) GetTorrents(filter string) ([]Torrent, error) {
 	endpoint := "/api/v2/torrents/info"
@@ -83,9 +249,9 @@ func (c *Client) GetTorrents(filter string) ([]Torrent, error) {
 		endpoint += "?filter=" + filter
 	}
 
-	resp, err := c.httpClient.Get(c.baseURL + endpoint)
+	resp, err := c.do(http.MethodGet, endpoint, nil)
 	if err != nil {
-		return nil, fmt.Errorf("qbittorrent: get torrents: %w", err)
+		return nil, err
 	}
 	defer resp.Body.Close()
 
@@ -101,44 +267,79 @@ func (c *Client) GetPausedTorrents() ([]Torrent, error) {
 	return c.GetTorrents("paused")
 }
 
-// DeleteTorrent supprime un torrent par son hash.
+// Delete supprime un torrent par son hash.
 // deleteFiles = true supprime aussi les fichiers du disque.
-func (c *Client) DeleteTorrent(hash string, deleteFiles bool) error {
-	resp, err := c.httpClient.PostForm(c.baseURL+"/api/v2/torrents/delete", url.Values{
+func (c *Client) Delete(hash string, deleteFiles bool) error {
+	resp, err := c.do(http.MethodPost, "/api/v2/torrents/delete", url.Values{
 		"hashes":      {hash},
 		"deleteFiles": {fmt.Sprintf("%v", deleteFiles)},
 	})
 	if err != nil {
-		return fmt.Errorf("qbittorrent: delete torrent: %w", err)
+		return err
 	}
-	defer resp.Body.Close()
+	resp.Body.Close()
 	return nil
 }
 
-// DeleteTorrentByPath supprime le torrent dont le path correspond.
-func (c *Client) DeleteTorrentByPath(filePath string, deleteFiles bool) error {
+// GetTorrentFiles retourne les fichiers d'un torrent.
+func (c *Client) GetTorrentFiles(hash string) ([]TorrentFile, error) {
+	resp, err := c.do(http.MethodGet, "/api/v2/torrents/files?hash="+url.QueryEscape(hash), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var files []TorrentFile
+	if err := json.NewDecoder(resp.Body).Decode(&files); err != nil {
+		return nil, fmt.Errorf("qbittorrent: decode torrent files: %w", err)
+	}
+	return files, nil
+}
+
+// MatchPaths retourne les torrents contenant au moins un des paths,
+// avec la liste complète de leurs fichiers. Les chemins sont comparés
+// exactement, après nettoyage, fichier par fichier.
+func (c *Client) MatchPaths(paths []string) ([]download.Item, error) {
+	wanted := download.PathSet(paths)
+
 	torrents, err := c.GetTorrents("")
 	if err != nil {
-		return err
+		return nil, err
 	}
 
+	var matches []download.Item
 	for _, t := range torrents {
-		if strings.HasPrefix(filePath, t.SavePath) || strings.HasPrefix(filePath, t.ContentPath) {
-			return c.DeleteTorrent(t.Hash, deleteFiles)
+		// Tous les fichiers d'un torrent sont sous son content_path : inutile
+		// de lister les fichiers d'un torrent qui ne contient aucun path.
+		if !download.ContainsAny(t.ContentPath, paths) {
+			continue
+		}
+
+		files, err := c.GetTorrentFiles(t.Hash)
+		if err != nil {
+			return nil, err
+		}
+
+		item := t.Item()
+		for _, f := range files {
+			item.Files = append(item.Files, filepath.Join(t.SavePath, f.Name))
+		}
+		if download.AnyIn(item.Files, wanted) {
+			matches = append(matches, item)
 		}
 	}
 
-	return fmt.Errorf("qbittorrent: no torrent found for path %s", filePath)
+	return matches, nil
 }
 
-// PauseTorrent met en pause un torrent par son hash.
-func (c *Client) PauseTorrent(hash string) error {
-	resp, err := c.httpClient.PostForm(c.baseURL+"/api/v2/torrents/pause", url.Values{
+// Pause met en pause un torrent par son hash.
+func (c *Client) Pause(hash string) error {
+	resp, err := c.do(http.MethodPost, "/api/v2/torrents/pause", url.Values{
 		"hashes": {hash},
 	})
 	if err != nil {
-		return fmt.Errorf("qbittorrent: pause torrent: %w", err)
+		return err
 	}
-	defer resp.Body.Close()
+	resp.Body.Close()
 	return nil
 }
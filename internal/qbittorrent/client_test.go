@@ -0,0 +1,266 @@
+package qbittorrent
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// fakeTorrent est un torrent servi par le faux qBittorrent.
+type fakeTorrent struct {
+	Torrent
+	Files []TorrentFile
+}
+
+// fakeServer simule l'API qBittorrent et enregistre les hash supprimés.
+func fakeServer(t *testing.T, torrents []fakeTorrent, deleted *[]string) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/api/v2/auth/login", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("Ok."))
+	})
+	mux.HandleFunc("/api/v2/torrents/info", func(w http.ResponseWriter, r *http.Request) {
+		list := make([]Torrent, len(torrents))
+		for i, ft := range torrents {
+			list[i] = ft.Torrent
+		}
+		_ = json.NewEncoder(w).Encode(list)
+	})
+	mux.HandleFunc("/api/v2/torrents/files", func(w http.ResponseWriter, r *http.Request) {
+		for _, ft := range torrents {
+			if ft.Hash == r.URL.Query().Get("hash") {
+				_ = json.NewEncoder(w).Encode(ft.Files)
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+	})
+	mux.HandleFunc("/api/v2/torrents/delete", func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("parse delete form: %v", err)
+		}
+		*deleted = append(*deleted, strings.Split(r.PostForm.Get("hashes"), "|")...)
+	})
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestMatchPaths(t *testing.T) {
+	// Tous les torrents partagent le même save_path, comme dans un
+	// /downloads/complete classique.
+	torrents := []fakeTorrent{
+		{
+			Torrent: Torrent{Hash: "single", Name: "Movie.2021.mkv", SavePath: "/downloads/complete/", ContentPath: "/downloads/complete/Movie.2021.mkv"},
+			Files:   []TorrentFile{{Name: "Movie.2021.mkv"}},
+		},
+		{
+			Torrent: Torrent{Hash: "neighbour", Name: "Other.2020.mkv", SavePath: "/downloads/complete/", ContentPath: "/downloads/complete/Other.2020.mkv"},
+			Files:   []TorrentFile{{Name: "Other.2020.mkv"}},
+		},
+		{
+			Torrent: Torrent{Hash: "pack", Name: "Show.S01", SavePath: "/downloads/complete", ContentPath: "/downloads/complete/Show.S01"},
+			Files: []TorrentFile{
+				{Name: "Show.S01/Show.S01E01.mkv"},
+				{Name: "Show.S01/Show.S01E02.mkv"},
+			},
+		},
+		{
+			Torrent: Torrent{Hash: "prefix", Name: "Show.S01 Extras", SavePath: "/downloads/complete", ContentPath: "/downloads/complete/Show.S01 Extras"},
+			Files:   []TorrentFile{{Name: "Show.S01 Extras/Making.Of.mkv"}},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		orphans []string
+		want    []string
+	}{
+		{
+			name:    "single file only matches its own torrent",
+			orphans: []string{"/downloads/complete/Movie.2021.mkv"},
+			want:    []string{"single"},
+		},
+		{
+			name:    "shared save path alone matches nothing",
+			orphans: []string{"/downloads/complete/Unknown.mkv"},
+			want:    nil,
+		},
+		{
+			name:    "file name prefix is not a match",
+			orphans: []string{"/downloads/complete/Movie.2021.mkv.bak"},
+			want:    nil,
+		},
+		{
+			// Le cleaner décide de le garder : il reçoit tous ses fichiers.
+			name:    "multi-file torrent matches on a single file",
+			orphans: []string{"/downloads/complete/Show.S01/Show.S01E01.mkv"},
+			want:    []string{"pack"},
+		},
+		{
+			name:    "directory name prefix does not leak to sibling",
+			orphans: []string{"/downloads/complete/Show.S01 Extras/Making.Of.mkv"},
+			want:    []string{"prefix"},
+		},
+		{
+			name:    "uncleaned paths still match",
+			orphans: []string{"/downloads//complete/./Other.2020.mkv"},
+			want:    []string{"neighbour"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var deleted []string
+			srv := fakeServer(t, torrents, &deleted)
+
+			c, err := New(srv.URL, "admin", "secret")
+			if err != nil {
+				t.Fatalf("login: %v", err)
+			}
+
+			matches, err := c.MatchPaths(tt.orphans)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var got []string
+			for _, m := range matches {
+				got = append(got, m.ID)
+				if m.ID == "pack" && len(m.Files) != 2 {
+					t.Errorf("pack files = %v, want both episodes", m.Files)
+				}
+			}
+			sort.Strings(got)
+
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("matched %v, want %v", got, tt.want)
+			}
+			if len(deleted) != 0 {
+				t.Errorf("MatchPaths deleted %v", deleted)
+			}
+		})
+	}
+}
+
+func TestReloginOnExpiredSession(t *testing.T) {
+	logins := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v2/auth/login", func(w http.ResponseWriter, r *http.Request) {
+		logins++
+		http.SetCookie(w, &http.Cookie{Name: "SID", Value: fmt.Sprintf("sid-%d", logins), Path: "/"})
+		_, _ = w.Write([]byte("Ok."))
+	})
+	mux.HandleFunc("/api/v2/torrents/info", func(w http.ResponseWriter, r *http.Request) {
+		// Seule la session ouverte en dernier est valide, comme après un
+		// redémarrage de qBittorrent.
+		cookie, err := r.Cookie("SID")
+		if err != nil || cookie.Value != fmt.Sprintf("sid-%d", logins) {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		_ = json.NewEncoder(w).Encode([]Torrent{{Hash: "abc"}})
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	c, err := New(srv.URL, "admin", "secret")
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	if logins != 0 {
+		t.Fatalf("New logged in %d times, want lazy login", logins)
+	}
+
+	if _, err := c.GetTorrents(""); err != nil {
+		t.Fatalf("first call: %v", err)
+	}
+
+	// Session expirée côté serveur.
+	logins++
+
+	torrents, err := c.GetTorrents("")
+	if err != nil {
+		t.Fatalf("call after expiry: %v", err)
+	}
+	if len(torrents) != 1 || torrents[0].Hash != "abc" {
+		t.Errorf("got %+v, want torrent abc", torrents)
+	}
+	if logins != 3 {
+		t.Errorf("logins = %d, want 3 (initial, bump, relogin)", logins)
+	}
+	if err := c.LastError(); err != nil {
+		t.Errorf("LastError = %v, want nil", err)
+	}
+}
+
+func TestTypedErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		login http.HandlerFunc
+		info  http.HandlerFunc
+		check func(t *testing.T, err error)
+	}{
+		{
+			name:  "wrong credentials",
+			login: func(w http.ResponseWriter, r *http.Request) { _, _ = w.Write([]byte("Fails.")) },
+			check: func(t *testing.T, err error) {
+				if !errors.Is(err, ErrAuthFailed) {
+					t.Errorf("got %v, want ErrAuthFailed", err)
+				}
+			},
+		},
+		{
+			name:  "still forbidden after relogin",
+			login: func(w http.ResponseWriter, r *http.Request) { _, _ = w.Write([]byte("Ok.")) },
+			info:  func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusForbidden) },
+			check: func(t *testing.T, err error) {
+				var apiErr *APIError
+				if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusForbidden {
+					t.Errorf("got %v, want APIError 403", err)
+				}
+			},
+		},
+		{
+			name:  "server error is temporary",
+			login: func(w http.ResponseWriter, r *http.Request) { _, _ = w.Write([]byte("Ok.")) },
+			info:  func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusServiceUnavailable) },
+			check: func(t *testing.T, err error) {
+				var apiErr *APIError
+				if !errors.As(err, &apiErr) || !apiErr.Temporary() {
+					t.Errorf("got %v, want temporary APIError", err)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			mux.HandleFunc("/api/v2/auth/login", tt.login)
+			if tt.info != nil {
+				mux.HandleFunc("/api/v2/torrents/info", tt.info)
+			}
+			srv := httptest.NewServer(mux)
+			t.Cleanup(srv.Close)
+
+			c, err := New(srv.URL, "admin", "secret")
+			if err != nil {
+				t.Fatalf("new: %v", err)
+			}
+
+			_, err = c.GetTorrents("")
+			tt.check(t, err)
+			if c.LastError() != err {
+				t.Errorf("LastError = %v, want %v", c.LastError(), err)
+			}
+		})
+	}
+}
@@ -0,0 +1,491 @@
+package queue
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+	"go.uber.org/zap"
+)
+
+// Les jobs à traiter (pending, running) et les dead-letters sont rangés
+// dans deux buckets, pour que claim ne parcoure jamais les jobs morts.
+var (
+	jobsBucket = []byte("jobs")
+	deadBucket = []byte("dead")
+)
+
+// pollInterval est le délai max entre deux recherches de job dû,
+// au cas où aucun Enqueue ne réveille les workers (retries différés).
+const pollInterval = time.Second
+
+type Queue struct {
+	db       *bolt.DB
+	opts     Options
+	handlers map[string]HandlerFunc
+	logger   *zap.Logger
+	wake     chan struct{}
+	wg       sync.WaitGroup
+}
+
+type Options struct {
+	Workers       int
+	MaxAttempts   int
+	RetryDelay    time.Duration
+	MaxRetryDelay time.Duration
+	// DeadRetention est la durée de conservation des dead-letters,
+	// 0 pour les garder indéfiniment.
+	DeadRetention time.Duration
+}
+
+// HandlerFunc traite le payload d'un job. Une erreur déclenche un retry
+// avec backoff, sauf si elle est enveloppée par Permanent.
+type HandlerFunc func(ctx context.Context, payload json.RawMessage) error
+
+// ─── Models ───────────────────────────────────────────────────────────
+
+type State string
+
+const (
+	StatePending State = "pending"
+	StateRunning State = "running"
+	StateDead    State = "dead"
+)
+
+type Job struct {
+	ID        uint64          `json:"id"`
+	Kind      string          `json:"kind"`
+	Payload   json.RawMessage `json:"payload"`
+	State     State           `json:"state"`
+	Attempts  int             `json:"attempts"`
+	LastError string          `json:"last_error,omitempty"`
+	NextRunAt time.Time       `json:"next_run_at"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+// ─── Errors ───────────────────────────────────────────────────────────
+
+type permanentError struct{ err error }
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent marque une erreur comme définitive : le job passe directement
+// en dead-letter au lieu d'être réessayé.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsPermanent indique si err a été marquée par Permanent.
+func IsPermanent(err error) bool {
+	var permanent *permanentError
+	return errors.As(err, &permanent)
+}
+
+// ─── Lifecycle ────────────────────────────────────────────────────────
+
+// Open ouvre (ou crée) la file persistante dans dataDir.
+func Open(dataDir string, opts Options, logger *zap.Logger) (*Queue, error) {
+	if opts.Workers < 1 {
+		opts.Workers = 1
+	}
+	if opts.MaxAttempts < 1 {
+		opts.MaxAttempts = 1
+	}
+
+	if err := os.MkdirAll(dataDir, 0o755); err != nil {
+		return nil, fmt.Errorf("queue: create data dir: %w", err)
+	}
+
+	db, err := bolt.Open(filepath.Join(dataDir, "queue.db"), 0o600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("queue: open db: %w", err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists(jobsBucket); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucketIfNotExists(deadBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("queue: create bucket: %w", err)
+	}
+
+	return &Queue{
+		db:       db,
+		opts:     opts,
+		handlers: make(map[string]HandlerFunc),
+		logger:   logger,
+		wake:     make(chan struct{}, 1),
+	}, nil
+}
+
+// Handle enregistre le handler d'un type de job. À appeler avant Start.
+func (q *Queue) Handle(kind string, fn HandlerFunc) {
+	q.handlers[kind] = fn
+}
+
+// Start remet en attente les jobs interrompus par un arrêt précédent,
+// purge les dead-letters expirés puis lance les workers. Ils s'arrêtent
+// quand ctx est annulé.
+func (q *Queue) Start(ctx context.Context) error {
+	replayed, err := q.requeueRunning()
+	if err != nil {
+		return err
+	}
+
+	pruned, err := q.pruneDead(time.Now().UTC())
+	if err != nil {
+		return err
+	}
+
+	pending, err := q.count(StatePending)
+	if err != nil {
+		return err
+	}
+	q.logger.Info("job queue started",
+		zap.Int("workers", q.opts.Workers),
+		zap.Int("pending", pending),
+		zap.Int("replayed", replayed),
+		zap.Int("dead_pruned", pruned),
+	)
+
+	for i := 0; i < q.opts.Workers; i++ {
+		q.wg.Add(1)
+		go q.worker(ctx)
+	}
+	return nil
+}
+
+// Close attend la fin des workers puis ferme la base.
+func (q *Queue) Close() error {
+	q.wg.Wait()
+	return q.db.Close()
+}
+
+// ─── Enqueue ──────────────────────────────────────────────────────────
+
+// Enqueue persiste un nouveau job et réveille un worker.
+func (q *Queue) Enqueue(kind string, payload any) (uint64, error) {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return 0, fmt.Errorf("queue: encode payload: %w", err)
+	}
+
+	now := time.Now().UTC()
+	job := Job{
+		Kind:      kind,
+		Payload:   raw,
+		State:     StatePending,
+		NextRunAt: now,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	err = q.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(jobsBucket)
+		id, err := b.NextSequence()
+		if err != nil {
+			return err
+		}
+		job.ID = id
+		return putJob(b, &job)
+	})
+	if err != nil {
+		return 0, fmt.Errorf("queue: enqueue: %w", err)
+	}
+
+	q.notify()
+	return job.ID, nil
+}
+
+// ─── Workers ──────────────────────────────────────────────────────────
+
+func (q *Queue) worker(ctx context.Context) {
+	defer q.wg.Done()
+
+	for {
+		job, next, err := q.claim()
+		if err != nil {
+			q.logger.Error("job queue claim failed", zap.Error(err))
+		}
+
+		if job == nil {
+			wait := pollInterval
+			if !next.IsZero() && time.Until(next) < wait {
+				wait = max(time.Until(next), 0)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-q.wake:
+			case <-time.After(wait):
+			}
+			continue
+		}
+
+		q.run(ctx, job)
+	}
+}
+
+func (q *Queue) run(ctx context.Context, job *Job) {
+	fn, ok := q.handlers[job.Kind]
+	if !ok {
+		q.finish(ctx, job, Permanent(fmt.Errorf("no handler for job kind %q", job.Kind)))
+		return
+	}
+
+	q.finish(ctx, job, fn(context.WithValue(ctx, jobIDKey{}, job.ID), job.Payload))
+}
+
+type jobIDKey struct{}
+
+// JobID retourne l'ID du job traité par un HandlerFunc, pour relier ses
+// actions à l'événement qui l'a créé.
+func JobID(ctx context.Context) (uint64, bool) {
+	id, ok := ctx.Value(jobIDKey{}).(uint64)
+	return id, ok
+}
+
+// finish enregistre l'issue d'un job : supprimé en cas de succès,
+// replanifié avec backoff sur erreur transitoire, déplacé dans le bucket
+// des dead-letters sinon.
+func (q *Queue) finish(ctx context.Context, job *Job, jobErr error) {
+	fields := []zap.Field{
+		zap.Uint64("job_id", job.ID),
+		zap.String("kind", job.Kind),
+		zap.Int("attempt", job.Attempts),
+	}
+
+	err := q.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(jobsBucket)
+
+		if jobErr == nil {
+			return b.Delete(key(job.ID))
+		}
+
+		job.UpdatedAt = time.Now().UTC()
+		job.LastError = jobErr.Error()
+
+		var permanent *permanentError
+		switch {
+		case ctx.Err() != nil:
+			// Arrêt en cours : le job sera rejoué au prochain démarrage,
+			// sans compter cette tentative.
+			job.Attempts--
+			job.State = StatePending
+		case errors.As(jobErr, &permanent) || job.Attempts >= q.opts.MaxAttempts:
+			job.State = StateDead
+		default:
+			job.State = StatePending
+			job.NextRunAt = job.UpdatedAt.Add(q.backoff(job.Attempts))
+		}
+
+		if job.State == StateDead {
+			if err := b.Delete(key(job.ID)); err != nil {
+				return err
+			}
+			return putJob(tx.Bucket(deadBucket), job)
+		}
+		return putJob(b, job)
+	})
+	if err != nil {
+		q.logger.Error("job queue update failed", append(fields, zap.Error(err))...)
+		return
+	}
+
+	switch {
+	case jobErr == nil:
+		q.logger.Info("job done", fields...)
+	case job.State == StateDead:
+		q.logger.Error("job failed permanently, moved to dead-letter", append(fields, zap.Error(jobErr))...)
+		if _, err := q.pruneDead(job.UpdatedAt); err != nil {
+			q.logger.Error("job queue prune failed", zap.Error(err))
+		}
+	case ctx.Err() != nil:
+		q.logger.Warn("job interrupted by shutdown, will be replayed", append(fields, zap.Error(jobErr))...)
+	default:
+		q.logger.Warn("job failed, will retry",
+			append(fields, zap.Time("next_run_at", job.NextRunAt), zap.Error(jobErr))...,
+		)
+	}
+}
+
+// backoff retourne le délai avant la tentative suivante :
+// RetryDelay, puis doublé à chaque échec, plafonné à MaxRetryDelay.
+func (q *Queue) backoff(attempts int) time.Duration {
+	d := q.opts.RetryDelay
+	for i := 1; i < attempts; i++ {
+		d *= 2
+		if q.opts.MaxRetryDelay > 0 && d >= q.opts.MaxRetryDelay {
+			return q.opts.MaxRetryDelay
+		}
+	}
+	return d
+}
+
+// ─── Storage ──────────────────────────────────────────────────────────
+
+// claim passe le plus ancien job dû en running et le retourne. Sinon,
+// retourne l'échéance du prochain retry planifié (zéro s'il n'y en a pas).
+// Les transactions bolt étant sérialisées, deux workers ne peuvent
+// pas réclamer le même job.
+func (q *Queue) claim() (*Job, time.Time, error) {
+	var claimed *Job
+	var next time.Time
+	now := time.Now().UTC()
+
+	err := q.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(jobsBucket)
+		c := b.Cursor()
+
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			var job Job
+			if err := json.Unmarshal(v, &job); err != nil {
+				return fmt.Errorf("decode job %x: %w", k, err)
+			}
+			if job.State != StatePending {
+				continue
+			}
+			if job.NextRunAt.After(now) {
+				if next.IsZero() || job.NextRunAt.Before(next) {
+					next = job.NextRunAt
+				}
+				continue
+			}
+
+			job.State = StateRunning
+			job.Attempts++
+			job.UpdatedAt = now
+			claimed = &job
+			return putJob(b, &job)
+		}
+		return nil
+	})
+	return claimed, next, err
+}
+
+// requeueRunning remet en pending les jobs restés running après un arrêt
+// brutal, pour qu'ils soient rejoués.
+func (q *Queue) requeueRunning() (int, error) {
+	n := 0
+	err := q.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(jobsBucket)
+		return b.ForEach(func(k, v []byte) error {
+			var job Job
+			if err := json.Unmarshal(v, &job); err != nil {
+				return fmt.Errorf("decode job %x: %w", k, err)
+			}
+			if job.State != StateRunning {
+				return nil
+			}
+			job.State = StatePending
+			n++
+			return putJob(b, &job)
+		})
+	})
+	if err != nil {
+		return 0, fmt.Errorf("queue: replay running jobs: %w", err)
+	}
+	return n, nil
+}
+
+// pruneDead supprime les dead-letters plus anciens que DeadRetention.
+func (q *Queue) pruneDead(now time.Time) (int, error) {
+	if q.opts.DeadRetention <= 0 {
+		return 0, nil
+	}
+	cutoff := now.Add(-q.opts.DeadRetention)
+
+	n := 0
+	err := q.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(deadBucket)
+
+		// Les clés sont collectées avant suppression : supprimer pendant
+		// un ForEach n'est pas permis par bolt.
+		var expired [][]byte
+		err := b.ForEach(func(k, v []byte) error {
+			var job Job
+			if err := json.Unmarshal(v, &job); err != nil {
+				return fmt.Errorf("decode job %x: %w", k, err)
+			}
+			if job.UpdatedAt.Before(cutoff) {
+				expired = append(expired, k)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, k := range expired {
+			if err := b.Delete(k); err != nil {
+				return err
+			}
+		}
+		n = len(expired)
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("queue: prune dead jobs: %w", err)
+	}
+	return n, nil
+}
+
+func (q *Queue) count(state State) (int, error) {
+	n := 0
+	err := q.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(jobsBucket).ForEach(func(_, v []byte) error {
+			var job Job
+			if err := json.Unmarshal(v, &job); err != nil {
+				return err
+			}
+			if job.State == state {
+				n++
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return 0, fmt.Errorf("queue: count jobs: %w", err)
+	}
+	return n, nil
+}
+
+func (q *Queue) notify() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+func putJob(b *bolt.Bucket, job *Job) error {
+	raw, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	return b.Put(key(job.ID), raw)
+}
+
+// key encode l'ID en big-endian pour que l'ordre des clés bolt
+// suive l'ordre d'arrivée des jobs.
+func key(id uint64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, id)
+	return b
+}
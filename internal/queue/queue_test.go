@@ -0,0 +1,252 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+	"go.uber.org/zap"
+)
+
+func openQueue(t *testing.T, dir string, opts Options) *Queue {
+	t.Helper()
+	q, err := Open(dir, opts, zap.NewNop())
+	if err != nil {
+		t.Fatalf("open queue: %v", err)
+	}
+	return q
+}
+
+// jobs retourne tous les jobs encore à traiter.
+func jobs(t *testing.T, q *Queue) []Job {
+	t.Helper()
+	return bucket(t, q, jobsBucket)
+}
+
+// deadJobs retourne les jobs passés en dead-letter.
+func deadJobs(t *testing.T, q *Queue) []Job {
+	t.Helper()
+	return bucket(t, q, deadBucket)
+}
+
+func bucket(t *testing.T, q *Queue, name []byte) []Job {
+	t.Helper()
+	var out []Job
+	err := q.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(name).ForEach(func(_, v []byte) error {
+			var job Job
+			if err := json.Unmarshal(v, &job); err != nil {
+				return err
+			}
+			out = append(out, job)
+			return nil
+		})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met before deadline")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestQueue_RetriesTransientErrors(t *testing.T) {
+	q := openQueue(t, t.TempDir(), Options{Workers: 2, MaxAttempts: 5, RetryDelay: time.Millisecond})
+
+	var calls atomic.Int32
+	q.Handle("test", func(ctx context.Context, payload json.RawMessage) error {
+		if calls.Add(1) < 3 {
+			return errors.New("radarr unavailable")
+		}
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := q.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := q.Enqueue("test", map[string]string{"title": "Dune"}); err != nil {
+		t.Fatal(err)
+	}
+
+	waitFor(t, func() bool { return calls.Load() == 3 && len(jobs(t, q)) == 0 })
+
+	cancel()
+	if err := q.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestQueue_JobIDInContext(t *testing.T) {
+	q := openQueue(t, t.TempDir(), Options{Workers: 1, MaxAttempts: 1})
+
+	var got atomic.Uint64
+	q.Handle("test", func(ctx context.Context, payload json.RawMessage) error {
+		id, _ := JobID(ctx)
+		got.Store(id)
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := q.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	id, err := q.Enqueue("test", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	waitFor(t, func() bool { return got.Load() == id })
+
+	cancel()
+	if err := q.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestQueue_DeadLetter(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		wantCalls int32
+	}{
+		{"permanent error", Permanent(errors.New("bad request")), 1},
+		{"attempts exhausted", errors.New("still down"), 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := openQueue(t, t.TempDir(), Options{Workers: 1, MaxAttempts: 3, RetryDelay: time.Millisecond})
+
+			var calls atomic.Int32
+			q.Handle("test", func(ctx context.Context, payload json.RawMessage) error {
+				calls.Add(1)
+				return tt.err
+			})
+
+			ctx, cancel := context.WithCancel(context.Background())
+			if err := q.Start(ctx); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := q.Enqueue("test", nil); err != nil {
+				t.Fatal(err)
+			}
+
+			waitFor(t, func() bool {
+				js := deadJobs(t, q)
+				return len(js) == 1 && js[0].State == StateDead
+			})
+			if js := jobs(t, q); len(js) != 0 {
+				t.Errorf("dead job still scanned by claim: %+v", js)
+			}
+
+			cancel()
+			if err := q.Close(); err != nil {
+				t.Fatal(err)
+			}
+			if calls.Load() != tt.wantCalls {
+				t.Errorf("expected %d calls, got %d", tt.wantCalls, calls.Load())
+			}
+		})
+	}
+}
+
+func TestQueue_DeadRetention(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now().UTC()
+
+	// Deux dead-letters d'une exécution précédente, l'un expiré, l'autre récent.
+	q := openQueue(t, dir, Options{})
+	err := q.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(deadBucket)
+		for _, job := range []Job{
+			{ID: 1, Kind: "test", State: StateDead, UpdatedAt: now.Add(-48 * time.Hour)},
+			{ID: 2, Kind: "test", State: StateDead, UpdatedAt: now.Add(-time.Hour)},
+		} {
+			if err := putJob(b, &job); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	q = openQueue(t, dir, Options{Workers: 1, MaxAttempts: 1, DeadRetention: 24 * time.Hour})
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := q.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if js := deadJobs(t, q); len(js) != 1 || js[0].ID != 2 {
+		t.Errorf("dead jobs after start = %+v, want only the recent one", js)
+	}
+
+	cancel()
+	if err := q.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestQueue_ReplaysPendingJobsOnStartup(t *testing.T) {
+	dir := t.TempDir()
+
+	// Premier démarrage : le job est persisté mais aucun worker ne tourne.
+	q := openQueue(t, dir, Options{Workers: 1, MaxAttempts: 3})
+	if _, err := q.Enqueue("test", map[string]int{"movie_id": 42}); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Redémarrage : le job doit être rejoué.
+	q = openQueue(t, dir, Options{Workers: 1, MaxAttempts: 3})
+	var got atomic.Value
+	q.Handle("test", func(ctx context.Context, payload json.RawMessage) error {
+		got.Store(string(payload))
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := q.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	waitFor(t, func() bool { return got.Load() != nil })
+	if got.Load() != `{"movie_id":42}` {
+		t.Errorf("unexpected payload %v", got.Load())
+	}
+
+	cancel()
+	if err := q.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	q := &Queue{opts: Options{RetryDelay: time.Second, MaxRetryDelay: 10 * time.Second}}
+
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second, 10 * time.Second}
+	for i, w := range want {
+		if got := q.backoff(i + 1); got != w {
+			t.Errorf("backoff(%d) = %v, want %v", i+1, got, w)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package radarr
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// fixtureServer sert le document enregistré sur GET et capture le corps
+// du PUT renvoyé par le client.
+func fixtureServer(t *testing.T, fixture []byte, put *map[string]any) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			_, _ = w.Write(fixture)
+		case http.MethodPut:
+			body, _ := io.ReadAll(r.Body)
+			if err := json.Unmarshal(body, put); err != nil {
+				t.Errorf("decode PUT body: %v", err)
+			}
+			_, _ = w.Write(body)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestUnmonitorMovie_PreservesUnknownFields(t *testing.T) {
+	fixture, err := os.ReadFile("testdata/movie.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var put map[string]any
+	srv := fixtureServer(t, fixture, &put)
+
+	if err := New(srv.URL, "key").UnmonitorMovie(42); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var want map[string]any
+	if err := json.Unmarshal(fixture, &want); err != nil {
+		t.Fatal(err)
+	}
+	want["monitored"] = false
+
+	if !reflect.DeepEqual(put, want) {
+		for k, v := range want {
+			if !reflect.DeepEqual(put[k], v) {
+				t.Errorf("field %q: got %v, want %v", k, put[k], v)
+			}
+		}
+		t.Fatal("PUT body does not match fixture with monitored = false")
+	}
+}
+
+// commandServer renvoie successivement les états donnés pour la commande 7.
+// "503" et "404" répondent ce statut, "hang" ne répond qu'à l'annulation
+// de la requête.
+func commandServer(t *testing.T, states ...string) *httptest.Server {
+	t.Helper()
+	polls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/api/v3/command":
+			fmt.Fprint(w, `{"id": 7, "name": "RescanMovie", "status": "queued"}`)
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v3/command/7":
+			state := states[min(polls, len(states)-1)]
+			polls++
+			switch state {
+			case "503":
+				w.WriteHeader(http.StatusServiceUnavailable)
+			case "404":
+				w.WriteHeader(http.StatusNotFound)
+			case "hang":
+				<-r.Context().Done()
+			default:
+				fmt.Fprintf(w, `{"id": 7, "name": "RescanMovie", "status": %q, "message": "boom"}`, state)
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestWaitForCommand(t *testing.T) {
+	tests := []struct {
+		name    string
+		states  []string
+		timeout time.Duration
+		wantErr bool
+	}{
+		{"completed", []string{"queued", "started", "completed"}, time.Second, false},
+		{"failed", []string{"started", "failed"}, time.Second, true},
+		{"timeout", []string{"started"}, 50 * time.Millisecond, true},
+		{"transient poll errors are retried", []string{"started", "503", "503", "completed"}, time.Second, false},
+		{"unknown command stops the wait", []string{"started", "404", "completed"}, time.Second, true},
+		{"stuck request is cancelled with ctx", []string{"hang"}, 50 * time.Millisecond, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := New(commandServer(t, tt.states...).URL, "key")
+			c.pollInterval = 5 * time.Millisecond
+
+			id, err := c.RescanAll()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if id != 7 {
+				t.Fatalf("expected command id 7, got %d", id)
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), tt.timeout)
+			defer cancel()
+
+			start := time.Now()
+			_, err = c.WaitForCommand(ctx, id)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("WaitForCommand error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if elapsed := time.Since(start); elapsed > tt.timeout+time.Second {
+				t.Errorf("WaitForCommand returned after %s, ctx was not honoured", elapsed)
+			}
+		})
+	}
+}
+
+func TestImportedFiles_PagesHistory(t *testing.T) {
+	pages := map[string]string{
+		"1": `{"page": 1, "totalRecords": 1500, "records": [
+			{"eventType": "downloadFolderImported", "data": {"droppedPath": "/downloads/radarr/Dune.2021/Dune.2021.mkv", "importedPath": "/movies/Dune (2021)/Dune.mkv"}},
+			{"eventType": "downloadFolderImported", "data": {"droppedPath": "/downloads/radarr/Dune.2021/Dune.2021.mkv", "importedPath": "/movies/Dune (2021)/Dune.old.mkv"}}
+		]}`,
+		"2": `{"page": 2, "totalRecords": 1500, "records": [
+			{"eventType": "downloadFolderImported", "data": {"droppedPath": "/downloads/radarr/Heat.1995.mkv", "importedPath": "/movies/Heat (1995)/Heat.mkv"}},
+			{"eventType": "grabbed", "data": {}}
+		]}`,
+	}
+	var requested []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page := r.URL.Query().Get("page")
+		requested = append(requested, page)
+		if r.URL.Path != "/api/v3/history" || r.URL.Query().Get("eventType") != "3" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprint(w, pages[page])
+	}))
+	t.Cleanup(srv.Close)
+
+	got, err := New(srv.URL, "key").ImportedFiles()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := map[string]string{
+		"/downloads/radarr/Dune.2021/Dune.2021.mkv": "/movies/Dune (2021)/Dune.mkv",
+		"/downloads/radarr/Heat.1995.mkv":           "/movies/Heat (1995)/Heat.mkv",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ImportedFiles() = %v, want %v", got, want)
+	}
+	if !reflect.DeepEqual(requested, []string{"1", "2"}) {
+		t.Errorf("requested pages %v, want [1 2]", requested)
+	}
+}
+
+func TestVersion(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v3/system/status" || r.Header.Get("X-Api-Key") != "key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, `{"appName": "Radarr", "version": "5.2.6.8376", "branch": "master"}`)
+	}))
+	t.Cleanup(srv.Close)
+
+	if got, err := New(srv.URL, "key").Version(); err != nil || got != "5.2.6.8376" {
+		t.Errorf("Version() = %q, %v", got, err)
+	}
+	if _, err := New(srv.URL, "wrong").Version(); err == nil {
+		t.Error("expected an error with an invalid api key")
+	}
+}
@@ -0,0 +1,143 @@
+package readarr
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/cleeryy/clarr/internal/arr"
+)
+
+// Client parle à l'API v1 de Readarr ; les requêtes, commandes et dossiers
+// racine viennent du client commun.
+type Client struct {
+	*arr.Client
+}
+
+func New(baseURL, apiKey string) *Client {
+	return &Client{Client: arr.NewClient("readarr", baseURL, apiKey)}
+}
+
+// ─── Models ─────────────────────────────────────────────────────────
+
+type Author struct {
+	ID              int    `json:"id"`
+	AuthorName      string `json:"authorName"`
+	Monitored       bool   `json:"monitored"`
+	Path            string `json:"path"`
+	ForeignAuthorID string `json:"foreignAuthorId"` // Goodreads author ID
+}
+
+type Book struct {
+	ID            int       `json:"id"`
+	Title         string    `json:"title"`
+	AuthorID      int       `json:"authorId"`
+	Monitored     bool      `json:"monitored"`
+	ForeignBookID string    `json:"foreignBookId"` // Goodreads book ID
+	ReleaseDate   time.Time `json:"releaseDate"`
+	Author        struct {
+		AuthorName string `json:"authorName"`
+		Path       string `json:"path"`
+	} `json:"author"`
+	Statistics struct {
+		BookFileCount int `json:"bookFileCount"`
+	} `json:"statistics"`
+}
+
+type BooksMonitor struct {
+	BookIDs   []int `json:"bookIds"`
+	Monitored bool  `json:"monitored"`
+}
+
+type Command struct {
+	Name     string `json:"name"`
+	AuthorID int    `json:"authorId,omitempty"`
+}
+
+// Types communs à Lidarr et Readarr.
+type (
+	APIError      = arr.APIError
+	RootFolder    = arr.RootFolder
+	CommandStatus = arr.CommandStatus
+	SystemStatus  = arr.SystemStatus
+)
+
+// ─── Author Methods ─────────────────────────────────────────────────
+
+// GetAllAuthors retourne tous les auteurs de la bibliothèque Readarr.
+func (c *Client) GetAllAuthors() ([]Author, error) {
+	var authors []Author
+	if err := c.Get("/api/v1/author", "authors", &authors); err != nil {
+		return nil, err
+	}
+	return authors, nil
+}
+
+// RescanAuthor force un rescan des fichiers d'un auteur.
+// Retourne l'ID de la commande, à passer à WaitForCommand.
+func (c *Client) RescanAuthor(authorID int) (int, error) {
+	return c.StartCommand(Command{
+		Name:     "RefreshAuthor",
+		AuthorID: authorID,
+	})
+}
+
+// RescanAll force un rescan complet des dossiers racine.
+// Retourne l'ID de la commande, à passer à WaitForCommand.
+func (c *Client) RescanAll() (int, error) {
+	return c.StartCommand(Command{
+		Name: "RescanFolders",
+	})
+}
+
+// ─── Book Methods ──────────────────────────────────────────────────
+
+// GetAllBooks retourne tous les livres, auteur inclus.
+func (c *Client) GetAllBooks() ([]Book, error) {
+	var books []Book
+	if err := c.Get("/api/v1/book", "books", &books); err != nil {
+		return nil, err
+	}
+	return books, nil
+}
+
+// GetBook retourne un livre par son ID.
+func (c *Client) GetBook(bookID int) (*Book, error) {
+	var book Book
+	if err := c.Get(fmt.Sprintf("/api/v1/book/%d", bookID), "book", &book); err != nil {
+		return nil, err
+	}
+	return &book, nil
+}
+
+// GetMissingBooks retourne les livres sans aucun fichier sur le disque.
+func (c *Client) GetMissingBooks() ([]Book, error) {
+	books, err := c.GetAllBooks()
+	if err != nil {
+		return nil, err
+	}
+
+	var missing []Book
+	for _, a := range books {
+		if a.Statistics.BookFileCount == 0 {
+			missing = append(missing, a)
+		}
+	}
+	return missing, nil
+}
+
+// UnmonitorBooks désactive le monitoring d'une liste de livres
+// en une seule requête. L'auteur reste monitoré.
+func (c *Client) UnmonitorBooks(bookIDs []int) error {
+	if len(bookIDs) == 0 {
+		return nil
+	}
+	return c.Put("/api/v1/book/monitor", BooksMonitor{
+		BookIDs:   bookIDs,
+		Monitored: false,
+	})
+}
+
+// DeleteBook supprime un livre de Readarr (et optionnellement ses fichiers).
+func (c *Client) DeleteBook(bookID int, deleteFiles bool) error {
+	return c.Delete(fmt.Sprintf("/api/v1/book/%d?deleteFiles=%v&addImportListExclusion=false", bookID, deleteFiles))
+}
@@ -0,0 +1,73 @@
+package readarr
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// bookServer sert une liste de livres et capture le corps du PUT
+// /api/v1/book/monitor.
+func bookServer(t *testing.T, books string, monitor *BooksMonitor) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Api-Key") != "key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v1/book":
+			fmt.Fprint(w, books)
+		case r.Method == http.MethodPut && r.URL.Path == "/api/v1/book/monitor":
+			if err := json.NewDecoder(r.Body).Decode(monitor); err != nil {
+				t.Errorf("decode monitor body: %v", err)
+			}
+			w.WriteHeader(http.StatusAccepted)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestGetMissingBooks(t *testing.T) {
+	books := `[
+		{"id": 1, "title": "Dune", "authorId": 3, "monitored": true, "statistics": {"bookFileCount": 12}},
+		{"id": 2, "title": "Dune Messiah", "authorId": 3, "monitored": true, "statistics": {"bookFileCount": 0}},
+		{"id": 3, "title": "Children of Dune", "authorId": 3, "monitored": false}
+	]`
+	c := New(bookServer(t, books, nil).URL, "key")
+
+	missing, err := c.GetMissingBooks()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(missing) != 2 || missing[0].ID != 2 || missing[1].ID != 3 {
+		t.Errorf("got %+v, want books 2 and 3", missing)
+	}
+}
+
+func TestUnmonitorBooks(t *testing.T) {
+	var monitor BooksMonitor
+	c := New(bookServer(t, "[]", &monitor).URL, "key")
+
+	if err := c.UnmonitorBooks([]int{2, 3}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if monitor.Monitored || len(monitor.BookIDs) != 2 || monitor.BookIDs[0] != 2 || monitor.BookIDs[1] != 3 {
+		t.Errorf("got %+v, want books [2 3] unmonitored", monitor)
+	}
+}
+
+func TestAPIError(t *testing.T) {
+	c := New(bookServer(t, "[]", nil).URL, "wrong")
+
+	_, err := c.GetAllBooks()
+	apiErr, ok := err.(*APIError)
+	if !ok || apiErr.StatusCode != http.StatusUnauthorized || apiErr.Temporary() {
+		t.Errorf("got %v, want permanent 401 APIError", err)
+	}
+}
@@ -0,0 +1,245 @@
+package sabnzbd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/cleeryy/clarr/internal/download"
+)
+
+type Client struct {
+	baseURL    string
+	apiKey     string
+	httpClient *http.Client
+}
+
+// New crée le client de l'API SABnzbd (ex. http://sabnzbd:8080).
+func New(baseURL, apiKey string) *Client {
+	return &Client{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		apiKey:  apiKey,
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
+
+// SetTransport remplace le transport HTTP du client, par exemple pour
+// mesurer les requêtes.
+func (c *Client) SetTransport(rt http.RoundTripper) {
+	c.httpClient.Transport = rt
+}
+
+// ─── Models ───────────────────────────────────────────────────────────
+
+// HistorySlot est un téléchargement terminé de l'historique SABnzbd.
+type HistorySlot struct {
+	NzoID    string `json:"nzo_id"`
+	Name     string `json:"name"`
+	Category string `json:"category"`
+	Status   string `json:"status"`  // Completed | Failed | ...
+	Storage  string `json:"storage"` // dossier (ou fichier) final
+	Bytes    int64  `json:"bytes"`
+}
+
+type historyResponse struct {
+	History struct {
+		Slots []HistorySlot `json:"slots"`
+	} `json:"history"`
+}
+
+// statusResponse est la réponse des actions (delete, pause...).
+type statusResponse struct {
+	Status bool   `json:"status"`
+	Error  string `json:"error"`
+}
+
+// ─── Errors ───────────────────────────────────────────────────────────
+
+// APIError est retourné quand SABnzbd répond avec un statut >= 400.
+type APIError struct {
+	StatusCode int
+	Mode       string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("sabnzbd: unexpected status %d on mode=%s", e.StatusCode, e.Mode)
+}
+
+// Temporary indique si la requête peut être réessayée plus tard.
+func (e *APIError) Temporary() bool {
+	return e.StatusCode >= 500 || e.StatusCode == http.StatusTooManyRequests
+}
+
+// ─── HTTP Helper ──────────────────────────────────────────────────────
+
+// get appelle /api avec le mode et les paramètres donnés, et décode la
+// réponse JSON dans out. SABnzbd répond 200 même en erreur : le champ
+// "error" est vérifié quand il est présent.
+func (c *Client) get(mode string, params url.Values, out any) error {
+	if params == nil {
+		params = url.Values{}
+	}
+	params.Set("mode", mode)
+	params.Set("output", "json")
+	params.Set("apikey", c.apiKey)
+
+	resp, err := c.httpClient.Get(c.baseURL + "/api?" + params.Encode())
+	if err != nil {
+		return fmt.Errorf("sabnzbd: request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return &APIError{StatusCode: resp.StatusCode, Mode: mode}
+	}
+
+	var raw json.RawMessage
+	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+		return fmt.Errorf("sabnzbd: decode %s: %w", mode, err)
+	}
+
+	var status statusResponse
+	if err := json.Unmarshal(raw, &status); err == nil && status.Error != "" {
+		return fmt.Errorf("sabnzbd: %s: %s", mode, status.Error)
+	}
+
+	if err := json.Unmarshal(raw, out); err != nil {
+		return fmt.Errorf("sabnzbd: decode %s: %w", mode, err)
+	}
+	return nil
+}
+
+// action appelle une action mode/name sur un nzo_id.
+func (c *Client) action(mode, name, id string, params url.Values) error {
+	if params == nil {
+		params = url.Values{}
+	}
+	params.Set("name", name)
+	params.Set("value", id)
+
+	var status statusResponse
+	if err := c.get(mode, params, &status); err != nil {
+		return err
+	}
+	if !status.Status {
+		return fmt.Errorf("sabnzbd: %s %s on %s refused", mode, name, id)
+	}
+	return nil
+}
+
+// ─── Methods ──────────────────────────────────────────────────────────
+
+// Version retourne la version de SABnzbd. Elle passe par la file, vide,
+// plutôt que par mode=version qui n'exige pas la clé d'API.
+func (c *Client) Version() (string, error) {
+	var out struct {
+		Queue struct {
+			Version string `json:"version"`
+		} `json:"queue"`
+	}
+	if err := c.get("queue", url.Values{"limit": {"0"}}, &out); err != nil {
+		return "", err
+	}
+	return out.Queue.Version, nil
+}
+
+// GetHistory retourne les téléchargements de l'historique.
+func (c *Client) GetHistory() ([]HistorySlot, error) {
+	var history historyResponse
+	if err := c.get("history", nil, &history); err != nil {
+		return nil, err
+	}
+	return history.History.Slots, nil
+}
+
+// MatchPaths retourne les entrées de l'historique contenant au moins un
+// des paths. SABnzbd ne liste pas les fichiers d'un téléchargement : ils
+// sont relevés sur le disque, sous le dossier final de l'entrée.
+func (c *Client) MatchPaths(paths []string) ([]download.Item, error) {
+	wanted := download.PathSet(paths)
+
+	slots, err := c.GetHistory()
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []download.Item
+	for _, s := range slots {
+		if !download.ContainsAny(s.Storage, paths) {
+			continue
+		}
+
+		files, err := listFiles(s.Storage)
+		if err != nil {
+			return nil, err
+		}
+
+		item := s.Item()
+		item.Files = files
+		if download.AnyIn(item.Files, wanted) {
+			matches = append(matches, item)
+		}
+	}
+	return matches, nil
+}
+
+// Delete retire une entrée de l'historique par son nzo_id.
+// deleteFiles = true supprime aussi les fichiers du disque.
+func (c *Client) Delete(nzoID string, deleteFiles bool) error {
+	delFiles := "0"
+	if deleteFiles {
+		delFiles = "1"
+	}
+	return c.action("history", "delete", nzoID, url.Values{"del_files": {delFiles}})
+}
+
+// Pause met en pause un téléchargement de la file par son nzo_id.
+func (c *Client) Pause(nzoID string) error {
+	return c.action("queue", "pause", nzoID, nil)
+}
+
+// Item convertit l'entrée, sans ses fichiers, pour le cleaner.
+// L'usenet n'a ni ratio ni tracker : seules les règles de catégorie
+// peuvent s'appliquer. Une entrée encore en post-traitement (Verifying,
+// Extracting, Moving...) est incomplète.
+func (s HistorySlot) Item() download.Item {
+	return download.Item{
+		ID:         s.NzoID,
+		Name:       s.Name,
+		Category:   s.Category,
+		Incomplete: s.Status != "Completed" && s.Status != "Failed",
+	}
+}
+
+// ─── Helpers ──────────────────────────────────────────────────────────
+
+// listFiles retourne les fichiers sous root, ou root lui-même si c'est un
+// fichier. Un dossier déjà supprimé ne contient aucun fichier.
+func listFiles(root string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(filepath.Clean(root), func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("sabnzbd: list files of %s: %w", root, err)
+	}
+	return files, nil
+}
@@ -0,0 +1,90 @@
+package sabnzbd
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// fakeServer simule l'API SABnzbd et enregistre les actions reçues.
+func fakeServer(t *testing.T, slots []HistorySlot, actions *[]url.Values) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("apikey") != "key" {
+			_, _ = w.Write([]byte(`{"status":false,"error":"API Key Incorrect"}`))
+			return
+		}
+
+		switch {
+		case q.Get("mode") == "history" && q.Get("name") == "":
+			_ = json.NewEncoder(w).Encode(map[string]any{"history": map[string]any{"slots": slots}})
+		default:
+			*actions = append(*actions, q)
+			_, _ = w.Write([]byte(`{"status":true,"nzo_ids":["` + q.Get("value") + `"]}`))
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestMatchPaths(t *testing.T) {
+	dir := t.TempDir()
+	release := filepath.Join(dir, "Movie.2021")
+	if err := os.MkdirAll(release, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Movie.2021.mkv", "Movie.2021.nfo"} {
+		if err := os.WriteFile(filepath.Join(release, name), []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	slots := []HistorySlot{
+		{NzoID: "SABnzbd_nzo_1", Name: "Movie.2021", Category: "movies", Status: "Completed", Storage: release},
+		{NzoID: "SABnzbd_nzo_2", Name: "Gone", Category: "tv", Status: "Completed", Storage: filepath.Join(dir, "Gone")},
+	}
+	var actions []url.Values
+	c := New(fakeServer(t, slots, &actions).URL, "key")
+
+	items, err := c.MatchPaths([]string{filepath.Join(release, "Movie.2021.mkv")})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(items) != 1 {
+		t.Fatalf("got %d items, want 1", len(items))
+	}
+	if items[0].ID != "SABnzbd_nzo_1" || items[0].Category != "movies" || len(items[0].Files) != 2 {
+		t.Errorf("unexpected item %+v", items[0])
+	}
+}
+
+func TestDelete(t *testing.T) {
+	var actions []url.Values
+	c := New(fakeServer(t, nil, &actions).URL, "key")
+
+	if err := c.Delete("SABnzbd_nzo_1", false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(actions) != 1 {
+		t.Fatalf("server saw %d actions, want 1", len(actions))
+	}
+	a := actions[0]
+	if a.Get("mode") != "history" || a.Get("name") != "delete" || a.Get("value") != "SABnzbd_nzo_1" || a.Get("del_files") != "0" {
+		t.Errorf("unexpected delete call %v", a)
+	}
+}
+
+func TestAPIKeyError(t *testing.T) {
+	var actions []url.Values
+	c := New(fakeServer(t, nil, &actions).URL, "wrong")
+
+	if _, err := c.GetHistory(); err == nil {
+		t.Error("expected an error for a wrong API key")
+	}
+}
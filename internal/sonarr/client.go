@@ -2,16 +2,19 @@ package sonarr
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"time"
 )
 
 type Client struct {
-	baseURL    string
-	apiKey     string
-	httpClient *http.Client
+	baseURL      string
+	apiKey       string
+	httpClient   *http.Client
+	pollInterval time.Duration
 }
 
 func New(baseURL, apiKey string) *Client {
@@ -21,21 +24,38 @@ func New(baseURL, apiKey string) *Client {
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
+		pollInterval: 2 * time.Second,
 	}
 }
 
+// SetTransport remplace le transport HTTP du client, par exemple pour
+// mesurer les requêtes.
+func (c *Client) SetTransport(rt http.RoundTripper) {
+	c.httpClient.Transport = rt
+}
+
 // ─── Models ──────────────────────────────────────────────────────────
 
 type Series struct {
-	ID         int    `json:"id"`
-	Title      string `json:"title"`
-	Monitored  bool   `json:"monitored"`
-	Path       string `json:"path"`
+	ID         int      `json:"id"`
+	Title      string   `json:"title"`
+	Year       int      `json:"year"`
+	Monitored  bool     `json:"monitored"`
+	Path       string   `json:"path"`
+	TvdbID     int      `json:"tvdbId"`
+	TmdbID     int      `json:"tmdbId"`
+	ImdbID     string   `json:"imdbId"`
+	Seasons    []Season `json:"seasons"`
 	Statistics struct {
 		EpisodeFileCount int `json:"episodeFileCount"`
 	} `json:"statistics"`
 }
 
+type Season struct {
+	SeasonNumber int  `json:"seasonNumber"`
+	Monitored    bool `json:"monitored"`
+}
+
 type Episode struct {
 	ID            int    `json:"id"`
 	SeriesID      int    `json:"seriesId"`
@@ -46,14 +66,79 @@ type Episode struct {
 	EpisodeNumber int    `json:"episodeNumber"`
 }
 
+type EpisodesMonitor struct {
+	EpisodeIDs []int `json:"episodeIds"`
+	Monitored  bool  `json:"monitored"`
+}
+
+// RootFolder est un dossier racine de la bibliothèque.
+type RootFolder struct {
+	ID   int    `json:"id"`
+	Path string `json:"path"`
+}
+
+// HistoryPage est une page de /api/v3/history.
+type HistoryPage struct {
+	Page         int             `json:"page"`
+	PageSize     int             `json:"pageSize"`
+	TotalRecords int             `json:"totalRecords"`
+	Records      []HistoryRecord `json:"records"`
+}
+
+type HistoryRecord struct {
+	EventType string `json:"eventType"` // "grabbed" | "downloadFolderImported" | ...
+	Data      struct {
+		DroppedPath  string `json:"droppedPath"`  // fichier importé, dans le dossier de téléchargement
+		ImportedPath string `json:"importedPath"` // copie ou lien en bibliothèque
+	} `json:"data"`
+}
+
+// CommandStatus est l'état d'une commande tel que retourné par /api/v3/command.
+type CommandStatus struct {
+	ID      int    `json:"id"`
+	Name    string `json:"name"`
+	Status  string `json:"status"` // queued | started | completed | failed | aborted | cancelled | orphaned
+	Message string `json:"message"`
+}
+
 type Command struct {
 	Name     string `json:"name"`
 	SeriesID int    `json:"seriesId,omitempty"`
 }
 
-// ─── HTTP Helper ──────────────────────────────────────────────────────
+// SystemStatus est la réponse de /api/v3/system/status.
+type SystemStatus struct {
+	AppName string `json:"appName"`
+	Version string `json:"version"`
+}
+
+// ─── Errors ──────────────────────────────────────────────────────────
+
+// APIError est retourné quand Sonarr répond avec un statut >= 400.
+type APIError struct {
+	StatusCode int
+	Method     string
+	Endpoint   string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("sonarr: unexpected status %d on %s %s", e.StatusCode, e.Method, e.Endpoint)
+}
+
+// Temporary indique si la requête peut être réessayée plus tard
+// (erreur serveur ou rate limit), par opposition à une requête invalide.
+func (e *APIError) Temporary() bool {
+	return e.StatusCode >= 500 || e.StatusCode == http.StatusTooManyRequests
+}
+
+// ─── HTTP Helper ─────────────────────────────────────────────────────
 
 func (c *Client) do(method, endpoint string, body any) (*http.Response, error) {
+	return c.doContext(context.Background(), method, endpoint, body)
+}
+
+// doContext envoie la requête ; annuler ctx l'interrompt.
+func (c *Client) doContext(ctx context.Context, method, endpoint string, body any) (*http.Response, error) {
 	var buf bytes.Buffer
 	if body != nil {
 		if err := json.NewEncoder(&buf).Encode(body); err != nil {
@@ -61,7 +146,7 @@ func (c *Client) do(method, endpoint string, body any) (*http.Response, error) {
 		}
 	}
 
-	req, err := http.NewRequest(method, c.baseURL+endpoint, &buf)
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+endpoint, &buf)
 	if err != nil {
 		return nil, fmt.Errorf("sonarr: build request: %w", err)
 	}
@@ -75,13 +160,14 @@ func (c *Client) do(method, endpoint string, body any) (*http.Response, error) {
 	}
 
 	if resp.StatusCode >= 400 {
-		return nil, fmt.Errorf("sonarr: unexpected status %d on %s %s", resp.StatusCode, method, endpoint)
+		resp.Body.Close()
+		return nil, &APIError{StatusCode: resp.StatusCode, Method: method, Endpoint: endpoint}
 	}
 
 	return resp, nil
 }
 
-// ─── Series Methods ───────────────────────────────────────────────────
+// ─── Series Methods ──────────────────────────────────────────────────
 
 // GetAllSeries retourne toutes les séries.
 func (c *Client) GetAllSeries() ([]Series, error) {
@@ -98,6 +184,21 @@ func (c *Client) GetAllSeries() ([]Series, error) {
 	return series, nil
 }
 
+// GetSeries retourne une série par son ID.
+func (c *Client) GetSeries(seriesID int) (*Series, error) {
+	resp, err := c.do(http.MethodGet, fmt.Sprintf("/api/v3/series/%d", seriesID), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var series Series
+	if err := json.NewDecoder(resp.Body).Decode(&series); err != nil {
+		return nil, fmt.Errorf("sonarr: decode series: %w", err)
+	}
+	return &series, nil
+}
+
 // GetEmptySeries retourne les séries sans aucun fichier sur le disque.
 func (c *Client) GetEmptySeries() ([]Series, error) {
 	series, err := c.GetAllSeries()
@@ -115,15 +216,58 @@ func (c *Client) GetEmptySeries() ([]Series, error) {
 }
 
 // UnmonitorSeries désactive le monitoring d'une série.
+// Seul le champ monitored est modifié, le reste du document est renvoyé tel quel.
 func (c *Client) UnmonitorSeries(seriesID int) error {
-	series, err := c.getSeriesByID(seriesID)
+	endpoint := fmt.Sprintf("/api/v3/series/%d", seriesID)
+
+	doc, err := c.getDocument(endpoint)
 	if err != nil {
 		return err
 	}
+	doc["monitored"] = json.RawMessage("false")
 
-	series.Monitored = false
-	_, err = c.do(http.MethodPut, fmt.Sprintf("/api/v3/series/%d", seriesID), series)
-	return err
+	return c.putDocument(endpoint, doc)
+}
+
+// UnmonitorSeason désactive le monitoring d'une saison. Sonarr propage
+// le changement aux épisodes de la saison ; la série reste monitorée.
+func (c *Client) UnmonitorSeason(seriesID, seasonNumber int) error {
+	endpoint := fmt.Sprintf("/api/v3/series/%d", seriesID)
+
+	doc, err := c.getDocument(endpoint)
+	if err != nil {
+		return err
+	}
+
+	// Chaque saison est gardée en document brut : ses statistiques et
+	// autres champs ne doivent pas disparaître.
+	var seasons []map[string]json.RawMessage
+	if err := json.Unmarshal(doc["seasons"], &seasons); err != nil {
+		return fmt.Errorf("sonarr: decode seasons: %w", err)
+	}
+
+	found := false
+	for _, season := range seasons {
+		var number int
+		if err := json.Unmarshal(season["seasonNumber"], &number); err != nil {
+			return fmt.Errorf("sonarr: decode season number: %w", err)
+		}
+		if number == seasonNumber {
+			season["monitored"] = json.RawMessage("false")
+			found = true
+		}
+	}
+	if !found {
+		return fmt.Errorf("sonarr: season %d not found in series %d", seasonNumber, seriesID)
+	}
+
+	raw, err := json.Marshal(seasons)
+	if err != nil {
+		return fmt.Errorf("sonarr: encode seasons: %w", err)
+	}
+	doc["seasons"] = raw
+
+	return c.putDocument(endpoint, doc)
 }
 
 // DeleteSeries supprime une série de Sonarr.
@@ -134,26 +278,26 @@ func (c *Client) DeleteSeries(seriesID int, deleteFiles bool) error {
 }
 
 // RescanSeries force un rescan d'une série spécifique.
-func (c *Client) RescanSeries(seriesID int) error {
-	_, err := c.do(http.MethodPost, "/api/v3/command", Command{
+// Retourne l'ID de la commande, à passer à WaitForCommand.
+func (c *Client) RescanSeries(seriesID int) (int, error) {
+	return c.StartCommand(Command{
 		Name:     "RescanSeries",
 		SeriesID: seriesID,
 	})
-	return err
 }
 
 // RescanAll force un rescan complet de toutes les séries.
-func (c *Client) RescanAll() error {
-	_, err := c.do(http.MethodPost, "/api/v3/command", Command{
+// Retourne l'ID de la commande, à passer à WaitForCommand.
+func (c *Client) RescanAll() (int, error) {
+	return c.StartCommand(Command{
 		Name: "RescanSeries",
 	})
-	return err
 }
 
-// ─── Episode Methods ──────────────────────────────────────────────────
+// ─── Episode Methods ─────────────────────────────────────────────────
 
-// GetMissingEpisodes retourne les épisodes sans fichier d'une série.
-func (c *Client) GetMissingEpisodes(seriesID int) ([]Episode, error) {
+// GetEpisodes retourne tous les épisodes d'une série.
+func (c *Client) GetEpisodes(seriesID int) ([]Episode, error) {
 	resp, err := c.do(http.MethodGet, fmt.Sprintf("/api/v3/episode?seriesId=%d", seriesID), nil)
 	if err != nil {
 		return nil, err
@@ -164,6 +308,15 @@ func (c *Client) GetMissingEpisodes(seriesID int) ([]Episode, error) {
 	if err := json.NewDecoder(resp.Body).Decode(&episodes); err != nil {
 		return nil, fmt.Errorf("sonarr: decode episodes: %w", err)
 	}
+	return episodes, nil
+}
+
+// GetMissingEpisodes retourne les épisodes sans fichier d'une série.
+func (c *Client) GetMissingEpisodes(seriesID int) ([]Episode, error) {
+	episodes, err := c.GetEpisodes(seriesID)
+	if err != nil {
+		return nil, err
+	}
 
 	var missing []Episode
 	for _, e := range episodes {
@@ -174,18 +327,200 @@ func (c *Client) GetMissingEpisodes(seriesID int) ([]Episode, error) {
 	return missing, nil
 }
 
-// ─── Private ──────────────────────────────────────────────────────────
+// UnmonitorEpisodes désactive le monitoring d'une liste d'épisodes
+// en une seule requête.
+func (c *Client) UnmonitorEpisodes(episodeIDs []int) error {
+	if len(episodeIDs) == 0 {
+		return nil
+	}
 
-func (c *Client) getSeriesByID(seriesID int) (*Series, error) {
-	resp, err := c.do(http.MethodGet, fmt.Sprintf("/api/v3/series/%d", seriesID), nil)
+	resp, err := c.do(http.MethodPut, "/api/v3/episode/monitor", EpisodesMonitor{
+		EpisodeIDs: episodeIDs,
+		Monitored:  false,
+	})
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// GetRootFolders retourne les dossiers racine de la bibliothèque.
+func (c *Client) GetRootFolders() ([]RootFolder, error) {
+	resp, err := c.do(http.MethodGet, "/api/v3/rootfolder", nil)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
-	var series Series
-	if err := json.NewDecoder(resp.Body).Decode(&series); err != nil {
-		return nil, fmt.Errorf("sonarr: decode series: %w", err)
+	var folders []RootFolder
+	if err := json.NewDecoder(resp.Body).Decode(&folders); err != nil {
+		return nil, fmt.Errorf("sonarr: decode root folders: %w", err)
 	}
-	return &series, nil
+	return folders, nil
+}
+
+// ─── History Methods ─────────────────────────────────────────────────
+
+// historyPageSize est le nombre d'entrées d'historique lues par requête.
+const historyPageSize = 1000
+
+// ImportedFiles retourne, pour chaque fichier importé depuis le dossier
+// de téléchargement, le chemin de son import en bibliothèque. Un fichier
+// importé plusieurs fois garde son import le plus récent.
+func (c *Client) ImportedFiles() (map[string]string, error) {
+	imports := make(map[string]string)
+	for page := 1; ; page++ {
+		// eventType=3 : downloadFolderImported. L'historique est trié du plus
+		// récent au plus ancien, le premier import vu est donc le dernier.
+		endpoint := fmt.Sprintf("/api/v3/history?page=%d&pageSize=%d&eventType=3&sortKey=date&sortDirection=descending", page, historyPageSize)
+		resp, err := c.do(http.MethodGet, endpoint, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		var history HistoryPage
+		err = json.NewDecoder(resp.Body).Decode(&history)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("sonarr: decode history: %w", err)
+		}
+
+		for _, r := range history.Records {
+			if r.EventType != "downloadFolderImported" || r.Data.DroppedPath == "" {
+				continue
+			}
+			if _, ok := imports[r.Data.DroppedPath]; !ok {
+				imports[r.Data.DroppedPath] = r.Data.ImportedPath
+			}
+		}
+
+		if len(history.Records) == 0 || page*historyPageSize >= history.TotalRecords {
+			return imports, nil
+		}
+	}
+}
+
+// ─── System Methods ──────────────────────────────────────────────────
+
+// Version retourne la version de Sonarr, lue dans le statut système, ce qui
+// vérifie au passage l'URL et la clé d'API.
+func (c *Client) Version() (string, error) {
+	resp, err := c.do(http.MethodGet, "/api/v3/system/status", nil)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var status SystemStatus
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		return "", fmt.Errorf("sonarr: decode system status: %w", err)
+	}
+	return status.Version, nil
+}
+
+// ─── Command Methods ─────────────────────────────────────────────────
+
+// StartCommand envoie une commande et retourne son ID sans attendre
+// qu'elle soit exécutée.
+func (c *Client) StartCommand(cmd Command) (int, error) {
+	resp, err := c.do(http.MethodPost, "/api/v3/command", cmd)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	var status CommandStatus
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		return 0, fmt.Errorf("sonarr: decode command: %w", err)
+	}
+	return status.ID, nil
+}
+
+// GetCommand retourne l'état courant d'une commande.
+func (c *Client) GetCommand(commandID int) (*CommandStatus, error) {
+	return c.getCommand(context.Background(), commandID)
+}
+
+func (c *Client) getCommand(ctx context.Context, commandID int) (*CommandStatus, error) {
+	resp, err := c.doContext(ctx, http.MethodGet, fmt.Sprintf("/api/v3/command/%d", commandID), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var status CommandStatus
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		return nil, fmt.Errorf("sonarr: decode command: %w", err)
+	}
+	return &status, nil
+}
+
+// WaitForCommand interroge la commande jusqu'à ce qu'elle soit terminée.
+// Une interrogation en échec (réseau, 5xx) est retentée au tick suivant ;
+// seule une requête refusée (4xx) arrête l'attente. Retourne une erreur si
+// la commande échoue, est annulée, ou si ctx expire avant, y compris
+// pendant une requête.
+func (c *Client) WaitForCommand(ctx context.Context, commandID int) (*CommandStatus, error) {
+	ticker := time.NewTicker(c.pollInterval)
+	defer ticker.Stop()
+
+	var last *CommandStatus
+	var pollErr error
+	for {
+		status, err := c.getCommand(ctx, commandID)
+		var apiErr *APIError
+		switch {
+		case err == nil:
+			last, pollErr = status, nil
+			switch status.Status {
+			case "completed":
+				return status, nil
+			case "failed", "aborted", "cancelled", "orphaned":
+				return status, fmt.Errorf("sonarr: command %d (%s) %s: %s", commandID, status.Name, status.Status, status.Message)
+			}
+		case errors.As(err, &apiErr) && !apiErr.Temporary():
+			return last, err
+		default:
+			pollErr = err
+		}
+
+		select {
+		case <-ctx.Done():
+			if pollErr != nil {
+				return last, fmt.Errorf("sonarr: wait for command %d: %w (last poll: %v)", commandID, ctx.Err(), pollErr)
+			}
+			return last, fmt.Errorf("sonarr: wait for command %d (%s): %w", commandID, last.Name, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// ─── Private ─────────────────────────────────────────────────────────
+
+// getDocument retourne le document JSON complet d'une ressource, champ par
+// champ, sans passer par les structs partielles qui perdraient les champs
+// non modélisés (profil qualité, tags, saisons...).
+func (c *Client) getDocument(endpoint string) (map[string]json.RawMessage, error) {
+	resp, err := c.do(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var doc map[string]json.RawMessage
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("sonarr: decode %s: %w", endpoint, err)
+	}
+	return doc, nil
+}
+
+// putDocument renvoie un document complet via PUT.
+func (c *Client) putDocument(endpoint string, doc map[string]json.RawMessage) error {
+	resp, err := c.do(http.MethodPut, endpoint, doc)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
 }
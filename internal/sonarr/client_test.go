@@ -0,0 +1,275 @@
+package sonarr
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// fixtureServer sert le document enregistré sur GET et capture le corps
+// du PUT renvoyé par le client.
+func fixtureServer(t *testing.T, fixture []byte, put *map[string]any) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			_, _ = w.Write(fixture)
+		case http.MethodPut:
+			body, _ := io.ReadAll(r.Body)
+			if err := json.Unmarshal(body, put); err != nil {
+				t.Errorf("decode PUT body: %v", err)
+			}
+			_, _ = w.Write(body)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func loadSeriesFixture(t *testing.T) ([]byte, map[string]any) {
+	t.Helper()
+	fixture, err := os.ReadFile("testdata/series.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(fixture, &doc); err != nil {
+		t.Fatal(err)
+	}
+	return fixture, doc
+}
+
+func assertSameDocument(t *testing.T, got, want map[string]any) {
+	t.Helper()
+	if reflect.DeepEqual(got, want) {
+		return
+	}
+	for k, v := range want {
+		if !reflect.DeepEqual(got[k], v) {
+			t.Errorf("field %q: got %v, want %v", k, got[k], v)
+		}
+	}
+	t.Fatal("PUT body does not match expected document")
+}
+
+func TestUnmonitorSeries_PreservesUnknownFields(t *testing.T) {
+	fixture, want := loadSeriesFixture(t)
+
+	var put map[string]any
+	srv := fixtureServer(t, fixture, &put)
+
+	if err := New(srv.URL, "key").UnmonitorSeries(12); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want["monitored"] = false
+	assertSameDocument(t, put, want)
+}
+
+func TestUnmonitorSeason_PreservesOtherSeasons(t *testing.T) {
+	fixture, want := loadSeriesFixture(t)
+
+	var put map[string]any
+	srv := fixtureServer(t, fixture, &put)
+
+	if err := New(srv.URL, "key").UnmonitorSeason(12, 1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Seule la saison 1 change ; la série et la saison 2 restent monitorées.
+	want["seasons"].([]any)[1].(map[string]any)["monitored"] = false
+	assertSameDocument(t, put, want)
+}
+
+func TestUnmonitorSeason_UnknownSeason(t *testing.T) {
+	fixture, _ := loadSeriesFixture(t)
+
+	var put map[string]any
+	srv := fixtureServer(t, fixture, &put)
+
+	if err := New(srv.URL, "key").UnmonitorSeason(12, 9); err == nil {
+		t.Fatal("expected error for unknown season")
+	}
+	if put != nil {
+		t.Error("no PUT expected for unknown season")
+	}
+}
+
+// closeTracker enregistre, pour chaque réponse, si son body a été fermé.
+type closeTracker struct {
+	bodies []*trackedBody
+}
+
+type trackedBody struct {
+	io.ReadCloser
+	closed bool
+}
+
+func (b *trackedBody) Close() error {
+	b.closed = true
+	return b.ReadCloser.Close()
+}
+
+func (ct *closeTracker) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := http.DefaultTransport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body := &trackedBody{ReadCloser: resp.Body}
+	ct.bodies = append(ct.bodies, body)
+	resp.Body = body
+	return resp, nil
+}
+
+func TestUnmonitorEpisodes(t *testing.T) {
+	var got EpisodesMonitor
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut || r.URL.Path != "/api/v3/episode/monitor" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decode body: %v", err)
+		}
+		w.WriteHeader(http.StatusAccepted)
+		fmt.Fprint(w, `[{"id": 101, "monitored": false}, {"id": 103, "monitored": false}]`)
+	}))
+	t.Cleanup(srv.Close)
+
+	transport := &closeTracker{}
+	c := New(srv.URL, "key")
+	c.SetTransport(transport)
+
+	if err := c.UnmonitorEpisodes([]int{101, 103}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Monitored || !reflect.DeepEqual(got.EpisodeIDs, []int{101, 103}) {
+		t.Errorf("request = %+v, want episodes [101 103] unmonitored", got)
+	}
+	if len(transport.bodies) != 1 || !transport.bodies[0].closed {
+		t.Error("response body left open, the connection leaks")
+	}
+}
+
+// commandServer renvoie successivement les états donnés pour la commande 7.
+// "503" et "404" répondent ce statut, "hang" ne répond qu'à l'annulation
+// de la requête.
+func commandServer(t *testing.T, states ...string) *httptest.Server {
+	t.Helper()
+	polls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/api/v3/command":
+			fmt.Fprint(w, `{"id": 7, "name": "RescanSeries", "status": "queued"}`)
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v3/command/7":
+			state := states[min(polls, len(states)-1)]
+			polls++
+			switch state {
+			case "503":
+				w.WriteHeader(http.StatusServiceUnavailable)
+			case "404":
+				w.WriteHeader(http.StatusNotFound)
+			case "hang":
+				<-r.Context().Done()
+			default:
+				fmt.Fprintf(w, `{"id": 7, "name": "RescanSeries", "status": %q, "message": "boom"}`, state)
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestWaitForCommand(t *testing.T) {
+	tests := []struct {
+		name    string
+		states  []string
+		timeout time.Duration
+		wantErr bool
+	}{
+		{"completed", []string{"queued", "started", "completed"}, time.Second, false},
+		{"failed", []string{"started", "failed"}, time.Second, true},
+		{"timeout", []string{"started"}, 50 * time.Millisecond, true},
+		{"transient poll errors are retried", []string{"started", "503", "503", "completed"}, time.Second, false},
+		{"unknown command stops the wait", []string{"started", "404", "completed"}, time.Second, true},
+		{"stuck request is cancelled with ctx", []string{"hang"}, 50 * time.Millisecond, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := New(commandServer(t, tt.states...).URL, "key")
+			c.pollInterval = 5 * time.Millisecond
+
+			id, err := c.RescanAll()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if id != 7 {
+				t.Fatalf("expected command id 7, got %d", id)
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), tt.timeout)
+			defer cancel()
+
+			start := time.Now()
+			_, err = c.WaitForCommand(ctx, id)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("WaitForCommand error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if elapsed := time.Since(start); elapsed > tt.timeout+time.Second {
+				t.Errorf("WaitForCommand returned after %s, ctx was not honoured", elapsed)
+			}
+		})
+	}
+}
+
+func TestImportedFiles_PagesHistory(t *testing.T) {
+	pages := map[string]string{
+		"1": `{"page": 1, "totalRecords": 1500, "records": [
+			{"eventType": "downloadFolderImported", "data": {"droppedPath": "/downloads/tv/Andor.S01E01.mkv", "importedPath": "/tv/Andor/Season 1/Andor - S01E01.mkv"}},
+			{"eventType": "downloadFolderImported", "data": {"droppedPath": "/downloads/tv/Andor.S01E01.mkv", "importedPath": "/tv/Andor/Season 1/Andor - S01E01.old.mkv"}}
+		]}`,
+		"2": `{"page": 2, "totalRecords": 1500, "records": [
+			{"eventType": "downloadFolderImported", "data": {"droppedPath": "/downloads/tv/Andor.S01E02.mkv", "importedPath": "/tv/Andor/Season 1/Andor - S01E02.mkv"}},
+			{"eventType": "grabbed", "data": {}}
+		]}`,
+	}
+	var requested []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page := r.URL.Query().Get("page")
+		requested = append(requested, page)
+		if r.URL.Path != "/api/v3/history" || r.URL.Query().Get("eventType") != "3" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprint(w, pages[page])
+	}))
+	t.Cleanup(srv.Close)
+
+	got, err := New(srv.URL, "key").ImportedFiles()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := map[string]string{
+		"/downloads/tv/Andor.S01E01.mkv": "/tv/Andor/Season 1/Andor - S01E01.mkv",
+		"/downloads/tv/Andor.S01E02.mkv": "/tv/Andor/Season 1/Andor - S01E02.mkv",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ImportedFiles() = %v, want %v", got, want)
+	}
+	if !reflect.DeepEqual(requested, []string{"1", "2"}) {
+		t.Errorf("requested pages %v, want [1 2]", requested)
+	}
+}
@@ -0,0 +1,262 @@
+package transmission
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cleeryy/clarr/internal/download"
+)
+
+// sessionHeader porte le jeton anti-CSRF exigé par l'API RPC.
+const sessionHeader = "X-Transmission-Session-Id"
+
+type Client struct {
+	baseURL    string
+	username   string
+	password   string
+	httpClient *http.Client
+
+	mu        sync.Mutex
+	sessionID string
+}
+
+// New crée le client. baseURL pointe sur l'interface web de Transmission
+// (ex. http://transmission:9091) ; username et password sont optionnels.
+func New(baseURL, username, password string) *Client {
+	return &Client{
+		baseURL:  strings.TrimSuffix(baseURL, "/"),
+		username: username,
+		password: password,
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
+
+// SetTransport remplace le transport HTTP du client, par exemple pour
+// mesurer les requêtes.
+func (c *Client) SetTransport(rt http.RoundTripper) {
+	c.httpClient.Transport = rt
+}
+
+// ─── Models ───────────────────────────────────────────────────────────
+
+type Torrent struct {
+	ID             int       `json:"id"`
+	HashString     string    `json:"hashString"`
+	Name           string    `json:"name"`
+	DownloadDir    string    `json:"downloadDir"`
+	UploadRatio    float64   `json:"uploadRatio"`
+	SecondsSeeding int64     `json:"secondsSeeding"`
+	LeftUntilDone  int64     `json:"leftUntilDone"`
+	Labels         []string  `json:"labels"`
+	Trackers       []Tracker `json:"trackers"`
+	Files          []File    `json:"files"`
+}
+
+type Tracker struct {
+	Announce string `json:"announce"`
+}
+
+type File struct {
+	Name   string `json:"name"` // relatif au downloadDir du torrent
+	Length int64  `json:"length"`
+}
+
+// torrentFields sont les champs demandés à torrent-get.
+var torrentFields = []string{
+	"id", "hashString", "name", "downloadDir", "uploadRatio",
+	"secondsSeeding", "leftUntilDone", "labels", "trackers", "files",
+}
+
+type request struct {
+	Method    string `json:"method"`
+	Arguments any    `json:"arguments,omitempty"`
+}
+
+type response struct {
+	Result    string          `json:"result"`
+	Arguments json.RawMessage `json:"arguments"`
+}
+
+// ─── Errors ───────────────────────────────────────────────────────────
+
+// APIError est retourné quand Transmission répond avec un statut >= 400.
+type APIError struct {
+	StatusCode int
+	Method     string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("transmission: unexpected status %d on %s", e.StatusCode, e.Method)
+}
+
+// Temporary indique si la requête peut être réessayée plus tard.
+func (e *APIError) Temporary() bool {
+	return e.StatusCode >= 500 || e.StatusCode == http.StatusTooManyRequests
+}
+
+// RPCError est retourné quand la méthode RPC échoue (result != "success").
+type RPCError struct {
+	Method string
+	Result string
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("transmission: %s failed: %s", e.Method, e.Result)
+}
+
+// ─── RPC Helper ───────────────────────────────────────────────────────
+
+// call appelle une méthode RPC et décode ses arguments dans out.
+// Sur 409, Transmission fournit un nouveau session id : on le mémorise
+// et on rejoue la requête une fois.
+func (c *Client) call(method string, args, out any) error {
+	body, err := json.Marshal(request{Method: method, Arguments: args})
+	if err != nil {
+		return fmt.Errorf("transmission: encode %s: %w", method, err)
+	}
+
+	resp, err := c.send(body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode == http.StatusConflict {
+		resp.Body.Close()
+		c.mu.Lock()
+		c.sessionID = resp.Header.Get(sessionHeader)
+		c.mu.Unlock()
+		if resp, err = c.send(body); err != nil {
+			return err
+		}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return &APIError{StatusCode: resp.StatusCode, Method: method}
+	}
+
+	var r response
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return fmt.Errorf("transmission: decode %s: %w", method, err)
+	}
+	if r.Result != "success" {
+		return &RPCError{Method: method, Result: r.Result}
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(r.Arguments, out); err != nil {
+		return fmt.Errorf("transmission: decode %s arguments: %w", method, err)
+	}
+	return nil
+}
+
+func (c *Client) send(body []byte) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodPost, c.baseURL+"/transmission/rpc", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("transmission: build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.username != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+	c.mu.Lock()
+	if c.sessionID != "" {
+		req.Header.Set(sessionHeader, c.sessionID)
+	}
+	c.mu.Unlock()
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("transmission: request failed: %w", err)
+	}
+	return resp, nil
+}
+
+// ─── Methods ──────────────────────────────────────────────────────────
+
+// Version retourne la version de Transmission, ce qui vérifie au passage
+// l'URL et les identifiants.
+func (c *Client) Version() (string, error) {
+	var out struct {
+		Version string `json:"version"`
+	}
+	if err := c.call("session-get", map[string]any{"fields": []string{"version"}}, &out); err != nil {
+		return "", err
+	}
+	return out.Version, nil
+}
+
+// GetTorrents retourne tous les torrents avec leurs fichiers.
+func (c *Client) GetTorrents() ([]Torrent, error) {
+	var out struct {
+		Torrents []Torrent `json:"torrents"`
+	}
+	if err := c.call("torrent-get", map[string]any{"fields": torrentFields}, &out); err != nil {
+		return nil, err
+	}
+	return out.Torrents, nil
+}
+
+// MatchPaths retourne les torrents contenant au moins un des paths,
+// avec la liste complète de leurs fichiers.
+func (c *Client) MatchPaths(paths []string) ([]download.Item, error) {
+	wanted := download.PathSet(paths)
+
+	torrents, err := c.GetTorrents()
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []download.Item
+	for _, t := range torrents {
+		item := t.Item()
+		if download.AnyIn(item.Files, wanted) {
+			matches = append(matches, item)
+		}
+	}
+	return matches, nil
+}
+
+// Delete supprime un torrent par son hash.
+// deleteFiles = true supprime aussi les fichiers du disque.
+func (c *Client) Delete(hash string, deleteFiles bool) error {
+	return c.call("torrent-remove", map[string]any{
+		"ids":               []string{hash},
+		"delete-local-data": deleteFiles,
+	}, nil)
+}
+
+// Pause arrête un torrent par son hash.
+func (c *Client) Pause(hash string) error {
+	return c.call("torrent-stop", map[string]any{"ids": []string{hash}}, nil)
+}
+
+// Item convertit le torrent pour le cleaner. Le premier label sert de
+// catégorie, comme le font Radarr et Sonarr avec Transmission.
+func (t Torrent) Item() download.Item {
+	item := download.Item{
+		ID:          t.HashString,
+		Name:        t.Name,
+		Ratio:       t.UploadRatio,
+		SeedingTime: time.Duration(t.SecondsSeeding) * time.Second,
+		Incomplete:  t.LeftUntilDone > 0,
+	}
+	if len(t.Labels) > 0 {
+		item.Category = t.Labels[0]
+	}
+	if len(t.Trackers) > 0 {
+		item.Tracker = t.Trackers[0].Announce
+	}
+	for _, f := range t.Files {
+		item.Files = append(item.Files, filepath.Join(t.DownloadDir, f.Name))
+	}
+	return item
+}
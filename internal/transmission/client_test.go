@@ -0,0 +1,116 @@
+package transmission
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// fakeServer simule l'API RPC de Transmission, jeton de session compris,
+// et enregistre les requêtes torrent-remove reçues.
+func fakeServer(t *testing.T, torrents []Torrent, removed *[]map[string]any) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/transmission/rpc" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.Header.Get(sessionHeader) != "token" {
+			w.Header().Set(sessionHeader, "token")
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		if user, pass, _ := r.BasicAuth(); user != "admin" || pass != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		var req struct {
+			Method    string         `json:"method"`
+			Arguments map[string]any `json:"arguments"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decode rpc request: %v", err)
+		}
+
+		switch req.Method {
+		case "torrent-get":
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"result":    "success",
+				"arguments": map[string]any{"torrents": torrents},
+			})
+		case "torrent-remove":
+			*removed = append(*removed, req.Arguments)
+			_, _ = w.Write([]byte(`{"result":"success","arguments":{}}`))
+		default:
+			_, _ = w.Write([]byte(`{"result":"method name not recognized"}`))
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestMatchPaths(t *testing.T) {
+	torrents := []Torrent{
+		{
+			HashString: "movie", Name: "Movie.2021.mkv", DownloadDir: "/downloads/complete",
+			UploadRatio: 1.5, SecondsSeeding: 3600, Labels: []string{"radarr"},
+			Trackers: []Tracker{{Announce: "https://tracker.example.org/announce"}},
+			Files:    []File{{Name: "Movie.2021.mkv"}},
+		},
+		{
+			HashString: "pack", Name: "Show.S01", DownloadDir: "/downloads/complete",
+			Files: []File{{Name: "Show.S01/E01.mkv"}, {Name: "Show.S01/E02.mkv"}},
+		},
+	}
+	var removed []map[string]any
+	c := New(fakeServer(t, torrents, &removed).URL, "admin", "secret")
+
+	items, err := c.MatchPaths([]string{"/downloads//complete/Show.S01/E01.mkv", "/downloads/complete/Movie.2021.mkv"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(items) != 2 {
+		t.Fatalf("got %d items, want 2", len(items))
+	}
+
+	movie := items[0]
+	if movie.ID != "movie" || movie.Category != "radarr" || movie.Tracker != "https://tracker.example.org/announce" ||
+		movie.Ratio != 1.5 || movie.SeedingTime != time.Hour {
+		t.Errorf("unexpected movie item %+v", movie)
+	}
+	if len(items[1].Files) != 2 || items[1].Files[1] != "/downloads/complete/Show.S01/E02.mkv" {
+		t.Errorf("pack files = %v, want full file list", items[1].Files)
+	}
+}
+
+func TestDelete(t *testing.T) {
+	var removed []map[string]any
+	c := New(fakeServer(t, nil, &removed).URL, "admin", "secret")
+
+	if err := c.Delete("abc", false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(removed) != 1 {
+		t.Fatalf("server saw %d removes, want 1", len(removed))
+	}
+	if ids := removed[0]["ids"].([]any); len(ids) != 1 || ids[0] != "abc" {
+		t.Errorf("ids = %v, want [abc]", ids)
+	}
+	if removed[0]["delete-local-data"] != false {
+		t.Errorf("delete-local-data = %v, want false", removed[0]["delete-local-data"])
+	}
+}
+
+func TestRPCError(t *testing.T) {
+	var removed []map[string]any
+	c := New(fakeServer(t, nil, &removed).URL, "admin", "secret")
+
+	err := c.call("session-close", nil, nil)
+	if _, ok := err.(*RPCError); !ok {
+		t.Errorf("got %v, want *RPCError", err)
+	}
+}
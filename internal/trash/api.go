@@ -0,0 +1,99 @@
+package trash
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/cleeryy/clarr/internal/audit"
+	"github.com/gin-gonic/gin"
+)
+
+// Register expose la corbeille sur l'API.
+func (t *Trash) Register(r *gin.Engine) {
+	r.GET("/api/trash", func(c *gin.Context) {
+		entries := t.List()
+		c.JSON(http.StatusOK, gin.H{
+			"entries":   entries,
+			"count":     len(entries),
+			"size":      TotalSize(entries),
+			"retention": t.retention.String(),
+		})
+	})
+
+	// Restaure les entrées "ids" à leur emplacement d'origine.
+	r.POST("/api/trash/restore", func(c *gin.Context) {
+		var req struct {
+			IDs []string `json:"ids"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil || len(req.IDs) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "ids is required"})
+			return
+		}
+
+		ctx := audit.WithTrigger(c.Request.Context(), audit.TriggerAPI)
+		restored, errs := t.Restore(ctx, req.IDs)
+		c.JSON(statusFor(restored, errs), gin.H{"restored": orEmpty(restored), "errors": messages(errs)})
+	})
+
+	// Sans corps, purge les entrées expirées ; "ids" ou "all" purgent
+	// sans attendre la rétention.
+	r.POST("/api/trash/purge", func(c *gin.Context) {
+		var req struct {
+			IDs []string `json:"ids"`
+			All bool     `json:"all"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body: " + err.Error()})
+			return
+		}
+
+		ctx := audit.WithTrigger(c.Request.Context(), audit.TriggerAPI)
+		var purged []Entry
+		var errs []error
+		switch {
+		case req.All:
+			purged, errs = t.PurgeAll(ctx)
+		case len(req.IDs) > 0:
+			purged, errs = t.Purge(ctx, req.IDs)
+		default:
+			purged, errs = t.PurgeExpired(ctx, time.Now())
+		}
+		c.JSON(statusFor(purged, errs), gin.H{
+			"purged":      orEmpty(purged),
+			"freed_bytes": TotalSize(purged),
+			"errors":      messages(errs),
+		})
+	})
+}
+
+// statusFor retourne 200 si au moins une entrée a été traitée ou s'il n'y
+// a pas eu d'erreur ; sinon 404 quand aucun ID n'existe, 409 pour les
+// autres échecs.
+func statusFor(done []Entry, errs []error) int {
+	if len(done) > 0 || len(errs) == 0 {
+		return http.StatusOK
+	}
+	for _, err := range errs {
+		if !errors.Is(err, ErrNotFound) {
+			return http.StatusConflict
+		}
+	}
+	return http.StatusNotFound
+}
+
+func orEmpty(entries []Entry) []Entry {
+	if entries == nil {
+		return []Entry{}
+	}
+	return entries
+}
+
+func messages(errs []error) []string {
+	msgs := make([]string, len(errs))
+	for i, err := range errs {
+		msgs[i] = err.Error()
+	}
+	return msgs
+}
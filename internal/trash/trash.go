@@ -0,0 +1,406 @@
+// Package trash met en quarantaine les fichiers supprimés par le cleaner,
+// pour pouvoir les restaurer tant que la rétention n'est pas écoulée.
+package trash
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/cleeryy/clarr/internal/audit"
+	"go.uber.org/zap"
+)
+
+const (
+	// manifestFile liste les entrées de la corbeille, à sa racine.
+	manifestFile = "manifest.json"
+	// restoredFile liste les fichiers restaurés, que le cleaner ne doit
+	// pas remettre en corbeille au run suivant.
+	restoredFile = "restored.json"
+)
+
+var (
+	// ErrNotFound est retourné pour un ID absent de la corbeille.
+	ErrNotFound = errors.New("trash: entry not found")
+	// ErrExists est retourné quand un fichier restauré écraserait un
+	// fichier revenu depuis à son emplacement d'origine.
+	ErrExists = errors.New("trash: original path already exists")
+)
+
+// Trash déplace les fichiers dans un dossier du même système de fichiers
+// (un simple rename) et garde dans un manifest d'où ils viennent.
+type Trash struct {
+	dir       string
+	retention time.Duration
+	logger    *zap.Logger
+	audit     *audit.Log
+
+	mu       sync.Mutex
+	entries  []Entry              // du plus ancien au plus récent
+	restored map[string]time.Time // chemin d'origine → date de restauration
+}
+
+// Option configure la corbeille.
+type Option func(*Trash)
+
+// WithAudit journalise les restaurations et les purges.
+func WithAudit(l *audit.Log) Option {
+	return func(t *Trash) {
+		t.audit = l
+	}
+}
+
+// ─── Models ───────────────────────────────────────────────────────────
+
+// Entry est un fichier en corbeille.
+type Entry struct {
+	ID        string     `json:"id"`   // chemin relatif dans la corbeille
+	Path      string     `json:"path"` // chemin d'origine
+	Size      int64      `json:"size"`
+	Reason    string     `json:"reason"`
+	TrashedAt time.Time  `json:"trashed_at"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"` // calculé depuis la rétention
+}
+
+// ─── Lifecycle ────────────────────────────────────────────────────────
+
+// Open ouvre (ou crée) la corbeille dir. Les entrées sont purgées après
+// retention ; 0 les garde jusqu'à une purge manuelle.
+func Open(dir string, retention time.Duration, logger *zap.Logger, opts ...Option) (*Trash, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("trash: create dir: %w", err)
+	}
+
+	t := &Trash{dir: filepath.Clean(dir), retention: retention, logger: logger}
+	for _, opt := range opts {
+		opt(t)
+	}
+
+	if err := t.load(manifestFile, &t.entries); err != nil {
+		return nil, err
+	}
+	if err := t.load(restoredFile, &t.restored); err != nil {
+		return nil, err
+	}
+	if t.restored == nil {
+		t.restored = make(map[string]time.Time)
+	}
+	t.pruneRestored()
+	return t, nil
+}
+
+// Dir retourne le dossier de la corbeille, que le cleaner ne doit pas
+// parcourir.
+func (t *Trash) Dir() string {
+	return t.dir
+}
+
+// ─── Methods ──────────────────────────────────────────────────────────
+
+// Move met path en corbeille sous rel, son chemin relatif au downloadDir,
+// dans un dossier par date pour que deux versions d'un fichier ne se
+// marchent pas dessus.
+func (t *Trash) Move(path, rel string, size int64, reason string) (Entry, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now().UTC()
+	batch := now.Format("20060102-150405")
+
+	id := filepath.ToSlash(filepath.Join(batch, rel))
+	for n := 2; t.exists(id); n++ {
+		id = filepath.ToSlash(filepath.Join(fmt.Sprintf("%s-%d", batch, n), rel))
+	}
+
+	dest := t.path(id)
+	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		return Entry{}, fmt.Errorf("trash: create dir: %w", err)
+	}
+	if err := os.Rename(path, dest); err != nil {
+		if errors.Is(err, syscall.EXDEV) {
+			return Entry{}, fmt.Errorf("trash: %s is not on the same filesystem as %s: %w", t.dir, path, err)
+		}
+		return Entry{}, fmt.Errorf("trash: move %s: %w", path, err)
+	}
+
+	entry := Entry{ID: id, Path: path, Size: size, Reason: reason, TrashedAt: now}
+	t.entries = append(t.entries, entry)
+	if err := t.save(); err != nil {
+		return Entry{}, err
+	}
+
+	t.logger.Info("moved to trash",
+		zap.String("path", path),
+		zap.String("id", id),
+		zap.Int64("size_bytes", size),
+	)
+	return t.withExpiry(entry), nil
+}
+
+// Restored indique si path a été restauré depuis la corbeille : le cleaner
+// le laisse en place, même sans hardlink, tant qu'il existe.
+func (t *Trash) Restored(path string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	_, ok := t.restored[filepath.Clean(path)]
+	return ok
+}
+
+// List retourne les entrées, de la plus récente à la plus ancienne.
+func (t *Trash) List() []Entry {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	list := make([]Entry, 0, len(t.entries))
+	for i := len(t.entries) - 1; i >= 0; i-- {
+		list = append(list, t.withExpiry(t.entries[i]))
+	}
+	return list
+}
+
+// Restore remet les entrées ids à leur emplacement d'origine. Une entrée
+// qui échoue n'empêche pas les autres.
+func (t *Trash) Restore(ctx context.Context, ids []string) ([]Entry, []error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var restored []Entry
+	var errs []error
+	for _, id := range ids {
+		entry, err := t.restore(ctx, id)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", id, err))
+			continue
+		}
+		restored = append(restored, entry)
+	}
+
+	if len(restored) > 0 {
+		if err := t.save(); err != nil {
+			errs = append(errs, err)
+		}
+		if err := t.saveRestored(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return restored, errs
+}
+
+// Purge supprime définitivement les entrées ids.
+func (t *Trash) Purge(ctx context.Context, ids []string) ([]Entry, []error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.purge(ctx, ids)
+}
+
+// PurgeAll vide la corbeille.
+func (t *Trash) PurgeAll(ctx context.Context) ([]Entry, []error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.purge(ctx, t.ids(func(Entry) bool { return true }))
+}
+
+// PurgeExpired supprime les entrées plus vieilles que la rétention.
+func (t *Trash) PurgeExpired(ctx context.Context, now time.Time) ([]Entry, []error) {
+	if t.retention <= 0 {
+		return nil, nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.purge(ctx, t.ids(func(e Entry) bool {
+		return !now.Before(e.TrashedAt.Add(t.retention))
+	}))
+}
+
+// TotalSize retourne la place occupée par les entrées.
+func TotalSize(entries []Entry) int64 {
+	var total int64
+	for _, e := range entries {
+		total += e.Size
+	}
+	return total
+}
+
+// ─── Private ──────────────────────────────────────────────────────────
+
+// À appeler sous t.mu.
+func (t *Trash) restore(ctx context.Context, id string) (Entry, error) {
+	i := t.index(id)
+	if i < 0 {
+		return Entry{}, ErrNotFound
+	}
+	entry := t.entries[i]
+
+	if _, err := os.Lstat(entry.Path); !errors.Is(err, fs.ErrNotExist) {
+		return Entry{}, fmt.Errorf("%w: %s", ErrExists, entry.Path)
+	}
+	if err := os.MkdirAll(filepath.Dir(entry.Path), 0o755); err != nil {
+		return Entry{}, err
+	}
+	err := os.Rename(t.path(id), entry.Path)
+	t.record(ctx, audit.ActionRestoreFile, entry, err)
+	if err != nil {
+		return Entry{}, err
+	}
+
+	t.entries = append(t.entries[:i], t.entries[i+1:]...)
+	t.restored[filepath.Clean(entry.Path)] = time.Now().UTC()
+	t.removeEmptyParents(t.path(id))
+	t.logger.Info("restored from trash", zap.String("path", entry.Path), zap.String("id", id))
+	return entry, nil
+}
+
+// À appeler sous t.mu.
+func (t *Trash) purge(ctx context.Context, ids []string) ([]Entry, []error) {
+	var purged []Entry
+	var errs []error
+	for _, id := range ids {
+		i := t.index(id)
+		if i < 0 {
+			errs = append(errs, fmt.Errorf("%s: %w", id, ErrNotFound))
+			continue
+		}
+		entry := t.entries[i]
+
+		if err := os.Remove(t.path(id)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			t.record(ctx, audit.ActionPurgeFile, entry, err)
+			errs = append(errs, fmt.Errorf("%s: %w", id, err))
+			continue
+		}
+		t.record(ctx, audit.ActionPurgeFile, entry, nil)
+
+		t.entries = append(t.entries[:i], t.entries[i+1:]...)
+		t.removeEmptyParents(t.path(id))
+		t.logger.Info("purged from trash", zap.String("path", entry.Path), zap.String("id", id))
+		purged = append(purged, entry)
+	}
+
+	if len(purged) > 0 {
+		if err := t.save(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return purged, errs
+}
+
+// record journalise une action sur entry si l'audit est activé.
+func (t *Trash) record(ctx context.Context, action audit.Action, entry Entry, err error) {
+	if t.audit != nil {
+		t.audit.Record(ctx, audit.Entry{Action: action, Target: entry.Path, Detail: entry.ID}, err)
+	}
+}
+
+// save réécrit le manifest de façon atomique. À appeler sous t.mu.
+func (t *Trash) save() error {
+	return t.write(manifestFile, t.entries)
+}
+
+// saveRestored réécrit la liste des fichiers restaurés. À appeler sous
+// t.mu.
+func (t *Trash) saveRestored() error {
+	t.pruneRestored()
+	return t.write(restoredFile, t.restored)
+}
+
+// pruneRestored oublie les fichiers restaurés qui ont disparu depuis, pour
+// que la liste ne grossisse pas indéfiniment.
+func (t *Trash) pruneRestored() {
+	for path := range t.restored {
+		if _, err := os.Lstat(path); errors.Is(err, fs.ErrNotExist) {
+			delete(t.restored, path)
+		}
+	}
+}
+
+// load décode le fichier name de la corbeille dans v ; un fichier absent
+// laisse v vide.
+func (t *Trash) load(name string, v any) error {
+	data, err := os.ReadFile(filepath.Join(t.dir, name))
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return nil
+	case err != nil:
+		return fmt.Errorf("trash: read %s: %w", name, err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("trash: decode %s: %w", name, err)
+	}
+	return nil
+}
+
+// write écrit v dans le fichier name de la corbeille de façon atomique.
+func (t *Trash) write(name string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("trash: encode %s: %w", name, err)
+	}
+
+	tmp := filepath.Join(t.dir, name+".tmp")
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("trash: write %s: %w", name, err)
+	}
+	if err := os.Rename(tmp, filepath.Join(t.dir, name)); err != nil {
+		return fmt.Errorf("trash: write %s: %w", name, err)
+	}
+	return nil
+}
+
+func (t *Trash) path(id string) string {
+	return filepath.Join(t.dir, filepath.FromSlash(id))
+}
+
+func (t *Trash) index(id string) int {
+	for i, e := range t.entries {
+		if e.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func (t *Trash) exists(id string) bool {
+	if t.index(id) >= 0 {
+		return true
+	}
+	_, err := os.Lstat(t.path(id))
+	return !errors.Is(err, fs.ErrNotExist)
+}
+
+func (t *Trash) ids(keep func(Entry) bool) []string {
+	var ids []string
+	for _, e := range t.entries {
+		if keep(e) {
+			ids = append(ids, e.ID)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func (t *Trash) withExpiry(e Entry) Entry {
+	if t.retention > 0 {
+		expires := e.TrashedAt.Add(t.retention)
+		e.ExpiresAt = &expires
+	}
+	return e
+}
+
+// removeEmptyParents supprime les dossiers vidés au-dessus de path,
+// jusqu'à la racine de la corbeille exclue.
+func (t *Trash) removeEmptyParents(path string) {
+	for dir := filepath.Dir(path); dir != t.dir && len(dir) > len(t.dir); dir = filepath.Dir(dir) {
+		if err := os.Remove(dir); err != nil {
+			return
+		}
+	}
+}
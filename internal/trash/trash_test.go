@@ -0,0 +1,173 @@
+package trash
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// trashFile crée un fichier de downloads puis le met en corbeille.
+func trashFile(t *testing.T, tr *Trash, downloads, rel string) Entry {
+	t.Helper()
+	path := filepath.Join(downloads, rel)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(rel), 0644); err != nil {
+		t.Fatal(err)
+	}
+	entry, err := tr.Move(path, rel, int64(len(rel)), "no hardlink left")
+	if err != nil {
+		t.Fatalf("Move(%s): %v", rel, err)
+	}
+	return entry
+}
+
+func TestTrash_MoveAndRestore(t *testing.T) {
+	base := t.TempDir()
+	downloads := filepath.Join(base, "downloads")
+	dir := filepath.Join(downloads, ".clarr-trash")
+
+	tr, err := Open(dir, 24*time.Hour, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	movie := trashFile(t, tr, downloads, "radarr/Dune.2021/Dune.2021.mkv")
+	// Même fichier retéléchargé puis de nouveau orphelin.
+	again := trashFile(t, tr, downloads, "radarr/Dune.2021/Dune.2021.mkv")
+
+	if movie.ID == again.ID {
+		t.Fatalf("two versions of a file share the id %s", movie.ID)
+	}
+	if !strings.HasSuffix(movie.ID, "/radarr/Dune.2021/Dune.2021.mkv") {
+		t.Errorf("id %s does not keep the relative path", movie.ID)
+	}
+	if movie.ExpiresAt == nil || !movie.ExpiresAt.Equal(movie.TrashedAt.Add(24*time.Hour)) {
+		t.Errorf("expires_at = %v, want trashed_at + retention", movie.ExpiresAt)
+	}
+	if _, err := os.Stat(movie.Path); !os.IsNotExist(err) {
+		t.Fatal("trashed file still in download dir")
+	}
+
+	// Le manifest survit à un redémarrage.
+	tr, err = Open(dir, 24*time.Hour, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if list := tr.List(); len(list) != 2 || list[0].ID != again.ID {
+		t.Fatalf("expected newest entry first, got %+v", list)
+	}
+
+	restored, errs := tr.Restore(context.Background(), []string{movie.ID, again.ID, "nope"})
+	if len(restored) != 1 || restored[0].ID != movie.ID {
+		t.Fatalf("expected %s restored, got %+v", movie.ID, restored)
+	}
+	if len(errs) != 2 || !errors.Is(errs[0], ErrExists) || !errors.Is(errs[1], ErrNotFound) {
+		t.Fatalf("expected ErrExists then ErrNotFound, got %v", errs)
+	}
+	if data, err := os.ReadFile(movie.Path); err != nil || string(data) != "radarr/Dune.2021/Dune.2021.mkv" {
+		t.Fatalf("restored file: %q, %v", data, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, strings.SplitN(movie.ID, "/", 2)[0])); !os.IsNotExist(err) {
+		t.Error("empty batch dir should have been removed")
+	}
+	if !tr.Restored(movie.Path) {
+		t.Error("restored file not recorded")
+	}
+
+	// Une fois le fichier supprimé, il n'est plus protégé.
+	if err := os.Remove(movie.Path); err != nil {
+		t.Fatal(err)
+	}
+	tr, err = Open(dir, 24*time.Hour, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tr.Restored(movie.Path) {
+		t.Error("removed file still recorded as restored")
+	}
+}
+
+func TestTrash_PurgeExpired(t *testing.T) {
+	downloads := t.TempDir()
+	tr, err := Open(filepath.Join(downloads, ".clarr-trash"), time.Hour, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry := trashFile(t, tr, downloads, "tv/Andor.S01E01.mkv")
+
+	if purged, _ := tr.PurgeExpired(context.Background(), time.Now()); len(purged) != 0 {
+		t.Fatalf("entry purged before retention: %+v", purged)
+	}
+
+	purged, errs := tr.PurgeExpired(context.Background(), time.Now().Add(2*time.Hour))
+	if len(errs) != 0 || len(purged) != 1 || purged[0].ID != entry.ID {
+		t.Fatalf("expected %s purged, got %+v %v", entry.ID, purged, errs)
+	}
+	if _, err := os.Stat(tr.path(entry.ID)); !os.IsNotExist(err) {
+		t.Error("purged file still in trash")
+	}
+	if len(tr.List()) != 0 {
+		t.Error("purged entry still listed")
+	}
+}
+
+func TestRegister(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	downloads := t.TempDir()
+	tr, err := Open(filepath.Join(downloads, ".clarr-trash"), 0, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	movie := trashFile(t, tr, downloads, "radarr/Dune.2021.mkv")
+	show := trashFile(t, tr, downloads, "tv/Andor.S01E01.mkv")
+
+	r := gin.New()
+	tr.Register(r)
+
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return w
+	}
+
+	w := serve(http.MethodGet, "/api/trash", "")
+	var list struct {
+		Entries []Entry `json:"entries"`
+		Size    int64   `json:"size"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != http.StatusOK || len(list.Entries) != 2 || list.Size != movie.Size+show.Size {
+		t.Fatalf("GET /api/trash = %d %s", w.Code, w.Body)
+	}
+
+	if w := serve(http.MethodPost, "/api/trash/restore", `{}`); w.Code != http.StatusBadRequest {
+		t.Errorf("restore without ids: got %d, want 400", w.Code)
+	}
+	if w := serve(http.MethodPost, "/api/trash/restore", `{"ids": ["nope"]}`); w.Code != http.StatusNotFound {
+		t.Errorf("restore unknown id: got %d, want 404", w.Code)
+	}
+	if w := serve(http.MethodPost, "/api/trash/restore", `{"ids": ["`+movie.ID+`"]}`); w.Code != http.StatusOK {
+		t.Errorf("restore: got %d %s", w.Code, w.Body)
+	}
+
+	// Sans rétention, une purge sans corps ne supprime rien.
+	if w := serve(http.MethodPost, "/api/trash/purge", ""); w.Code != http.StatusOK || len(tr.List()) != 1 {
+		t.Errorf("purge expired: got %d, %d entries left", w.Code, len(tr.List()))
+	}
+	if w := serve(http.MethodPost, "/api/trash/purge", `{"all": true}`); w.Code != http.StatusOK || len(tr.List()) != 0 {
+		t.Errorf("purge all: got %d %s", w.Code, w.Body)
+	}
+}
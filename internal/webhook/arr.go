@@ -0,0 +1,250 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/cleeryy/clarr/internal/audit"
+	"github.com/cleeryy/clarr/internal/cleaner"
+	"github.com/cleeryy/clarr/internal/jobs"
+	"github.com/cleeryy/clarr/internal/queue"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// jobReleaseDeleted est le type de job des fichiers supprimés par
+// Radarr/Sonarr, dont les releases téléchargées sont à nettoyer.
+const jobReleaseDeleted = "arr.deleted"
+
+// ─── Models ───────────────────────────────────────────────────────────
+
+// ArrEvent est le payload d'une connexion Webhook de Radarr ou Sonarr.
+type ArrEvent struct {
+	EventType    string   `json:"eventType"` // "MovieFileDelete" | "EpisodeFileDelete" | "MovieDelete" | "SeriesDelete" | "Download" | "Test" | ...
+	IsUpgrade    bool     `json:"isUpgrade"`
+	DeleteReason string   `json:"deleteReason"`
+	Movie        *ArrItem `json:"movie"`
+	Series       *ArrItem `json:"series"`
+	MovieFile    *ArrFile `json:"movieFile"`
+	EpisodeFile  *ArrFile `json:"episodeFile"`
+
+	// DeletedFiles est un booléen pour MovieDelete/SeriesDelete et la liste
+	// des fichiers remplacés pour un Download.
+	DeletedFiles json.RawMessage `json:"deletedFiles"`
+}
+
+type ArrItem struct {
+	Title string `json:"title"`
+}
+
+type ArrFile struct {
+	Path         string `json:"path"`
+	RelativePath string `json:"relativePath"`
+	SceneName    string `json:"sceneName"`
+}
+
+// ReleaseDeleted est persisté dans la file : les releases dont un fichier
+// vient d'être supprimé de la bibliothèque. Sans release, tout le
+// downloadDir est nettoyé.
+type ReleaseDeleted struct {
+	App       string   `json:"app"`
+	EventType string   `json:"event_type"`
+	Title     string   `json:"title"`
+	Releases  []string `json:"releases,omitempty"`
+}
+
+// Reclaimer nettoie les fichiers orphelins du downloadDir.
+type Reclaimer interface {
+	Cleanup(ctx context.Context) (*cleaner.CleanupResult, error)
+	CleanupReleases(ctx context.Context, names []string) (*cleaner.CleanupResult, error)
+}
+
+// WithArrWebhooks expose /webhook/radarr et /webhook/sonarr, protégés par
+// basic auth. Les fichiers supprimés ou remplacés par Radarr/Sonarr sont
+// nettoyés aussitôt par r, sans attendre le cleanup planifié.
+func WithArrWebhooks(username, password string, r Reclaimer) Option {
+	return func(h *Handler) {
+		h.arrUsername = username
+		h.arrPassword = password
+		h.reclaimer = r
+	}
+}
+
+// WithTracker fait passer les cleanups des webhooks Radarr/Sonarr par le
+// tracker de jobs, comme /api/cleanup et le cron : ils apparaissent dans
+// /api/jobs, et un cleanup demandé pendant ce temps reçoit un 409.
+func WithTracker(t *jobs.Tracker) Option {
+	return func(h *Handler) {
+		h.tracker = t
+	}
+}
+
+// ─── Routes ───────────────────────────────────────────────────────────
+
+func (h *Handler) registerArr(r *gin.Engine) {
+	if h.arrPassword == "" || h.reclaimer == nil {
+		return
+	}
+	auth := gin.BasicAuth(gin.Accounts{h.arrUsername: h.arrPassword})
+	r.POST("/webhook/radarr", auth, h.handleArr("radarr"))
+	r.POST("/webhook/sonarr", auth, h.handleArr("sonarr"))
+}
+
+func (h *Handler) handleArr(app string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var event ArrEvent
+		if err := json.NewDecoder(c.Request.Body).Decode(&event); err != nil {
+			h.logger.Error("failed to decode arr event", zap.String("app", app), zap.Error(err))
+			h.observe(app, "", "invalid")
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
+			return
+		}
+
+		h.logger.Info("arr event received",
+			zap.String("app", app),
+			zap.String("event", event.EventType),
+			zap.String("title", event.title()),
+		)
+
+		deleted, ok := event.releaseDeleted(app)
+		if !ok {
+			h.observe(app, event.EventType, "ignored")
+			c.JSON(http.StatusOK, gin.H{"status": "ignored"})
+			return
+		}
+
+		jobID, err := h.queue.Enqueue(jobReleaseDeleted, deleted)
+		if err != nil {
+			h.logger.Error("failed to queue arr event", zap.String("app", app), zap.Error(err))
+			h.observe(app, event.EventType, "failed")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "cannot queue event"})
+			return
+		}
+		h.observe(app, event.EventType, "queued")
+		c.JSON(http.StatusOK, gin.H{"status": "queued", "job_id": jobID})
+	}
+}
+
+// releaseDeleted retourne les releases à nettoyer, ou false si l'événement
+// ne libère aucun fichier téléchargé.
+func (e ArrEvent) releaseDeleted(app string) (ReleaseDeleted, bool) {
+	deleted := ReleaseDeleted{App: app, EventType: e.EventType, Title: e.title()}
+
+	switch e.EventType {
+	case "MovieFileDelete":
+		deleted.Releases = e.MovieFile.releases()
+	case "EpisodeFileDelete":
+		deleted.Releases = e.EpisodeFile.releases()
+	case "Download":
+		if !e.IsUpgrade {
+			return deleted, false
+		}
+		var files []ArrFile
+		if err := json.Unmarshal(e.DeletedFiles, &files); err != nil {
+			return deleted, false
+		}
+		for i := range files {
+			deleted.Releases = append(deleted.Releases, files[i].releases()...)
+		}
+	case "MovieDelete", "SeriesDelete":
+		// Radarr/Sonarr ne disent pas quels fichiers ont été supprimés :
+		// seul un cleanup complet retrouve leurs releases.
+		var withFiles bool
+		if err := json.Unmarshal(e.DeletedFiles, &withFiles); err != nil || !withFiles {
+			return deleted, false
+		}
+		return deleted, true
+	default:
+		return deleted, false
+	}
+	return deleted, len(deleted.Releases) > 0
+}
+
+func (e ArrEvent) title() string {
+	switch {
+	case e.Movie != nil:
+		return e.Movie.Title
+	case e.Series != nil:
+		return e.Series.Title
+	}
+	return ""
+}
+
+// releases retourne les noms sous lesquels le fichier a pu être
+// téléchargé : son nom de scène et son nom de fichier.
+func (f *ArrFile) releases() []string {
+	if f == nil {
+		return nil
+	}
+	var names []string
+	if f.SceneName != "" {
+		names = append(names, f.SceneName)
+	}
+	for _, p := range []string{f.RelativePath, f.Path} {
+		if p != "" {
+			names = append(names, path.Base(strings.ReplaceAll(p, "\\", "/")))
+			break
+		}
+	}
+	return names
+}
+
+// ─── Job ──────────────────────────────────────────────────────────────
+
+// processRelease nettoie les releases d'un fichier supprimé par Radarr/Sonarr.
+// Un cleanup déjà en cours fait échouer le job, qui est réessayé plus tard.
+func (h *Handler) processRelease(ctx context.Context, payload json.RawMessage) error {
+	var deleted ReleaseDeleted
+	if err := json.Unmarshal(payload, &deleted); err != nil {
+		return queue.Permanent(fmt.Errorf("decode arr event: %w", err))
+	}
+	if h.reclaimer == nil {
+		return queue.Permanent(fmt.Errorf("arr webhooks are disabled"))
+	}
+
+	result, err := h.reclaim(withTrigger(ctx, deleted.App), deleted.Releases)
+	if err != nil {
+		return fmt.Errorf("%s %s cleanup: %w", deleted.App, deleted.EventType, err)
+	}
+
+	h.logger.Info("arr event cleanup done",
+		zap.String("app", deleted.App),
+		zap.String("event", deleted.EventType),
+		zap.String("title", deleted.Title),
+		zap.Strings("releases", deleted.Releases),
+		zap.Int("orphans", len(result.OrphanFiles)),
+		zap.String("freed", result.FreedBytesHuman()),
+	)
+	return nil
+}
+
+// reclaim nettoie les releases, ou tout le downloadDir sans release, via
+// le tracker s'il est configuré. Un cleanup déjà suivi par le tracker fait
+// échouer le job sans rien lancer.
+func (h *Handler) reclaim(ctx context.Context, releases []string) (*cleaner.CleanupResult, error) {
+	run := func() (*cleaner.CleanupResult, error) {
+		if len(releases) == 0 {
+			return h.reclaimer.Cleanup(ctx)
+		}
+		return h.reclaimer.CleanupReleases(ctx, releases)
+	}
+	if h.tracker == nil {
+		return run()
+	}
+
+	var result *cleaner.CleanupResult
+	var err error
+	job, started := h.tracker.TryStart("cleanup", audit.TriggerFrom(ctx), func() (any, error) {
+		result, err = run()
+		return result, err
+	})
+	if !started {
+		return nil, fmt.Errorf("%w: job %s", cleaner.ErrAlreadyRunning, job.ID)
+	}
+	h.tracker.Wait(job.ID)
+	return result, err
+}
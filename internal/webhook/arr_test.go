@@ -0,0 +1,217 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/cleeryy/clarr/internal/audit"
+	"github.com/cleeryy/clarr/internal/cleaner"
+	"github.com/cleeryy/clarr/internal/jobs"
+	"github.com/cleeryy/clarr/internal/queue"
+	"go.uber.org/zap"
+)
+
+// fakeReclaimer enregistre les cleanups demandés.
+type fakeReclaimer struct {
+	full     int
+	releases [][]string
+	triggers []string
+	err      error
+	blocked  chan struct{} // si non nil, chaque cleanup attend sa fermeture
+}
+
+func (f *fakeReclaimer) Cleanup(ctx context.Context) (*cleaner.CleanupResult, error) {
+	if f.blocked != nil {
+		<-f.blocked
+	}
+	f.full++
+	f.triggers = append(f.triggers, audit.TriggerFrom(ctx))
+	return &cleaner.CleanupResult{}, f.err
+}
+
+func (f *fakeReclaimer) CleanupReleases(ctx context.Context, names []string) (*cleaner.CleanupResult, error) {
+	f.releases = append(f.releases, names)
+	f.triggers = append(f.triggers, audit.TriggerFrom(ctx))
+	return &cleaner.CleanupResult{}, f.err
+}
+
+func TestArrEvent_ReleaseDeleted(t *testing.T) {
+	tests := []struct {
+		app     string
+		fixture string
+		want    ReleaseDeleted
+		ok      bool
+	}{
+		{"radarr", "testdata/radarr_moviefiledelete.json", ReleaseDeleted{
+			App: "radarr", EventType: "MovieFileDelete", Title: "Dune",
+			Releases: []string{"Dune.2021.1080p.BluRay.x264-SPARKS", "Dune (2021) - 1080p.mkv"},
+		}, true},
+		{"radarr", "testdata/radarr_download_upgrade.json", ReleaseDeleted{
+			App: "radarr", EventType: "Download", Title: "Dune",
+			Releases: []string{"Dune.2021.1080p.BluRay.x264-SPARKS", "Dune (2021) - 1080p.mkv"},
+		}, true},
+		{"radarr", "testdata/radarr_moviedelete.json", ReleaseDeleted{
+			App: "radarr", EventType: "MovieDelete", Title: "Dune",
+		}, true},
+		{"sonarr", "testdata/sonarr_episodefiledelete.json", ReleaseDeleted{
+			App: "sonarr", EventType: "EpisodeFileDelete", Title: "Severance",
+			Releases: []string{"Severance.S02E01.1080p.ATVP.WEB-DL.DDP5.1.H.264-NTb", "Severance - S02E01 - Hello, Ms. Cobel.mkv"},
+		}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			raw, err := os.ReadFile(tt.fixture)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var event ArrEvent
+			if err := json.Unmarshal(raw, &event); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+
+			got, ok := event.releaseDeleted(tt.app)
+			if ok != tt.ok || !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("releaseDeleted() = %+v, %v, want %+v, %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestArrEvent_Ignored(t *testing.T) {
+	events := map[string]string{
+		"test":            `{"eventType":"Test","movie":{"title":"Test Title"}}`,
+		"first download":  `{"eventType":"Download","isUpgrade":false,"movie":{"title":"Dune"},"movieFile":{"sceneName":"Dune.2021.2160p"}}`,
+		"files kept":      `{"eventType":"SeriesDelete","series":{"title":"Severance"},"deletedFiles":false}`,
+		"grab":            `{"eventType":"Grab","movie":{"title":"Dune"}}`,
+		"no release name": `{"eventType":"MovieFileDelete","movie":{"title":"Dune"},"movieFile":{}}`,
+	}
+
+	for name, body := range events {
+		t.Run(name, func(t *testing.T) {
+			var event ArrEvent
+			if err := json.Unmarshal([]byte(body), &event); err != nil {
+				t.Fatal(err)
+			}
+			if got, ok := event.releaseDeleted("radarr"); ok {
+				t.Fatalf("releaseDeleted() = %+v, want ignored", got)
+			}
+		})
+	}
+}
+
+func TestArrWebhook_BasicAuth(t *testing.T) {
+	payload, err := os.ReadFile("testdata/sonarr_episodefiledelete.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, _ := newTestServer(t, "", WithArrWebhooks("clarr", "s3cret", &fakeReclaimer{}))
+
+	req := httptest.NewRequest(http.MethodPost, "/webhook/sonarr", bytes.NewReader(payload))
+	if w := serve(r, req); w.Code != http.StatusUnauthorized {
+		t.Fatalf("no credentials: status = %d, want 401", w.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/webhook/sonarr", bytes.NewReader(payload))
+	req.SetBasicAuth("clarr", "s3cret")
+	w := serve(r, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body)
+	}
+	var body struct {
+		Status string `json:"status"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || body.Status != "queued" {
+		t.Fatalf("response %s", w.Body)
+	}
+}
+
+func TestProcessRelease(t *testing.T) {
+	reclaimer := &fakeReclaimer{}
+	h := &Handler{reclaimer: reclaimer, logger: zap.NewNop()}
+
+	targeted, _ := json.Marshal(ReleaseDeleted{App: "radarr", EventType: "MovieFileDelete", Releases: []string{"Dune.2021.1080p"}})
+	if err := h.processRelease(t.Context(), targeted); err != nil {
+		t.Fatalf("targeted: %v", err)
+	}
+	full, _ := json.Marshal(ReleaseDeleted{App: "radarr", EventType: "MovieDelete"})
+	if err := h.processRelease(t.Context(), full); err != nil {
+		t.Fatalf("full: %v", err)
+	}
+
+	if !reflect.DeepEqual(reclaimer.releases, [][]string{{"Dune.2021.1080p"}}) || reclaimer.full != 1 {
+		t.Fatalf("releases %v, full cleanups %d", reclaimer.releases, reclaimer.full)
+	}
+	// Hors file, le job n'a pas d'ID.
+	if !reflect.DeepEqual(reclaimer.triggers, []string{"webhook:radarr:0", "webhook:radarr:0"}) {
+		t.Errorf("audit triggers = %v", reclaimer.triggers)
+	}
+
+	// Un cleanup en cours est transitoire : le job sera réessayé.
+	reclaimer.err = cleaner.ErrAlreadyRunning
+	if err := h.processRelease(t.Context(), targeted); !errors.Is(err, cleaner.ErrAlreadyRunning) {
+		t.Fatalf("err = %v, want ErrAlreadyRunning", err)
+	}
+}
+
+func TestProcessRelease_Tracked(t *testing.T) {
+	reclaimer := &fakeReclaimer{}
+	tracker := jobs.New(10, zap.NewNop())
+	h := &Handler{reclaimer: reclaimer, tracker: tracker, logger: zap.NewNop()}
+
+	full, _ := json.Marshal(ReleaseDeleted{App: "radarr", EventType: "MovieDelete"})
+	if err := h.processRelease(t.Context(), full); err != nil {
+		t.Fatalf("full: %v", err)
+	}
+	list := tracker.List()
+	if len(list) != 1 || list[0].Kind != "cleanup" || list[0].Trigger != "webhook:radarr:0" || list[0].Status != jobs.StatusSucceeded {
+		t.Fatalf("tracked jobs = %+v, want the webhook cleanup", list)
+	}
+
+	// Un cleanup de l'API en cours : le job échoue sans rien lancer et
+	// sera réessayé.
+	release := make(chan struct{})
+	api, _ := tracker.TryStart("cleanup", "api", func() (any, error) {
+		<-release
+		return nil, nil
+	})
+	err := h.processRelease(t.Context(), full)
+	if !errors.Is(err, cleaner.ErrAlreadyRunning) || queue.IsPermanent(classify(err)) {
+		t.Fatalf("err = %v, want a transient ErrAlreadyRunning", err)
+	}
+	if reclaimer.full != 1 {
+		t.Errorf("cleanup ran %d times, want 1", reclaimer.full)
+	}
+	close(release)
+	tracker.Wait(api.ID)
+
+	// Pendant un cleanup du webhook, l'API voit le job actif (409).
+	reclaimer.blocked = make(chan struct{})
+	done := make(chan error, 1)
+	go func() { done <- h.processRelease(t.Context(), full) }()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for running := false; !running; {
+		if time.Now().After(deadline) {
+			t.Fatal("webhook cleanup never showed up as running")
+		}
+		time.Sleep(5 * time.Millisecond)
+		running = tracker.List()[0].Status == jobs.StatusRunning
+	}
+	job, started := tracker.TryStart("cleanup", "api", func() (any, error) { return nil, nil })
+	if started || job.Trigger != "webhook:radarr:0" {
+		t.Errorf("api cleanup started = %v next to job %+v, want a conflict with the webhook", started, job)
+	}
+	close(reclaimer.blocked)
+	if err := <-done; err != nil {
+		t.Fatalf("blocked cleanup: %v", err)
+	}
+}
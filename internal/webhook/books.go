@@ -0,0 +1,43 @@
+package webhook
+
+import (
+	"context"
+
+	"github.com/cleeryy/clarr/internal/readarr"
+)
+
+// ─── Readarr ──────────────────────────────────────────────────────────
+
+// readarrApp décrit Readarr pour handleLibraryDeleted.
+var readarrApp = libraryApp[readarr.Book]{name: "readarr", kind: "book", resolve: resolveBook}
+
+// handleBookDeleted traite la suppression d'un livre ou d'un livre audio.
+func (h *Handler) handleBookDeleted(ctx context.Context, event MediaDeleted) error {
+	return handleLibraryDeleted(ctx, h, readarrApp, libraries(h.readarr, newBooks), event)
+}
+
+// books présente un client Readarr comme une bibliothèque de livres.
+type books struct {
+	*readarr.Client
+}
+
+func newBooks(c *readarr.Client) library[readarr.Book] { return books{c} }
+
+func (c books) Items() ([]readarr.Book, error)        { return c.GetAllBooks() }
+func (c books) Item(id int) (*readarr.Book, error)    { return c.GetBook(id) }
+func (c books) MissingItems() ([]readarr.Book, error) { return c.GetMissingBooks() }
+func (c books) Unmonitor(ids []int) error             { return c.UnmonitorBooks(ids) }
+
+func (c books) RescanParent(b *readarr.Book) (int, error) {
+	return c.RescanAuthor(b.AuthorID)
+}
+
+func (c books) Describe(b *readarr.Book) libraryItem {
+	return libraryItem{
+		ID:        b.ID,
+		Title:     b.Title,
+		Parent:    b.Author.AuthorName,
+		Monitored: b.Monitored,
+		Files:     b.Statistics.BookFileCount,
+	}
+}
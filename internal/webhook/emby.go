@@ -0,0 +1,127 @@
+package webhook
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// ─── Emby ─────────────────────────────────────────────────────────────
+
+// EmbyEvent est le payload d'une notification webhook Emby.
+type EmbyEvent struct {
+	Event string   `json:"Event"`
+	Item  EmbyItem `json:"Item"`
+}
+
+type EmbyItem struct {
+	Name              string            `json:"Name"`
+	Type              string            `json:"Type"` // "Movie" | "Episode" | "Season" | "Series" | "MusicAlbum" | "Audio" | "Book" | "AudioBook"
+	Path              string            `json:"Path"`
+	ProductionYear    int               `json:"ProductionYear"`
+	SeriesName        string            `json:"SeriesName"`
+	IndexNumber       int               `json:"IndexNumber"`       // numéro de saison d'une saison, d'épisode d'un épisode
+	ParentIndexNumber int               `json:"ParentIndexNumber"` // saison d'un épisode
+	Album             string            `json:"Album"`
+	AlbumArtist       string            `json:"AlbumArtist"`
+	Artists           []string          `json:"Artists"`
+	ProviderIds       map[string]string `json:"ProviderIds"`
+}
+
+func (h *Handler) handleEmby(c *gin.Context) {
+	if !validToken(embyToken(c), h.embyToken) {
+		h.logger.Warn("emby webhook token invalid")
+		h.observe("emby", "", "unauthorized")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		return
+	}
+
+	// Emby envoie du JSON, ou un formulaire multipart avec un champ "data"
+	// selon la version et le format choisi dans la notification.
+	var body []byte
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		body = []byte(c.PostForm("data"))
+	} else {
+		var err error
+		if body, err = c.GetRawData(); err != nil {
+			h.observe("emby", "", "invalid")
+			c.JSON(http.StatusBadRequest, gin.H{"error": "cannot read body"})
+			return
+		}
+	}
+
+	var event EmbyEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		h.logger.Error("failed to decode emby event", zap.Error(err))
+		h.observe("emby", "", "invalid")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
+		return
+	}
+
+	h.logger.Info("emby event received",
+		zap.String("event", event.Event),
+		zap.String("item_type", event.Item.Type),
+		zap.String("title", event.Item.Name),
+	)
+
+	if !isEvent(event.Event, "library.deleted", "item.deleted") {
+		h.observe("emby", event.Event, "ignored")
+		c.JSON(http.StatusOK, gin.H{"status": "ignored"})
+		return
+	}
+
+	h.enqueue(c, event.mediaDeleted())
+}
+
+// embyToken lit le token dans l'en-tête Authorization: Bearer, ou à
+// défaut dans l'URL pour les versions d'Emby sans en-têtes personnalisés.
+func embyToken(c *gin.Context) string {
+	if token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer "); ok {
+		return strings.TrimSpace(token)
+	}
+	return c.Query("token")
+}
+
+// mediaDeleted normalise l'événement Emby.
+func (e EmbyEvent) mediaDeleted() MediaDeleted {
+	item := e.Item
+	event := MediaDeleted{
+		Source:                  "emby",
+		ItemType:                strings.ToLower(item.Type),
+		Title:                   item.Name,
+		Year:                    item.ProductionYear,
+		Path:                    item.Path,
+		SeriesName:              item.SeriesName,
+		Album:                   item.Album,
+		Artist:                  item.AlbumArtist,
+		TmdbID:                  providerID(item.ProviderIds, "Tmdb"),
+		ImdbID:                  providerID(item.ProviderIds, "Imdb"),
+		TvdbID:                  providerID(item.ProviderIds, "Tvdb"),
+		MusicBrainzReleaseGroup: providerID(item.ProviderIds, "MusicBrainzReleaseGroup"),
+	}
+	if event.Artist == "" && len(item.Artists) > 0 {
+		event.Artist = item.Artists[0]
+	}
+
+	switch event.ItemType {
+	case "season":
+		event.SeasonNumber = item.IndexNumber
+	case "episode":
+		event.SeasonNumber, event.EpisodeNumber = item.ParentIndexNumber, item.IndexNumber
+	}
+	return event
+}
+
+// providerID lit un ID externe sans tenir compte de la casse de la clé,
+// qui varie selon les plugins de métadonnées.
+func providerID(ids map[string]string, key string) string {
+	for k, v := range ids {
+		if strings.EqualFold(k, key) {
+			return v
+		}
+	}
+	return ""
+}
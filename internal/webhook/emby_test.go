@@ -0,0 +1,98 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+func TestEmbyEvent_MediaDeleted(t *testing.T) {
+	tests := []struct {
+		fixture string
+		want    MediaDeleted
+	}{
+		{"testdata/emby_episode.json", MediaDeleted{
+			Source: "emby", ItemType: "episode", Title: "Ozymandias", Year: 2013,
+			Path:       "/tv/Breaking Bad/Season 05/Breaking Bad - S05E14 - Ozymandias.mkv",
+			SeriesName: "Breaking Bad", SeasonNumber: 5, EpisodeNumber: 14,
+			ImdbID: "tt2301451", TvdbID: "4639473",
+		}},
+		{"testdata/emby_album.json", MediaDeleted{
+			Source: "emby", ItemType: "musicalbum", Title: "OK Computer", Year: 1997,
+			Path:   "/music/Radiohead/OK Computer (1997)",
+			Artist: "Radiohead",
+
+			MusicBrainzReleaseGroup: "b1392450-e666-3926-a536-22c65f834433",
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			raw, err := os.ReadFile(tt.fixture)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var event EmbyEvent
+			if err := json.Unmarshal(raw, &event); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if got := event.mediaDeleted(); got != tt.want {
+				t.Fatalf("mediaDeleted() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEmbyWebhook(t *testing.T) {
+	payload, err := os.ReadFile("testdata/emby_episode.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, queued := newTestServer(t, "", WithEmby("s3cret"))
+
+	req := httptest.NewRequest(http.MethodPost, "/webhook/emby", bytes.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	if w := serve(r, req); w.Code != http.StatusUnauthorized {
+		t.Fatalf("missing token: status = %d, want 401", w.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/webhook/emby", bytes.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer s3cret")
+	if w := serve(r, req); w.Code != http.StatusOK {
+		t.Fatalf("bearer token: status = %d, body %s", w.Code, w.Body)
+	}
+	if got := waitQueued(t, queued); got.Source != "emby" || got.EpisodeNumber != 14 {
+		t.Fatalf("queued %+v", got)
+	}
+}
+
+func TestEmbyWebhook_Multipart(t *testing.T) {
+	payload, err := os.ReadFile("testdata/emby_album.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, queued := newTestServer(t, "", WithEmby("s3cret"))
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	if err := form.WriteField("data", string(payload)); err != nil {
+		t.Fatal(err)
+	}
+	if err := form.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/webhook/emby?token=s3cret", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	if w := serve(r, req); w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body)
+	}
+	if got := waitQueued(t, queued); got.ItemType != "musicalbum" || got.Artist != "Radiohead" {
+		t.Fatalf("queued %+v", got)
+	}
+}
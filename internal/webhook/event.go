@@ -0,0 +1,102 @@
+package webhook
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// ─── Media Deleted ────────────────────────────────────────────────────
+
+// MediaDeleted est une suppression de média, normalisée quel que soit le
+// serveur qui l'a signalée. C'est elle qui est persistée dans la file et
+// routée vers Radarr/Sonarr/Lidarr/Readarr.
+type MediaDeleted struct {
+	Source        string `json:"source"`    // "jellyfin" | "emby" | "plex"
+	ItemType      string `json:"item_type"` // "movie" | "episode" | "season" | "series" | "musicalbum" | "audio" | "book" | "audiobook"
+	Title         string `json:"title"`
+	Year          int    `json:"year,omitempty"`
+	Path          string `json:"path,omitempty"`
+	SeriesName    string `json:"series_name,omitempty"`
+	SeasonNumber  int    `json:"season_number,omitempty"`
+	EpisodeNumber int    `json:"episode_number,omitempty"`
+	Album         string `json:"album,omitempty"`  // album d'une piste
+	Artist        string `json:"artist,omitempty"` // artiste d'un album ou d'une piste, auteur d'un livre
+	TmdbID        string `json:"tmdb_id,omitempty"`
+	ImdbID        string `json:"imdb_id,omitempty"`
+	TvdbID        string `json:"tvdb_id,omitempty"`
+
+	MusicBrainzReleaseGroup string `json:"musicbrainz_release_group,omitempty"`
+}
+
+// enqueue persiste la suppression avant de répondre : elle survit à un
+// redémarrage de clarr et est réessayée si Radarr/Sonarr est indisponible.
+func (h *Handler) enqueue(c *gin.Context, event MediaDeleted) {
+	jobID, err := h.queue.Enqueue(jobMediaDeleted, event)
+	if err != nil {
+		h.logger.Error("failed to queue deleted media",
+			zap.String("source", event.Source),
+			zap.Error(err),
+		)
+		h.observe(event.Source, event.ItemType, "failed")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "cannot queue event"})
+		return
+	}
+
+	h.observe(event.Source, event.ItemType, "queued")
+	c.JSON(http.StatusOK, gin.H{"status": "queued", "job_id": jobID})
+}
+
+// observe compte un webhook reçu si les métriques sont activées. Le type
+// est le type d'élément d'une suppression, sinon l'événement reçu ; il est
+// vide quand la requête est refusée avant d'être lue.
+func (h *Handler) observe(source, eventType, result string) {
+	if h.metrics != nil {
+		h.metrics.WebhookEvent(source, metricType(eventType), result)
+	}
+}
+
+// metricTypes liste les types d'élément et d'événement exposés tels quels
+// en label Prometheus.
+var metricTypes = map[string]bool{
+	// Types d'élément routés vers les *arr.
+	"movie": true, "episode": true, "season": true, "series": true,
+	"musicalbum": true, "audio": true, "book": true, "audiobook": true,
+	// Événements des serveurs de médias.
+	"library.deleted": true, "item.deleted": true, "media.deleted": true,
+	"playback.stop": true,
+	// Événements des *arr.
+	"test": true, "grab": true, "download": true, "rename": true,
+	"moviefiledelete": true, "episodefiledelete": true,
+	"moviedelete": true, "seriesdelete": true,
+	"health": true, "healthrestored": true, "applicationupdate": true,
+}
+
+// metricType ramène le type reçu à un label connu. Il vient du client :
+// l'exposer tel quel laisserait n'importe qui créer autant de séries
+// Prometheus qu'il le souhaite.
+func metricType(eventType string) string {
+	t := strings.ToLower(eventType)
+	if t == "" || metricTypes[t] {
+		return t
+	}
+	return "other"
+}
+
+// isEvent indique si event fait partie de events, sans tenir compte de la casse.
+func isEvent(event string, events ...string) bool {
+	for _, e := range events {
+		if strings.EqualFold(event, e) {
+			return true
+		}
+	}
+	return false
+}
+
+// validToken compare le token reçu à celui configuré en temps constant.
+func validToken(got, want string) bool {
+	return got != "" && subtle.ConstantTimeCompare([]byte(got), []byte(want)) == 1
+}
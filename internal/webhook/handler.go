@@ -9,7 +9,13 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/cleeryy/clarr/internal/audit"
+	"github.com/cleeryy/clarr/internal/jobs"
+	"github.com/cleeryy/clarr/internal/lidarr"
+	"github.com/cleeryy/clarr/internal/metrics"
+	"github.com/cleeryy/clarr/internal/queue"
 	"github.com/cleeryy/clarr/internal/radarr"
+	"github.com/cleeryy/clarr/internal/readarr"
 	"github.com/cleeryy/clarr/internal/sonarr"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
@@ -18,35 +24,127 @@ import (
 // ─── Models ───────────────────────────────────────────────────────────
 
 type JellyfinEvent struct {
-	Event        string `json:"Event"`
-	Title        string `json:"Title"`
-	ItemID       string `json:"ItemId"`
-	ItemType     string `json:"ItemType"` // "Movie" | "Episode" | "Series"
-	SeriesName   string `json:"SeriesName"`
-	SeasonNumber int    `json:"SeasonNumber"`
+	Event         string `json:"Event"`
+	Title         string `json:"Title"`
+	ItemID        string `json:"ItemId"`
+	ItemType      string `json:"ItemType"` // "Movie" | "Episode" | "Season" | "Series" | "MusicAlbum" | "Audio" | "Book" | "AudioBook"
+	Year          int    `json:"Year"`
+	Path          string `json:"Path"`
+	SeriesName    string `json:"SeriesName"`
+	SeasonNumber  int    `json:"SeasonNumber"`
+	EpisodeNumber int    `json:"EpisodeNumber"`
+	Album         string `json:"Album"`  // album d'une piste Audio
+	Artist        string `json:"Artist"` // artiste d'un album, auteur d'un livre
+	ProviderTmdb  string `json:"Provider_tmdb"`
+	ProviderImdb  string `json:"Provider_imdb"`
+	ProviderTvdb  string `json:"Provider_tvdb"`
+
+	ProviderMusicBrainzReleaseGroup string `json:"Provider_musicbrainzreleasegroup"`
 }
 
 // ─── Handler ──────────────────────────────────────────────────────────
 
 type Handler struct {
-	secret string
-	radarr *radarr.Client
-	sonarr *sonarr.Client
-	logger *zap.Logger
+	secret    string
+	plexToken string
+	embyToken string
+	sweep     bool
+
+	arrUsername string
+	arrPassword string
+	reclaimer   Reclaimer
+	tracker     *jobs.Tracker
+
+	radarr  []Instance[*radarr.Client]
+	sonarr  []Instance[*sonarr.Client]
+	lidarr  []Instance[*lidarr.Client]
+	readarr []Instance[*readarr.Client]
+	queue   *queue.Queue
+	audit   *audit.Log
+	metrics *metrics.Metrics
+	logger  *zap.Logger
 }
 
-func New(secret string, radarr *radarr.Client, sonarr *sonarr.Client, logger *zap.Logger) *Handler {
-	return &Handler{
+// Option configure un Handler à sa création.
+type Option func(*Handler)
+
+// WithLidarr traite les suppressions d'albums et de pistes via les
+// instances Lidarr données.
+func WithLidarr(instances ...Instance[*lidarr.Client]) Option {
+	return func(h *Handler) {
+		h.lidarr = instances
+	}
+}
+
+// WithReadarr traite les suppressions de livres et livres audio via les
+// instances Readarr données.
+func WithReadarr(instances ...Instance[*readarr.Client]) Option {
+	return func(h *Handler) {
+		h.readarr = instances
+	}
+}
+
+// WithPlex expose /webhook/plex, authentifié par token dans l'URL.
+func WithPlex(token string) Option {
+	return func(h *Handler) {
+		h.plexToken = token
+	}
+}
+
+// WithEmby expose /webhook/emby, authentifié par token.
+func WithEmby(token string) Option {
+	return func(h *Handler) {
+		h.embyToken = token
+	}
+}
+
+// WithAudit journalise chaque élément unmonitoré.
+func WithAudit(l *audit.Log) Option {
+	return func(h *Handler) {
+		h.audit = l
+	}
+}
+
+// WithMetrics compte les webhooks reçus par source, type et résultat.
+func WithMetrics(m *metrics.Metrics) Option {
+	return func(h *Handler) {
+		h.metrics = m
+	}
+}
+
+// New crée le handler webhook et l'enregistre comme consommateur des
+// événements persistés dans q. Avec sweep = true, chaque suppression
+// déclenche l'ancien balayage global (unmonitor de tous les films
+// manquants et de toutes les séries vides) au lieu d'agir uniquement
+// sur l'élément supprimé. Chaque événement est routé vers les instances
+// dont un dossier racine contient le chemin supprimé (voir route).
+func New(secret string, sweep bool, radarr []Instance[*radarr.Client], sonarr []Instance[*sonarr.Client], q *queue.Queue, logger *zap.Logger, opts ...Option) *Handler {
+	h := &Handler{
 		secret: secret,
+		sweep:  sweep,
 		radarr: radarr,
 		sonarr: sonarr,
+		queue:  q,
 		logger: logger,
 	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	q.Handle(jobMediaDeleted, h.process)
+	q.Handle(jobReleaseDeleted, h.processRelease)
+	return h
 }
 
 // Register enregistre les routes webhook sur le router Gin.
 func (h *Handler) Register(r *gin.Engine) {
 	r.POST("/webhook/jellyfin", h.handleJellyfin)
+	if h.plexToken != "" {
+		r.POST("/webhook/plex", h.handlePlex)
+	}
+	if h.embyToken != "" {
+		r.POST("/webhook/emby", h.handleEmby)
+	}
+	h.registerArr(r)
 }
 
 // ─── Routes ───────────────────────────────────────────────────────────
@@ -56,6 +154,7 @@ func (h *Handler) handleJellyfin(c *gin.Context) {
 	if h.secret != "" {
 		if err := h.verifySignature(c); err != nil {
 			h.logger.Warn("webhook signature invalid", zap.Error(err))
+			h.observe("jellyfin", "", "unauthorized")
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid signature"})
 			return
 		}
@@ -64,6 +163,7 @@ func (h *Handler) handleJellyfin(c *gin.Context) {
 	var event JellyfinEvent
 	if err := json.NewDecoder(c.Request.Body).Decode(&event); err != nil {
 		h.logger.Error("failed to decode jellyfin event", zap.Error(err))
+		h.observe("jellyfin", "", "invalid")
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
 		return
 	}
@@ -76,101 +176,31 @@ func (h *Handler) handleJellyfin(c *gin.Context) {
 
 	// On ne traite que les suppressions.
 	if !isDeleteEvent(event.Event) {
+		h.observe("jellyfin", event.Event, "ignored")
 		c.JSON(http.StatusOK, gin.H{"status": "ignored"})
 		return
 	}
 
-	go h.dispatch(event)
-
-	c.JSON(http.StatusOK, gin.H{"status": "processing"})
-}
-
-// ─── Dispatch ─────────────────────────────────────────────────────────
-
-func (h *Handler) dispatch(event JellyfinEvent) {
-	switch strings.ToLower(event.ItemType) {
-	case "movie":
-		h.handleMovieDeleted(event)
-	case "episode", "series":
-		h.handleSeriesDeleted(event)
-	default:
-		h.logger.Warn("unknown item type",
-			zap.String("item_type", event.ItemType),
-			zap.String("title", event.Title),
-		)
-	}
-}
-
-func (h *Handler) handleMovieDeleted(event JellyfinEvent) {
-	h.logger.Info("processing deleted movie",
-		zap.String("title", event.Title),
-	)
-
-	// Force rescan Radarr pour détecter hasFile == false.
-	if err := h.radarr.RescanAll(); err != nil {
-		h.logger.Error("radarr rescan failed",
-			zap.String("title", event.Title),
-			zap.Error(err),
-		)
-		return
-	}
-
-	// Récupère les films sans fichier et les unmonitor.
-	missing, err := h.radarr.GetMissingMovies()
-	if err != nil {
-		h.logger.Error("radarr get missing movies failed", zap.Error(err))
-		return
-	}
-
-	for _, m := range missing {
-		if err := h.radarr.UnmonitorMovie(m.ID); err != nil {
-			h.logger.Error("radarr unmonitor failed",
-				zap.String("title", m.Title),
-				zap.Error(err),
-			)
-			continue
-		}
-		h.logger.Info("radarr movie unmonitored",
-			zap.String("title", m.Title),
-			zap.Int("id", m.ID),
-		)
-	}
+	h.enqueue(c, event.mediaDeleted())
 }
 
-func (h *Handler) handleSeriesDeleted(event JellyfinEvent) {
-	h.logger.Info("processing deleted series/episode",
-		zap.String("title", event.Title),
-		zap.String("series", event.SeriesName),
-	)
-
-	// Force rescan Sonarr.
-	if err := h.sonarr.RescanAll(); err != nil {
-		h.logger.Error("sonarr rescan failed",
-			zap.String("title", event.Title),
-			zap.Error(err),
-		)
-		return
-	}
-
-	// Récupère les séries vides et les unmonitor.
-	empty, err := h.sonarr.GetEmptySeries()
-	if err != nil {
-		h.logger.Error("sonarr get empty series failed", zap.Error(err))
-		return
-	}
-
-	for _, s := range empty {
-		if err := h.sonarr.UnmonitorSeries(s.ID); err != nil {
-			h.logger.Error("sonarr unmonitor failed",
-				zap.String("title", s.Title),
-				zap.Error(err),
-			)
-			continue
-		}
-		h.logger.Info("sonarr series unmonitored",
-			zap.String("title", s.Title),
-			zap.Int("id", s.ID),
-		)
+// mediaDeleted normalise l'événement Jellyfin.
+func (e JellyfinEvent) mediaDeleted() MediaDeleted {
+	return MediaDeleted{
+		Source:                  "jellyfin",
+		ItemType:                strings.ToLower(e.ItemType),
+		Title:                   e.Title,
+		Year:                    e.Year,
+		Path:                    e.Path,
+		SeriesName:              e.SeriesName,
+		SeasonNumber:            e.SeasonNumber,
+		EpisodeNumber:           e.EpisodeNumber,
+		Album:                   e.Album,
+		Artist:                  e.Artist,
+		TmdbID:                  e.ProviderTmdb,
+		ImdbID:                  e.ProviderImdb,
+		TvdbID:                  e.ProviderTvdb,
+		MusicBrainzReleaseGroup: e.ProviderMusicBrainzReleaseGroup,
 	}
 }
 
@@ -203,15 +233,9 @@ func (h *Handler) verifySignature(c *gin.Context) error {
 // ─── Helpers ──────────────────────────────────────────────────────────
 
 func isDeleteEvent(event string) bool {
-	deleteEvents := []string{
+	return isEvent(event,
 		"library.deleted",
 		"item.deleted",
 		"playback.stop",
-	}
-	for _, e := range deleteEvents {
-		if strings.EqualFold(event, e) {
-			return true
-		}
-	}
-	return false
+	)
 }
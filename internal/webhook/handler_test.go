@@ -0,0 +1,119 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cleeryy/clarr/internal/metrics"
+	"github.com/cleeryy/clarr/internal/queue"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// newTestServer monte le handler sur un routeur Gin et capture les
+// suppressions mises en file au lieu de les traiter.
+func newTestServer(t *testing.T, secret string, opts ...Option) (*gin.Engine, <-chan MediaDeleted) {
+	t.Helper()
+
+	q, err := queue.Open(t.TempDir(), queue.Options{Workers: 1, MaxAttempts: 1, RetryDelay: time.Millisecond}, zap.NewNop())
+	if err != nil {
+		t.Fatalf("open queue: %v", err)
+	}
+
+	h := New(secret, false, nil, nil, q, zap.NewNop(), opts...)
+
+	queued := make(chan MediaDeleted, 1)
+	q.Handle(jobMediaDeleted, func(_ context.Context, payload json.RawMessage) error {
+		var event MediaDeleted
+		if err := json.Unmarshal(payload, &event); err != nil {
+			return queue.Permanent(err)
+		}
+		queued <- event
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := q.Start(ctx); err != nil {
+		t.Fatalf("start queue: %v", err)
+	}
+	t.Cleanup(func() {
+		cancel()
+		_ = q.Close()
+	})
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	h.Register(r)
+	return r, queued
+}
+
+func serve(r *gin.Engine, req *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+// waitQueued retourne la suppression mise en file par la requête.
+func waitQueued(t *testing.T, queued <-chan MediaDeleted) MediaDeleted {
+	t.Helper()
+	select {
+	case event := <-queued:
+		return event
+	case <-time.After(5 * time.Second):
+		t.Fatal("no deleted media queued")
+		return MediaDeleted{}
+	}
+}
+
+func TestJellyfinQueuesMediaDeleted(t *testing.T) {
+	r, queued := newTestServer(t, "")
+
+	body := `{"Event":"library.deleted","Title":"Dune","ItemType":"Movie","Year":2021,"Provider_tmdb":"438631"}`
+	req := httptest.NewRequest(http.MethodPost, "/webhook/jellyfin", strings.NewReader(body))
+	if w := serve(r, req); w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body)
+	}
+
+	got := waitQueued(t, queued)
+	want := MediaDeleted{Source: "jellyfin", ItemType: "movie", Title: "Dune", Year: 2021, TmdbID: "438631"}
+	if got != want {
+		t.Fatalf("queued %+v, want %+v", got, want)
+	}
+}
+
+func TestWebhookMetrics(t *testing.T) {
+	m := metrics.New()
+	r, queued := newTestServer(t, "", WithMetrics(m), WithEmby("s3cret"))
+	m.Register(r)
+
+	post := func(target, body string) {
+		serve(r, httptest.NewRequest(http.MethodPost, target, strings.NewReader(body)))
+	}
+
+	post("/webhook/jellyfin", `{"Event":"library.deleted","Title":"Dune","ItemType":"Movie"}`)
+	waitQueued(t, queued)
+	post("/webhook/jellyfin", `{"Event":"ItemAdded","Title":"Dune","ItemType":"Movie"}`)
+	post("/webhook/jellyfin", `{"Event":"forged-9f3c2a","Title":"Dune","ItemType":"Movie"}`)
+	post("/webhook/jellyfin", `{"Event":"library.deleted","Title":"?","ItemType":"Custom42"}`)
+	waitQueued(t, queued)
+	post("/webhook/jellyfin", `not json`)
+	post("/webhook/emby?token=forged", `{"Event":"library.deleted"}`)
+
+	w := serve(r, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	for _, want := range []string{
+		`clarr_webhook_events_total{result="queued",source="jellyfin",type="movie"} 1`,
+		`clarr_webhook_events_total{result="ignored",source="jellyfin",type="other"} 2`,
+		`clarr_webhook_events_total{result="queued",source="jellyfin",type="other"} 1`,
+		`clarr_webhook_events_total{result="invalid",source="jellyfin",type=""} 1`,
+		`clarr_webhook_events_total{result="unauthorized",source="emby",type=""} 1`,
+	} {
+		if !strings.Contains(w.Body.String(), want) {
+			t.Errorf("/metrics does not contain %q", want)
+		}
+	}
+}
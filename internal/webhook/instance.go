@@ -0,0 +1,115 @@
+package webhook
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/cleeryy/clarr/internal/queue"
+	"github.com/cleeryy/clarr/internal/radarr"
+	"github.com/cleeryy/clarr/internal/sonarr"
+	"go.uber.org/zap"
+)
+
+// ─── Instances ────────────────────────────────────────────────────────
+
+// Instance est une instance nommée d'une application *arr, par exemple
+// "radarr-4k" à côté de "radarr".
+type Instance[C any] struct {
+	Name   string
+	Client C
+}
+
+// route retourne les instances concernées par un élément situé sous path :
+// celles dont un dossier racine contient path. Une instance dont les
+// dossiers racine sont illisibles est gardée, pour ne pas perdre
+// l'événement ; si aucune ne correspond, toutes sont interrogées et la
+// résolution par ID externe ou titre départage.
+func route[C any](logger *zap.Logger, instances []Instance[C], p string, rootFolders func(C) ([]string, error)) []Instance[C] {
+	if p == "" || len(instances) < 2 {
+		return instances
+	}
+
+	var matched []Instance[C]
+	for _, inst := range instances {
+		roots, err := rootFolders(inst.Client)
+		if err != nil {
+			logger.Warn("cannot read root folders, instance kept",
+				zap.String("instance", inst.Name),
+				zap.Error(err),
+			)
+			matched = append(matched, inst)
+			continue
+		}
+		for _, root := range roots {
+			if pathWithin(p, root) {
+				matched = append(matched, inst)
+				break
+			}
+		}
+	}
+	if len(matched) == 0 {
+		return instances
+	}
+	return matched
+}
+
+// fanOut applique fn à chaque instance. matched indique si au moins une
+// instance connaissait l'élément (fn n'a pas retourné errNoMatch).
+//
+// Les erreurs sont préfixées par le nom de l'instance. Si l'une d'elles
+// est transitoire, seules les transitoires sont retournées pour que le
+// job soit réessayé : fn est idempotent, rejouer les instances déjà
+// traitées est sans effet. Les erreurs définitives sont alors seulement
+// journalisées, sinon elles enverraient le job en dead-letter.
+func fanOut[C any](logger *zap.Logger, instances []Instance[C], fn func(Instance[C]) error) (matched bool, err error) {
+	var transient, permanent []error
+	for _, inst := range instances {
+		err := fn(inst)
+		if errors.Is(err, errNoMatch) {
+			continue
+		}
+		matched = true
+		if err == nil {
+			continue
+		}
+
+		err = fmt.Errorf("%s: %w", inst.Name, err)
+		if queue.IsPermanent(classify(err)) {
+			permanent = append(permanent, err)
+		} else {
+			transient = append(transient, err)
+		}
+	}
+
+	if len(transient) == 0 {
+		return matched, errors.Join(permanent...)
+	}
+	for _, err := range permanent {
+		logger.Error("instance failed permanently, retrying the others", zap.Error(err))
+	}
+	return matched, errors.Join(transient...)
+}
+
+// ─── Targets ──────────────────────────────────────────────────────────
+
+func (h *Handler) radarrTargets(p string) []Instance[*radarr.Client] {
+	return route(h.logger, h.radarr, p, func(c *radarr.Client) ([]string, error) {
+		folders, err := c.GetRootFolders()
+		return rootPaths(folders, func(f radarr.RootFolder) string { return f.Path }), err
+	})
+}
+
+func (h *Handler) sonarrTargets(p string) []Instance[*sonarr.Client] {
+	return route(h.logger, h.sonarr, p, func(c *sonarr.Client) ([]string, error) {
+		folders, err := c.GetRootFolders()
+		return rootPaths(folders, func(f sonarr.RootFolder) string { return f.Path }), err
+	})
+}
+
+func rootPaths[F any](folders []F, path func(F) string) []string {
+	paths := make([]string, 0, len(folders))
+	for _, f := range folders {
+		paths = append(paths, path(f))
+	}
+	return paths
+}
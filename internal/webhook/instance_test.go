@@ -0,0 +1,108 @@
+package webhook
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/cleeryy/clarr/internal/queue"
+	"github.com/cleeryy/clarr/internal/radarr"
+	"go.uber.org/zap"
+)
+
+func names[C any](instances []Instance[C]) []string {
+	var out []string
+	for _, inst := range instances {
+		out = append(out, inst.Name)
+	}
+	return out
+}
+
+func TestRoute(t *testing.T) {
+	roots := map[string][]string{
+		"radarr":    {"/movies"},
+		"radarr-4k": {"/movies-4k", "/uhd"},
+		"broken":    nil,
+	}
+	rootFolders := func(name string) ([]string, error) {
+		if name == "broken" {
+			return nil, errors.New("unreachable")
+		}
+		return roots[name], nil
+	}
+	instances := []Instance[string]{
+		{Name: "radarr", Client: "radarr"},
+		{Name: "radarr-4k", Client: "radarr-4k"},
+	}
+
+	tests := []struct {
+		name      string
+		instances []Instance[string]
+		path      string
+		want      []string
+	}{
+		{"by root folder", instances, "/movies-4k/Dune (2021)/Dune.mkv", []string{"radarr-4k"}},
+		{"root is a segment", instances, "/movies/Dune (2021)/Dune.mkv", []string{"radarr"}},
+		{"no path", instances, "", []string{"radarr", "radarr-4k"}},
+		{"no root matches", instances, "/elsewhere/Dune.mkv", []string{"radarr", "radarr-4k"}},
+		{"unreadable roots kept", append(instances, Instance[string]{Name: "broken", Client: "broken"}), "/uhd/Dune.mkv", []string{"radarr-4k", "broken"}},
+		{"single instance", instances[:1], "/elsewhere/Dune.mkv", []string{"radarr"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := names(route(zap.NewNop(), tt.instances, tt.path, rootFolders))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("route() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFanOut(t *testing.T) {
+	instances := []Instance[string]{
+		{Name: "a", Client: "a"},
+		{Name: "b", Client: "b"},
+	}
+	badRequest := &radarr.APIError{StatusCode: 400, Method: "PUT", Endpoint: "/api/v3/movie/editor"}
+	unavailable := &radarr.APIError{StatusCode: 503, Method: "GET", Endpoint: "/api/v3/movie"}
+	unreachable := dialError(t)
+
+	tests := []struct {
+		name          string
+		errs          map[string]error
+		wantMatched   bool
+		wantErr       bool
+		wantPermanent bool
+	}{
+		{"none knows the item", map[string]error{"a": errNoMatch, "b": errNoMatch}, false, false, false},
+		{"one knows the item", map[string]error{"a": errNoMatch}, true, false, false},
+		{"permanent failure", map[string]error{"a": badRequest}, true, true, true},
+		{"transient wins over permanent", map[string]error{"a": badRequest, "b": unavailable}, true, true, false},
+		{"unreachable instance", map[string]error{"a": unreachable}, true, true, false},
+		{"unreachable wins over permanent", map[string]error{"a": badRequest, "b": unreachable}, true, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var called []string
+			matched, err := fanOut(zap.NewNop(), instances, func(inst Instance[string]) error {
+				called = append(called, inst.Name)
+				return tt.errs[inst.Name]
+			})
+
+			if len(called) != len(instances) {
+				t.Fatalf("called %v, want every instance", called)
+			}
+			if matched != tt.wantMatched {
+				t.Errorf("matched = %v, want %v", matched, tt.wantMatched)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := queue.IsPermanent(classify(err)); err != nil && got != tt.wantPermanent {
+				t.Errorf("permanent = %v, want %v (err: %v)", got, tt.wantPermanent, err)
+			}
+		})
+	}
+}
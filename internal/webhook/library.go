@@ -0,0 +1,196 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/cleeryy/clarr/internal/arr"
+	"go.uber.org/zap"
+)
+
+// ─── Libraries ────────────────────────────────────────────────────────
+
+// library est une instance Lidarr ou Readarr vue comme une bibliothèque
+// d'éléments T (albums, livres) rattachés à un parent (artiste, auteur).
+// Ces applications ne monitorent que l'élément : il n'est unmonitoré
+// qu'une fois tous ses fichiers supprimés.
+type library[T any] interface {
+	Items() ([]T, error)
+	Item(id int) (*T, error)
+	MissingItems() ([]T, error)
+	Unmonitor(ids []int) error
+	RescanParent(item *T) (int, error)
+	RescanAll() (int, error)
+	WaitForCommand(ctx context.Context, commandID int) (*arr.CommandStatus, error)
+	GetRootFolders() ([]arr.RootFolder, error)
+	Describe(item *T) libraryItem
+}
+
+// libraryItem est ce que clarr lit d'un album ou d'un livre.
+type libraryItem struct {
+	ID        int
+	Title     string
+	Parent    string // nom de l'artiste ou de l'auteur
+	Monitored bool
+	Files     int // fichiers encore présents
+}
+
+// libraryApp décrit Lidarr ou Readarr pour les logs, l'audit et la
+// résolution d'une suppression.
+type libraryApp[T any] struct {
+	name    string // "lidarr", "readarr"
+	kind    string // "album", "book"
+	resolve func(MediaDeleted, []T) (*T, string, error)
+}
+
+// libraries présente des instances comme des bibliothèques.
+func libraries[C any, T any](instances []Instance[C], wrap func(C) library[T]) []Instance[library[T]] {
+	out := make([]Instance[library[T]], 0, len(instances))
+	for _, inst := range instances {
+		out = append(out, Instance[library[T]]{Name: inst.Name, Client: wrap(inst.Client)})
+	}
+	return out
+}
+
+// handleLibraryDeleted traite la suppression d'un élément : il est
+// unmonitoré dans les instances qui le connaissent, ou toute la
+// bibliothèque est balayée en mode sweep.
+func handleLibraryDeleted[T any](ctx context.Context, h *Handler, app libraryApp[T], instances []Instance[library[T]], event MediaDeleted) error {
+	if len(instances) == 0 {
+		h.logger.Info(app.name+" not configured, event ignored",
+			zap.String("item_type", event.ItemType),
+			zap.String("title", event.Title),
+		)
+		return nil
+	}
+
+	fields := []zap.Field{
+		zap.String("item_type", event.ItemType),
+		zap.String("title", event.Title),
+		zap.String("artist", event.Artist),
+	}
+	if event.Album != "" {
+		fields = append(fields, zap.String("album", event.Album))
+	}
+	h.logger.Info("processing deleted "+app.kind, fields...)
+
+	if h.sweep {
+		_, err := fanOut(h.logger, instances, func(inst Instance[library[T]]) error {
+			return sweepLibrary(ctx, h, app, inst)
+		})
+		return err
+	}
+
+	targets := route(h.logger, instances, event.Path, func(c library[T]) ([]string, error) {
+		folders, err := c.GetRootFolders()
+		return rootPaths(folders, func(f arr.RootFolder) string { return f.Path }), err
+	})
+	matched, err := fanOut(h.logger, targets, func(inst Instance[library[T]]) error {
+		return unmonitorLibraryItem(ctx, h, app, inst, event)
+	})
+	if !matched {
+		return h.unresolved(app.name+" "+app.kind+" not resolved", event, errNoMatch)
+	}
+	return err
+}
+
+// unmonitorLibraryItem unmonitor l'élément supprimé dans une instance,
+// s'il la connaît et qu'il n'a plus aucun fichier.
+func unmonitorLibraryItem[T any](ctx context.Context, h *Handler, app libraryApp[T], inst Instance[library[T]], event MediaDeleted) error {
+	items, err := inst.Client.Items()
+	if err != nil {
+		return fmt.Errorf("%s get %ss: %w", app.name, app.kind, err)
+	}
+
+	found, matchedBy, err := app.resolve(event, items)
+	if errors.Is(err, errNoMatch) {
+		return err
+	}
+	if err != nil {
+		return h.unresolved(app.name+" "+app.kind+" not resolved", event, err)
+	}
+	item := inst.Client.Describe(found)
+
+	h.logger.Info(app.name+" "+app.kind+" resolved",
+		zap.String("instance", inst.Name),
+		zap.String("title", item.Title),
+		zap.Int("id", item.ID),
+		zap.String("matched_by", matchedBy),
+	)
+
+	// Force rescan du parent et attend sa fin.
+	rescan := func() (int, error) { return inst.Client.RescanParent(found) }
+	if err := waitCommand(ctx, rescan, inst.Client.WaitForCommand); err != nil {
+		return fmt.Errorf("%s rescan %q: %w", app.name, item.Parent, err)
+	}
+
+	found, err = inst.Client.Item(item.ID)
+	if err != nil {
+		return fmt.Errorf("%s get %s: %w", app.name, app.kind, err)
+	}
+	item = inst.Client.Describe(found)
+
+	if item.Files > 0 {
+		h.logger.Info(app.name+" "+app.kind+" still has files, left monitored",
+			zap.String("instance", inst.Name),
+			zap.String("title", item.Title),
+			zap.Int("id", item.ID),
+			zap.Int("files", item.Files),
+		)
+		return nil
+	}
+	if !item.Monitored {
+		return nil
+	}
+
+	err = inst.Client.Unmonitor([]int{item.ID})
+	h.recordUnmonitor(ctx, fmt.Sprintf("%s/%s/%s/%d", app.name, inst.Name, app.kind, item.ID), item.Title, err)
+	if err != nil {
+		return fmt.Errorf("%s unmonitor %q: %w", app.name, item.Title, err)
+	}
+	h.logger.Info(app.name+" "+app.kind+" unmonitored",
+		zap.String("instance", inst.Name),
+		zap.String("title", item.Title),
+		zap.Int("id", item.ID),
+	)
+	return nil
+}
+
+// sweepLibrary rescanne toute l'instance et unmonitor chaque élément sans
+// fichier.
+func sweepLibrary[T any](ctx context.Context, h *Handler, app libraryApp[T], inst Instance[library[T]]) error {
+	if err := waitCommand(ctx, inst.Client.RescanAll, inst.Client.WaitForCommand); err != nil {
+		return fmt.Errorf("%s rescan: %w", app.name, err)
+	}
+
+	missing, err := inst.Client.MissingItems()
+	if err != nil {
+		return fmt.Errorf("%s get missing %ss: %w", app.name, app.kind, err)
+	}
+
+	var ids []int
+	var titles []string
+	for i := range missing {
+		if item := inst.Client.Describe(&missing[i]); item.Monitored {
+			ids = append(ids, item.ID)
+			titles = append(titles, item.Title)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	err = inst.Client.Unmonitor(ids)
+	for i, id := range ids {
+		h.recordUnmonitor(ctx, fmt.Sprintf("%s/%s/%s/%d", app.name, inst.Name, app.kind, id), titles[i], err)
+	}
+	if err != nil {
+		return fmt.Errorf("%s unmonitor %ss %v: %w", app.name, app.kind, ids, err)
+	}
+	h.logger.Info(app.name+" "+app.kind+"s unmonitored",
+		zap.String("instance", inst.Name),
+		zap.Ints("ids", ids),
+	)
+	return nil
+}
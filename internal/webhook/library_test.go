@@ -0,0 +1,187 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/cleeryy/clarr/internal/lidarr"
+	"github.com/cleeryy/clarr/internal/queue"
+	"github.com/cleeryy/clarr/internal/readarr"
+	"go.uber.org/zap"
+)
+
+// fakeLibrary sert les éléments d'une API v1 (albums Lidarr, livres
+// Readarr) et enregistre les IDs unmonitorés.
+type fakeLibrary struct {
+	kind  string // "album" | "book"
+	items []map[string]any
+
+	mu         sync.Mutex
+	unmonitors [][]int
+}
+
+func (f *fakeLibrary) serve(t *testing.T) string {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/"+f.kind, func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(f.items)
+	})
+	mux.HandleFunc("GET /api/v1/"+f.kind+"/{id}", func(w http.ResponseWriter, r *http.Request) {
+		for _, item := range f.items {
+			if r.PathValue("id") == jsonID(item) {
+				_ = json.NewEncoder(w).Encode(item)
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+	})
+	mux.HandleFunc("POST /api/v1/command", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"id":7,"name":"Refresh","status":"queued"}`))
+	})
+	mux.HandleFunc("GET /api/v1/command/7", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"id":7,"name":"Refresh","status":"completed"}`))
+	})
+	mux.HandleFunc("PUT /api/v1/"+f.kind+"/monitor", func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]json.RawMessage
+		var ids []int
+		var monitored bool
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("decode monitor body: %v", err)
+		}
+		_ = json.Unmarshal(body[f.kind+"Ids"], &ids)
+		_ = json.Unmarshal(body["monitored"], &monitored)
+		if monitored {
+			t.Errorf("%ss %v monitored instead of unmonitored", f.kind, ids)
+		}
+		f.mu.Lock()
+		f.unmonitors = append(f.unmonitors, ids)
+		f.mu.Unlock()
+		w.WriteHeader(http.StatusAccepted)
+	})
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv.URL
+}
+
+func jsonID(item map[string]any) string {
+	data, _ := json.Marshal(item["id"])
+	return string(data)
+}
+
+func album(id int, title string, monitored bool, files int) map[string]any {
+	return map[string]any{
+		"id": id, "title": title, "artistId": 3, "monitored": monitored,
+		"artist":     map[string]any{"artistName": "Radiohead"},
+		"statistics": map[string]any{"trackFileCount": files},
+	}
+}
+
+func book(id int, title string, monitored bool, files int) map[string]any {
+	return map[string]any{
+		"id": id, "title": title, "authorId": 4, "monitored": monitored,
+		"author":     map[string]any{"authorName": "Ursula K. Le Guin"},
+		"statistics": map[string]any{"bookFileCount": files},
+	}
+}
+
+func TestProcess_Libraries(t *testing.T) {
+	albums := []map[string]any{
+		album(1, "Kid A", true, 0),
+		album(2, "OK Computer", true, 12),
+		album(3, "Amnesiac", false, 0),
+		album(4, "Hail to the Thief", true, 0),
+	}
+	books := []map[string]any{
+		book(5, "The Dispossessed", true, 0),
+		book(6, "The Lathe of Heaven", true, 2),
+	}
+
+	tests := []struct {
+		name           string
+		kind           string
+		items          []map[string]any
+		sweep          bool
+		event          MediaDeleted
+		wantUnmonitors [][]int
+	}{
+		{
+			name:           "album without files",
+			kind:           "album",
+			items:          albums,
+			event:          MediaDeleted{ItemType: "musicalbum", Title: "Kid A", Artist: "Radiohead"},
+			wantUnmonitors: [][]int{{1}},
+		},
+		{
+			name:  "track of an album with files left",
+			kind:  "album",
+			items: albums,
+			event: MediaDeleted{ItemType: "audio", Title: "Airbag", Album: "OK Computer", Artist: "Radiohead"},
+		},
+		{
+			name:  "album already unmonitored",
+			kind:  "album",
+			items: albums,
+			event: MediaDeleted{ItemType: "musicalbum", Title: "Amnesiac", Artist: "Radiohead"},
+		},
+		{
+			name:           "album sweep",
+			kind:           "album",
+			items:          albums,
+			sweep:          true,
+			event:          MediaDeleted{ItemType: "musicalbum", Title: "Kid A", Artist: "Radiohead"},
+			wantUnmonitors: [][]int{{1, 4}},
+		},
+		{
+			name:           "book without files",
+			kind:           "book",
+			items:          books,
+			event:          MediaDeleted{ItemType: "book", Title: "The Dispossessed", Artist: "Ursula K. Le Guin"},
+			wantUnmonitors: [][]int{{5}},
+		},
+		{
+			name:  "book with files left",
+			kind:  "book",
+			items: books,
+			event: MediaDeleted{ItemType: "audiobook", Title: "The Lathe of Heaven", Artist: "Ursula K. Le Guin"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeLibrary{kind: tt.kind, items: tt.items}
+			url := fake.serve(t)
+
+			var opt Option
+			if tt.kind == "album" {
+				opt = WithLidarr(Instance[*lidarr.Client]{Name: "default", Client: lidarr.New(url, "key")})
+			} else {
+				opt = WithReadarr(Instance[*readarr.Client]{Name: "default", Client: readarr.New(url, "key")})
+			}
+
+			q, err := queue.Open(t.TempDir(), queue.Options{}, zap.NewNop())
+			if err != nil {
+				t.Fatalf("open queue: %v", err)
+			}
+			t.Cleanup(func() { _ = q.Close() })
+			h := New("", tt.sweep, nil, nil, q, zap.NewNop(), opt)
+
+			tt.event.Source = "jellyfin"
+			payload, _ := json.Marshal(tt.event)
+			if err := h.process(context.Background(), payload); err != nil {
+				t.Fatalf("process: %v", err)
+			}
+
+			fake.mu.Lock()
+			defer fake.mu.Unlock()
+			if !slices.EqualFunc(fake.unmonitors, tt.wantUnmonitors, slices.Equal[[]int]) {
+				t.Errorf("unmonitored %ss = %v, want %v", tt.kind, fake.unmonitors, tt.wantUnmonitors)
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package webhook
+
+import (
+	"context"
+
+	"github.com/cleeryy/clarr/internal/lidarr"
+)
+
+// ─── Lidarr ───────────────────────────────────────────────────────────
+
+// lidarrApp décrit Lidarr pour handleLibraryDeleted.
+var lidarrApp = libraryApp[lidarr.Album]{name: "lidarr", kind: "album", resolve: resolveAlbum}
+
+// handleAlbumDeleted traite la suppression d'un album ou d'une piste.
+// Lidarr ne monitore pas les pistes : l'album n'est unmonitoré qu'une
+// fois tous ses fichiers supprimés.
+func (h *Handler) handleAlbumDeleted(ctx context.Context, event MediaDeleted) error {
+	return handleLibraryDeleted(ctx, h, lidarrApp, libraries(h.lidarr, newAlbums), event)
+}
+
+// albums présente un client Lidarr comme une bibliothèque d'albums.
+type albums struct {
+	*lidarr.Client
+}
+
+func newAlbums(c *lidarr.Client) library[lidarr.Album] { return albums{c} }
+
+func (c albums) Items() ([]lidarr.Album, error)        { return c.GetAllAlbums() }
+func (c albums) Item(id int) (*lidarr.Album, error)    { return c.GetAlbum(id) }
+func (c albums) MissingItems() ([]lidarr.Album, error) { return c.GetMissingAlbums() }
+func (c albums) Unmonitor(ids []int) error             { return c.UnmonitorAlbums(ids) }
+
+func (c albums) RescanParent(a *lidarr.Album) (int, error) {
+	return c.RescanArtist(a.ArtistID)
+}
+
+func (c albums) Describe(a *lidarr.Album) libraryItem {
+	return libraryItem{
+		ID:        a.ID,
+		Title:     a.Title,
+		Parent:    a.Artist.ArtistName,
+		Monitored: a.Monitored,
+		Files:     a.Statistics.TrackFileCount,
+	}
+}
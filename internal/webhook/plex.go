@@ -0,0 +1,122 @@
+package webhook
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// ─── Plex ─────────────────────────────────────────────────────────────
+
+// PlexEvent est le payload JSON d'un webhook Plex, envoyé dans le champ
+// "payload" d'un formulaire multipart.
+type PlexEvent struct {
+	Event    string       `json:"event"`
+	Metadata PlexMetadata `json:"Metadata"`
+}
+
+type PlexMetadata struct {
+	Type             string `json:"type"` // "movie" | "show" | "season" | "episode" | "artist" | "album" | "track"
+	Title            string `json:"title"`
+	ParentTitle      string `json:"parentTitle"`      // série d'une saison, saison d'un épisode, artiste d'un album, album d'une piste
+	GrandparentTitle string `json:"grandparentTitle"` // série d'un épisode, artiste d'une piste
+	Year             int    `json:"year"`
+	Index            int    `json:"index"`       // numéro de saison, d'épisode ou de piste
+	ParentIndex      int    `json:"parentIndex"` // saison d'un épisode
+
+	// Plex envoie à la fois "guid" (plex://...) et "Guid" (IDs externes) :
+	// les deux champs sont nécessaires, encoding/json ignorant la casse.
+	PlexGUID string     `json:"guid"`
+	GUIDs    []PlexGUID `json:"Guid"`
+}
+
+type PlexGUID struct {
+	ID string `json:"id"` // "imdb://tt1160419", "tmdb://438631", "tvdb://81189"
+}
+
+// plexTypes fait correspondre les types Plex aux types normalisés.
+var plexTypes = map[string]string{
+	"movie":   "movie",
+	"show":    "series",
+	"season":  "season",
+	"episode": "episode",
+	"album":   "musicalbum",
+	"track":   "audio",
+}
+
+func (h *Handler) handlePlex(c *gin.Context) {
+	// Plex ne sait ni signer ni ajouter d'en-tête : le token est dans l'URL.
+	if !validToken(c.Query("token"), h.plexToken) {
+		h.logger.Warn("plex webhook token invalid")
+		h.observe("plex", "", "unauthorized")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		return
+	}
+
+	var event PlexEvent
+	if err := json.Unmarshal([]byte(c.PostForm("payload")), &event); err != nil {
+		h.logger.Error("failed to decode plex event", zap.Error(err))
+		h.observe("plex", "", "invalid")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
+		return
+	}
+
+	h.logger.Info("plex event received",
+		zap.String("event", event.Event),
+		zap.String("item_type", event.Metadata.Type),
+		zap.String("title", event.Metadata.Title),
+	)
+
+	if !isEvent(event.Event, "library.deleted", "media.deleted") {
+		h.observe("plex", event.Event, "ignored")
+		c.JSON(http.StatusOK, gin.H{"status": "ignored"})
+		return
+	}
+
+	h.enqueue(c, event.mediaDeleted())
+}
+
+// mediaDeleted normalise l'événement Plex. Plex n'envoie pas le chemin
+// des fichiers : la résolution passe par les IDs externes et les titres.
+func (e PlexEvent) mediaDeleted() MediaDeleted {
+	m := e.Metadata
+	event := MediaDeleted{
+		Source:   "plex",
+		ItemType: m.Type,
+		Title:    m.Title,
+		Year:     m.Year,
+	}
+	if t, ok := plexTypes[m.Type]; ok {
+		event.ItemType = t
+	}
+
+	switch m.Type {
+	case "season":
+		event.SeriesName, event.SeasonNumber = m.ParentTitle, m.Index
+	case "episode":
+		event.SeriesName, event.SeasonNumber, event.EpisodeNumber = m.GrandparentTitle, m.ParentIndex, m.Index
+	case "album":
+		event.Artist = m.ParentTitle
+	case "track":
+		event.Album, event.Artist = m.ParentTitle, m.GrandparentTitle
+	}
+
+	for _, g := range m.GUIDs {
+		provider, id, ok := strings.Cut(g.ID, "://")
+		if !ok {
+			continue
+		}
+		switch provider {
+		case "tmdb":
+			event.TmdbID = id
+		case "imdb":
+			event.ImdbID = id
+		case "tvdb":
+			event.TvdbID = id
+		}
+	}
+	return event
+}
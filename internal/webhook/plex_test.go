@@ -0,0 +1,93 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+// plexRequest construit la requête multipart envoyée par Plex.
+func plexRequest(t *testing.T, target, fixture string) *http.Request {
+	t.Helper()
+
+	payload, err := os.ReadFile(fixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	if err := form.WriteField("payload", string(payload)); err != nil {
+		t.Fatal(err)
+	}
+	if err := form.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, target, &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	return req
+}
+
+func TestPlexEvent_MediaDeleted(t *testing.T) {
+	tests := []struct {
+		fixture string
+		want    MediaDeleted
+	}{
+		{"testdata/plex_movie.json", MediaDeleted{
+			Source: "plex", ItemType: "movie", Title: "Dune", Year: 2021,
+			TmdbID: "438631", ImdbID: "tt1160419", TvdbID: "14446",
+		}},
+		{"testdata/plex_episode.json", MediaDeleted{
+			Source: "plex", ItemType: "episode", Title: "Ozymandias", Year: 2013,
+			SeriesName: "Breaking Bad", SeasonNumber: 5, EpisodeNumber: 14,
+			TmdbID: "62161", ImdbID: "tt2301451", TvdbID: "4639473",
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			raw, err := os.ReadFile(tt.fixture)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var event PlexEvent
+			if err := json.Unmarshal(raw, &event); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if got := event.mediaDeleted(); got != tt.want {
+				t.Fatalf("mediaDeleted() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlexWebhook(t *testing.T) {
+	r, queued := newTestServer(t, "", WithPlex("s3cret"))
+
+	w := serve(r, plexRequest(t, "/webhook/plex?token=wrong", "testdata/plex_movie.json"))
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("wrong token: status = %d, want 401", w.Code)
+	}
+
+	w = serve(r, plexRequest(t, "/webhook/plex?token=s3cret", "testdata/plex_episode.json"))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body)
+	}
+	if got := waitQueued(t, queued); got.ItemType != "episode" || got.SeriesName != "Breaking Bad" {
+		t.Fatalf("queued %+v", got)
+	}
+}
+
+func TestPlexWebhook_DisabledWithoutToken(t *testing.T) {
+	r, _ := newTestServer(t, "")
+
+	w := serve(r, plexRequest(t, "/webhook/plex", "testdata/plex_movie.json"))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want 404", w.Code)
+	}
+}
@@ -0,0 +1,486 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/cleeryy/clarr/internal/arr"
+	"github.com/cleeryy/clarr/internal/audit"
+	"github.com/cleeryy/clarr/internal/queue"
+	"github.com/cleeryy/clarr/internal/radarr"
+	"github.com/cleeryy/clarr/internal/sonarr"
+	"go.uber.org/zap"
+)
+
+// jobMediaDeleted est le type de job des suppressions dans la file.
+const jobMediaDeleted = "media.deleted"
+
+// commandTimeout borne l'attente d'un rescan Radarr/Sonarr/Lidarr/Readarr.
+const commandTimeout = 5 * time.Minute
+
+// ─── Job ──────────────────────────────────────────────────────────────
+
+// process est le handler de job : il rejoue une suppression persistée.
+func (h *Handler) process(ctx context.Context, payload json.RawMessage) error {
+	var event MediaDeleted
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return queue.Permanent(fmt.Errorf("decode deleted media: %w", err))
+	}
+	return classify(h.dispatch(withTrigger(ctx, event.Source), event))
+}
+
+// withTrigger attache à ctx le déclencheur webhook:<source>:<job id>
+// journalisé par l'audit.
+func withTrigger(ctx context.Context, source string) context.Context {
+	id, _ := queue.JobID(ctx)
+	return audit.WithTrigger(ctx, audit.WebhookTrigger(source, id))
+}
+
+// classify marque comme définitives les erreurs qu'un retry ne corrigera
+// pas : une requête refusée en 4xx (hors 429) par une application *arr.
+// Les erreurs réseau (connexion refusée, DNS, timeout), les 5xx et les
+// rate limits restent transitoires : le job sera réessayé.
+func classify(err error) error {
+	status, ok := apiStatus(err)
+	if ok && status >= 400 && status < 500 && status != http.StatusTooManyRequests {
+		return queue.Permanent(err)
+	}
+	return err
+}
+
+// apiStatus retourne le statut HTTP d'une erreur d'API Radarr, Sonarr,
+// Lidarr ou Readarr.
+func apiStatus(err error) (int, bool) {
+	var (
+		radarrErr *radarr.APIError
+		sonarrErr *sonarr.APIError
+		v1Err     *arr.APIError // Lidarr et Readarr
+	)
+	switch {
+	case errors.As(err, &radarrErr):
+		return radarrErr.StatusCode, true
+	case errors.As(err, &sonarrErr):
+		return sonarrErr.StatusCode, true
+	case errors.As(err, &v1Err):
+		return v1Err.StatusCode, true
+	}
+	return 0, false
+}
+
+// ─── Dispatch ─────────────────────────────────────────────────────────
+
+func (h *Handler) dispatch(ctx context.Context, event MediaDeleted) error {
+	switch event.ItemType {
+	case "movie":
+		return h.handleMovieDeleted(ctx, event)
+	case "episode", "season", "series":
+		return h.handleSeriesDeleted(ctx, event)
+	case "musicalbum", "audio":
+		return h.handleAlbumDeleted(ctx, event)
+	case "book", "audiobook":
+		return h.handleBookDeleted(ctx, event)
+	default:
+		h.logger.Warn("unknown item type",
+			zap.String("source", event.Source),
+			zap.String("item_type", event.ItemType),
+			zap.String("title", event.Title),
+		)
+		return nil
+	}
+}
+
+func (h *Handler) handleMovieDeleted(ctx context.Context, event MediaDeleted) error {
+	h.logger.Info("processing deleted movie",
+		zap.String("title", event.Title),
+	)
+
+	if h.sweep {
+		_, err := fanOut(h.logger, h.radarr, func(inst Instance[*radarr.Client]) error {
+			return h.sweepMovies(ctx, inst)
+		})
+		return err
+	}
+
+	matched, err := fanOut(h.logger, h.radarrTargets(event.Path), func(inst Instance[*radarr.Client]) error {
+		return h.unmonitorMovie(ctx, inst, event)
+	})
+	if !matched {
+		return h.unresolved("radarr movie not resolved", event, errNoMatch)
+	}
+	return err
+}
+
+// unmonitorMovie unmonitor le film supprimé dans une instance Radarr,
+// s'il la connaît et qu'il n'y a plus de fichier.
+func (h *Handler) unmonitorMovie(ctx context.Context, inst Instance[*radarr.Client], event MediaDeleted) error {
+	movies, err := inst.Client.GetAllMovies()
+	if err != nil {
+		return fmt.Errorf("radarr get movies: %w", err)
+	}
+
+	movie, matchedBy, err := resolveMovie(event, movies)
+	if errors.Is(err, errNoMatch) {
+		return err
+	}
+	if err != nil {
+		return h.unresolved("radarr movie not resolved", event, err)
+	}
+
+	h.logger.Info("radarr movie resolved",
+		zap.String("instance", inst.Name),
+		zap.String("title", movie.Title),
+		zap.Int("id", movie.ID),
+		zap.String("matched_by", matchedBy),
+	)
+
+	// Force rescan du film pour détecter hasFile == false,
+	// et attend sa fin pour ne pas lire l'état d'avant le rescan.
+	rescan := func() (int, error) { return inst.Client.RescanMovie(movie.ID) }
+	if err := waitCommand(ctx, rescan, inst.Client.WaitForCommand); err != nil {
+		return fmt.Errorf("radarr rescan %q: %w", movie.Title, err)
+	}
+
+	movie, err = inst.Client.GetMovie(movie.ID)
+	if err != nil {
+		return fmt.Errorf("radarr get movie: %w", err)
+	}
+
+	if movie.HasFile {
+		h.logger.Info("radarr movie still has a file, left monitored",
+			zap.String("instance", inst.Name),
+			zap.String("title", movie.Title),
+			zap.Int("id", movie.ID),
+		)
+		return nil
+	}
+	if !movie.Monitored {
+		return nil
+	}
+
+	err = inst.Client.UnmonitorMovie(movie.ID)
+	h.recordUnmonitor(ctx, fmt.Sprintf("radarr/%s/movie/%d", inst.Name, movie.ID), movie.Title, err)
+	if err != nil {
+		return fmt.Errorf("radarr unmonitor %q: %w", movie.Title, err)
+	}
+	h.logger.Info("radarr movie unmonitored",
+		zap.String("instance", inst.Name),
+		zap.String("title", movie.Title),
+		zap.Int("id", movie.ID),
+	)
+	return nil
+}
+
+func (h *Handler) handleSeriesDeleted(ctx context.Context, event MediaDeleted) error {
+	h.logger.Info("processing deleted series/season/episode",
+		zap.String("item_type", event.ItemType),
+		zap.String("title", event.Title),
+		zap.String("series", event.SeriesName),
+		zap.Int("season", event.SeasonNumber),
+		zap.Int("episode", event.EpisodeNumber),
+	)
+
+	if h.sweep {
+		_, err := fanOut(h.logger, h.sonarr, func(inst Instance[*sonarr.Client]) error {
+			return h.sweepSeries(ctx, inst)
+		})
+		return err
+	}
+
+	matched, err := fanOut(h.logger, h.sonarrTargets(event.Path), func(inst Instance[*sonarr.Client]) error {
+		return h.unmonitorSeriesItem(ctx, inst, event)
+	})
+	if !matched {
+		return h.unresolved("sonarr series not resolved", event, errNoMatch)
+	}
+	return err
+}
+
+// unmonitorSeriesItem unmonitor l'épisode, la saison ou la série supprimé
+// dans une instance Sonarr, si elle connaît la série.
+func (h *Handler) unmonitorSeriesItem(ctx context.Context, inst Instance[*sonarr.Client], event MediaDeleted) error {
+	all, err := inst.Client.GetAllSeries()
+	if err != nil {
+		return fmt.Errorf("sonarr get series: %w", err)
+	}
+
+	series, matchedBy, err := resolveSeries(event, all)
+	if errors.Is(err, errNoMatch) {
+		return err
+	}
+	if err != nil {
+		return h.unresolved("sonarr series not resolved", event, err)
+	}
+
+	h.logger.Info("sonarr series resolved",
+		zap.String("instance", inst.Name),
+		zap.String("title", series.Title),
+		zap.Int("id", series.ID),
+		zap.String("matched_by", matchedBy),
+	)
+
+	// Force rescan de la série et attend sa fin.
+	rescan := func() (int, error) { return inst.Client.RescanSeries(series.ID) }
+	if err := waitCommand(ctx, rescan, inst.Client.WaitForCommand); err != nil {
+		return fmt.Errorf("sonarr rescan %q: %w", series.Title, err)
+	}
+
+	switch event.ItemType {
+	case "episode":
+		return h.unmonitorEpisode(ctx, inst, series, event.SeasonNumber, event.EpisodeNumber)
+	case "season":
+		return h.unmonitorSeason(ctx, inst, series, event.SeasonNumber)
+	default:
+		return h.unmonitorEmptySeries(ctx, inst, series.ID)
+	}
+}
+
+// unresolved gère un événement qu'on ne sait pas rattacher à un élément.
+// Un élément inconnu de Radarr/Sonarr n'est pas une erreur ; une
+// correspondance ambiguë part en dead-letter pour examen manuel.
+func (h *Handler) unresolved(msg string, event MediaDeleted, err error) error {
+	h.logger.Warn(msg,
+		zap.String("source", event.Source),
+		zap.String("title", event.Title),
+		zap.String("series", event.SeriesName),
+		zap.Int("year", event.Year),
+		zap.Error(err),
+	)
+	if errors.Is(err, errNoMatch) {
+		return nil
+	}
+	return queue.Permanent(err)
+}
+
+// unmonitorEpisode unmonitor l'épisode supprimé s'il n'a plus de fichier.
+// La série reste monitorée pour les épisodes à venir.
+func (h *Handler) unmonitorEpisode(ctx context.Context, inst Instance[*sonarr.Client], series *sonarr.Series, season, episode int) error {
+	if episode == 0 {
+		h.logger.Warn("episode event without EpisodeNumber, nothing unmonitored",
+			zap.String("instance", inst.Name),
+			zap.String("series", series.Title),
+			zap.Int("season", season),
+		)
+		return nil
+	}
+
+	episodes, err := inst.Client.GetEpisodes(series.ID)
+	if err != nil {
+		return fmt.Errorf("sonarr get episodes: %w", err)
+	}
+
+	var ids []int
+	for _, e := range episodes {
+		if e.SeasonNumber != season || e.EpisodeNumber != episode {
+			continue
+		}
+		if e.HasFile {
+			h.logger.Info("sonarr episode still has a file, left monitored",
+				zap.String("instance", inst.Name),
+				zap.String("series", series.Title),
+				zap.Int("season", season),
+				zap.Int("episode", episode),
+			)
+			continue
+		}
+		if e.Monitored {
+			ids = append(ids, e.ID)
+		}
+	}
+
+	return h.unmonitorEpisodes(ctx, inst, series, ids)
+}
+
+// unmonitorSeason unmonitor la saison supprimée si elle n'a plus aucun
+// fichier, sinon uniquement ses épisodes sans fichier.
+func (h *Handler) unmonitorSeason(ctx context.Context, inst Instance[*sonarr.Client], series *sonarr.Series, season int) error {
+	episodes, err := inst.Client.GetEpisodes(series.ID)
+	if err != nil {
+		return fmt.Errorf("sonarr get episodes: %w", err)
+	}
+
+	var ids []int
+	withFiles := 0
+	for _, e := range episodes {
+		if e.SeasonNumber != season {
+			continue
+		}
+		if e.HasFile {
+			withFiles++
+			continue
+		}
+		if e.Monitored {
+			ids = append(ids, e.ID)
+		}
+	}
+
+	if withFiles > 0 {
+		h.logger.Info("sonarr season still has files, unmonitoring missing episodes only",
+			zap.String("instance", inst.Name),
+			zap.String("series", series.Title),
+			zap.Int("season", season),
+			zap.Int("episode_files", withFiles),
+		)
+		return h.unmonitorEpisodes(ctx, inst, series, ids)
+	}
+
+	err = inst.Client.UnmonitorSeason(series.ID, season)
+	h.recordUnmonitor(ctx, fmt.Sprintf("sonarr/%s/series/%d/season/%d", inst.Name, series.ID, season), series.Title, err)
+	if err != nil {
+		return fmt.Errorf("sonarr unmonitor %q season %d: %w", series.Title, season, err)
+	}
+	h.logger.Info("sonarr season unmonitored",
+		zap.String("instance", inst.Name),
+		zap.String("series", series.Title),
+		zap.Int("id", series.ID),
+		zap.Int("season", season),
+	)
+	return nil
+}
+
+func (h *Handler) unmonitorEpisodes(ctx context.Context, inst Instance[*sonarr.Client], series *sonarr.Series, ids []int) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	err := inst.Client.UnmonitorEpisodes(ids)
+	for _, id := range ids {
+		h.recordUnmonitor(ctx, fmt.Sprintf("sonarr/%s/episode/%d", inst.Name, id), series.Title, err)
+	}
+	if err != nil {
+		return fmt.Errorf("sonarr unmonitor %q episodes %v: %w", series.Title, ids, err)
+	}
+	h.logger.Info("sonarr episodes unmonitored",
+		zap.String("instance", inst.Name),
+		zap.String("series", series.Title),
+		zap.Ints("episode_ids", ids),
+	)
+	return nil
+}
+
+// unmonitorEmptySeries unmonitor la série si elle n'a plus aucun fichier.
+func (h *Handler) unmonitorEmptySeries(ctx context.Context, inst Instance[*sonarr.Client], seriesID int) error {
+	series, err := inst.Client.GetSeries(seriesID)
+	if err != nil {
+		return fmt.Errorf("sonarr get series: %w", err)
+	}
+
+	if series.Statistics.EpisodeFileCount > 0 {
+		h.logger.Info("sonarr series still has files, left monitored",
+			zap.String("instance", inst.Name),
+			zap.String("title", series.Title),
+			zap.Int("id", series.ID),
+			zap.Int("episode_files", series.Statistics.EpisodeFileCount),
+		)
+		return nil
+	}
+	if !series.Monitored {
+		return nil
+	}
+
+	err = inst.Client.UnmonitorSeries(series.ID)
+	h.recordUnmonitor(ctx, fmt.Sprintf("sonarr/%s/series/%d", inst.Name, series.ID), series.Title, err)
+	if err != nil {
+		return fmt.Errorf("sonarr unmonitor %q: %w", series.Title, err)
+	}
+	h.logger.Info("sonarr series unmonitored",
+		zap.String("instance", inst.Name),
+		zap.String("title", series.Title),
+		zap.Int("id", series.ID),
+	)
+	return nil
+}
+
+// ─── Sweep ────────────────────────────────────────────────────────────
+
+// sweepMovies rescanne tout Radarr et unmonitor chaque film sans fichier.
+func (h *Handler) sweepMovies(ctx context.Context, inst Instance[*radarr.Client]) error {
+	// Force rescan Radarr pour détecter hasFile == false.
+	if err := waitCommand(ctx, inst.Client.RescanAll, inst.Client.WaitForCommand); err != nil {
+		return fmt.Errorf("radarr rescan: %w", err)
+	}
+
+	// Récupère les films sans fichier et les unmonitor.
+	missing, err := inst.Client.GetMissingMovies()
+	if err != nil {
+		return fmt.Errorf("radarr get missing movies: %w", err)
+	}
+
+	var errs []error
+	for _, m := range missing {
+		if !m.Monitored {
+			continue
+		}
+		err := inst.Client.UnmonitorMovie(m.ID)
+		h.recordUnmonitor(ctx, fmt.Sprintf("radarr/%s/movie/%d", inst.Name, m.ID), m.Title, err)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("radarr unmonitor %q: %w", m.Title, err))
+			continue
+		}
+		h.logger.Info("radarr movie unmonitored",
+			zap.String("instance", inst.Name),
+			zap.String("title", m.Title),
+			zap.Int("id", m.ID),
+		)
+	}
+	return errors.Join(errs...)
+}
+
+// sweepSeries rescanne tout Sonarr et unmonitor chaque série vide.
+func (h *Handler) sweepSeries(ctx context.Context, inst Instance[*sonarr.Client]) error {
+	// Force rescan Sonarr.
+	if err := waitCommand(ctx, inst.Client.RescanAll, inst.Client.WaitForCommand); err != nil {
+		return fmt.Errorf("sonarr rescan: %w", err)
+	}
+
+	// Récupère les séries vides et les unmonitor.
+	empty, err := inst.Client.GetEmptySeries()
+	if err != nil {
+		return fmt.Errorf("sonarr get empty series: %w", err)
+	}
+
+	var errs []error
+	for _, s := range empty {
+		if !s.Monitored {
+			continue
+		}
+		err := inst.Client.UnmonitorSeries(s.ID)
+		h.recordUnmonitor(ctx, fmt.Sprintf("sonarr/%s/series/%d", inst.Name, s.ID), s.Title, err)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("sonarr unmonitor %q: %w", s.Title, err))
+			continue
+		}
+		h.logger.Info("sonarr series unmonitored",
+			zap.String("instance", inst.Name),
+			zap.String("title", s.Title),
+			zap.Int("id", s.ID),
+		)
+	}
+	return errors.Join(errs...)
+}
+
+// recordUnmonitor journalise l'unmonitor de target si l'audit est activé.
+func (h *Handler) recordUnmonitor(ctx context.Context, target, title string, err error) {
+	if h.audit != nil {
+		h.audit.Record(ctx, audit.Entry{Action: audit.ActionUnmonitor, Target: target, Detail: title}, err)
+	}
+}
+
+// ─── Commands ─────────────────────────────────────────────────────────
+
+// waitCommand lance une commande *arr et attend qu'elle soit terminée.
+func waitCommand[S any](ctx context.Context, start func() (int, error), wait func(context.Context, int) (S, error)) error {
+	id, err := start()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, commandTimeout)
+	defer cancel()
+
+	_, err = wait(ctx, id)
+	return err
+}
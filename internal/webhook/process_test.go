@@ -0,0 +1,268 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"maps"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cleeryy/clarr/internal/queue"
+	"github.com/cleeryy/clarr/internal/radarr"
+	"github.com/cleeryy/clarr/internal/sonarr"
+	"go.uber.org/zap"
+)
+
+// closedURL retourne l'URL d'un port où plus rien n'écoute : toute
+// requête échoue en connexion refusée.
+func closedURL(t *testing.T) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+	return "http://" + addr
+}
+
+// dialError retourne l'erreur réelle d'une requête vers un port fermé.
+func dialError(t *testing.T) error {
+	t.Helper()
+	resp, err := http.Get(closedURL(t))
+	if err == nil {
+		resp.Body.Close()
+		t.Fatal("expected a connection error")
+	}
+	return err
+}
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"connection refused", dialError(t), false},
+		{"radarr bad request", &radarr.APIError{StatusCode: http.StatusBadRequest}, true},
+		{"sonarr not found", &sonarr.APIError{StatusCode: http.StatusNotFound}, true},
+		{"rate limited", &radarr.APIError{StatusCode: http.StatusTooManyRequests}, false},
+		{"server error", &sonarr.APIError{StatusCode: http.StatusBadGateway}, false},
+		{"untyped error", errors.New("radarr: decode movies: unexpected EOF"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := queue.IsPermanent(classify(tt.err)); got != tt.want {
+				t.Errorf("permanent = %v, want %v (err: %v)", got, tt.want, tt.err)
+			}
+		})
+	}
+}
+
+func TestProcess_RetriesUnreachableArr(t *testing.T) {
+	const maxAttempts = 3
+	q, err := queue.Open(t.TempDir(), queue.Options{Workers: 1, MaxAttempts: maxAttempts, RetryDelay: time.Millisecond}, zap.NewNop())
+	if err != nil {
+		t.Fatalf("open queue: %v", err)
+	}
+
+	radarrDown := []Instance[*radarr.Client]{{Name: "radarr", Client: radarr.New(closedURL(t), "key")}}
+	h := New("", false, radarrDown, nil, q, zap.NewNop())
+
+	// Chaque tentative est capturée avant de rendre son erreur à la file.
+	attempts := make(chan error, maxAttempts+1)
+	q.Handle(jobMediaDeleted, func(ctx context.Context, payload json.RawMessage) error {
+		err := h.process(ctx, payload)
+		attempts <- err
+		return err
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := q.Start(ctx); err != nil {
+		t.Fatalf("start queue: %v", err)
+	}
+	t.Cleanup(func() {
+		cancel()
+		_ = q.Close()
+	})
+
+	if _, err := q.Enqueue(jobMediaDeleted, MediaDeleted{Source: "jellyfin", ItemType: "movie", Title: "Dune", Year: 2021}); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 1; i <= maxAttempts; i++ {
+		select {
+		case err := <-attempts:
+			if err == nil || queue.IsPermanent(err) {
+				t.Fatalf("attempt %d: err = %v, want a transient error", i, err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("attempt %d never ran: the job was dead-lettered instead of retried", i)
+		}
+	}
+}
+
+// fakeSonarr sert une série et ses épisodes, et enregistre ce que clarr
+// unmonitor : les épisodes via /episode/monitor, la série via un PUT du
+// document complet.
+type fakeSonarr struct {
+	mu         sync.Mutex
+	episodes   []sonarr.Episode
+	unmonitors [][]int
+	seriesPuts []map[string]any
+}
+
+func (f *fakeSonarr) serve(t *testing.T) *sonarr.Client {
+	t.Helper()
+	series := map[string]any{
+		"id": 1, "title": "Severance", "year": 2022, "monitored": true, "path": "/tv/Severance",
+		"seasons": []map[string]any{
+			{"seasonNumber": 1, "monitored": true},
+			{"seasonNumber": 2, "monitored": true},
+		},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v3/series", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode([]any{series})
+	})
+	mux.HandleFunc("GET /api/v3/series/1", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(series)
+	})
+	mux.HandleFunc("PUT /api/v3/series/1", func(w http.ResponseWriter, r *http.Request) {
+		var doc map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&doc); err != nil {
+			t.Errorf("decode series put: %v", err)
+		}
+		f.mu.Lock()
+		f.seriesPuts = append(f.seriesPuts, doc)
+		f.mu.Unlock()
+		_ = json.NewEncoder(w).Encode(doc)
+	})
+	mux.HandleFunc("POST /api/v3/command", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"id":7,"name":"RescanSeries","status":"queued"}`))
+	})
+	mux.HandleFunc("GET /api/v3/command/7", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"id":7,"name":"RescanSeries","status":"completed"}`))
+	})
+	mux.HandleFunc("GET /api/v3/episode", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("seriesId") != "1" {
+			t.Errorf("episodes requested for series %q", r.URL.Query().Get("seriesId"))
+		}
+		_ = json.NewEncoder(w).Encode(f.episodes)
+	})
+	mux.HandleFunc("PUT /api/v3/episode/monitor", func(w http.ResponseWriter, r *http.Request) {
+		var body sonarr.EpisodesMonitor
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("decode episode monitor: %v", err)
+		}
+		if body.Monitored {
+			t.Errorf("episodes %v monitored instead of unmonitored", body.EpisodeIDs)
+		}
+		f.mu.Lock()
+		f.unmonitors = append(f.unmonitors, body.EpisodeIDs)
+		f.mu.Unlock()
+		w.WriteHeader(http.StatusAccepted)
+	})
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return sonarr.New(srv.URL, "key")
+}
+
+// seasons retourne l'état monitored de chaque saison d'un PUT de série.
+func seasons(doc map[string]any) map[int]bool {
+	out := make(map[int]bool)
+	list, _ := doc["seasons"].([]any)
+	for _, s := range list {
+		season, _ := s.(map[string]any)
+		number, _ := season["seasonNumber"].(float64)
+		monitored, _ := season["monitored"].(bool)
+		out[int(number)] = monitored
+	}
+	return out
+}
+
+func TestProcess_SonarrEpisodeAndSeason(t *testing.T) {
+	// S1 : E1 supprimé, E2 encore présent. S2 : plus aucun fichier.
+	episodes := []sonarr.Episode{
+		{ID: 101, SeriesID: 1, SeasonNumber: 1, EpisodeNumber: 1, Monitored: true},
+		{ID: 102, SeriesID: 1, SeasonNumber: 1, EpisodeNumber: 2, Monitored: true, HasFile: true},
+		{ID: 103, SeriesID: 1, SeasonNumber: 1, EpisodeNumber: 3, Monitored: true},
+		{ID: 201, SeriesID: 1, SeasonNumber: 2, EpisodeNumber: 1, Monitored: true},
+		{ID: 202, SeriesID: 1, SeasonNumber: 2, EpisodeNumber: 2, Monitored: true},
+	}
+
+	tests := []struct {
+		name           string
+		event          MediaDeleted
+		wantUnmonitors [][]int
+		wantSeasons    map[int]bool // nil : la série ne doit pas être modifiée
+	}{
+		{
+			name:           "episode",
+			event:          MediaDeleted{ItemType: "episode", Title: "Good News About Hell", SeriesName: "Severance", SeasonNumber: 1, EpisodeNumber: 1},
+			wantUnmonitors: [][]int{{101}},
+		},
+		{
+			name:           "season with files left",
+			event:          MediaDeleted{ItemType: "season", Title: "Season 1", SeriesName: "Severance", SeasonNumber: 1},
+			wantUnmonitors: [][]int{{101, 103}},
+		},
+		{
+			name:        "empty season",
+			event:       MediaDeleted{ItemType: "season", Title: "Season 2", SeriesName: "Severance", SeasonNumber: 2},
+			wantSeasons: map[int]bool{1: true, 2: false},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeSonarr{episodes: episodes}
+			client := fake.serve(t)
+
+			q, err := queue.Open(t.TempDir(), queue.Options{}, zap.NewNop())
+			if err != nil {
+				t.Fatalf("open queue: %v", err)
+			}
+			t.Cleanup(func() { _ = q.Close() })
+			h := New("", false, nil, []Instance[*sonarr.Client]{{Name: "default", Client: client}}, q, zap.NewNop())
+
+			tt.event.Source = "jellyfin"
+			payload, _ := json.Marshal(tt.event)
+			if err := h.process(context.Background(), payload); err != nil {
+				t.Fatalf("process: %v", err)
+			}
+
+			fake.mu.Lock()
+			defer fake.mu.Unlock()
+			if !slices.EqualFunc(fake.unmonitors, tt.wantUnmonitors, slices.Equal[[]int]) {
+				t.Errorf("unmonitored episodes = %v, want %v", fake.unmonitors, tt.wantUnmonitors)
+			}
+
+			if tt.wantSeasons == nil {
+				if len(fake.seriesPuts) != 0 {
+					t.Errorf("series updated: %v, only episodes should be unmonitored", fake.seriesPuts)
+				}
+				return
+			}
+			if len(fake.seriesPuts) != 1 {
+				t.Fatalf("series updated %d times, want 1", len(fake.seriesPuts))
+			}
+			doc := fake.seriesPuts[0]
+			if doc["monitored"] != true {
+				t.Errorf("series monitored = %v, want true", doc["monitored"])
+			}
+			if got := seasons(doc); !maps.Equal(got, tt.wantSeasons) {
+				t.Errorf("seasons monitored = %v, want %v", got, tt.wantSeasons)
+			}
+		})
+	}
+}
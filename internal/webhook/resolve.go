@@ -0,0 +1,205 @@
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/cleeryy/clarr/internal/lidarr"
+	"github.com/cleeryy/clarr/internal/radarr"
+	"github.com/cleeryy/clarr/internal/readarr"
+	"github.com/cleeryy/clarr/internal/sonarr"
+)
+
+// errNoMatch est retourné quand aucun élément ne correspond à l'événement.
+var errNoMatch = errors.New("no matching item")
+
+// matcher associe un critère de correspondance à son nom (pour les logs).
+// Un fn nil signifie que l'événement ne porte pas l'information nécessaire.
+type matcher[T any] struct {
+	name string
+	fn   func(T) bool
+}
+
+// resolve applique les matchers dans l'ordre et retourne le premier
+// élément qui correspond de façon unique. Plusieurs correspondances
+// pour un même critère sont une erreur : on ne devine jamais.
+func resolve[T any](items []T, matchers []matcher[T]) (*T, string, error) {
+	for _, m := range matchers {
+		if m.fn == nil {
+			continue
+		}
+
+		var found []int
+		for i := range items {
+			if m.fn(items[i]) {
+				found = append(found, i)
+			}
+		}
+
+		switch len(found) {
+		case 0:
+			continue
+		case 1:
+			return &items[found[0]], m.name, nil
+		default:
+			return nil, m.name, fmt.Errorf("%d items match by %s", len(found), m.name)
+		}
+	}
+	return nil, "", errNoMatch
+}
+
+// resolveMovie retrouve le film Radarr supprimé du serveur média,
+// par TMDb, IMDb, chemin puis titre + année.
+func resolveMovie(event MediaDeleted, movies []radarr.Movie) (*radarr.Movie, string, error) {
+	var byTmdb, byImdb, byPath, byTitle func(radarr.Movie) bool
+
+	if id := atoi(event.TmdbID); id > 0 {
+		byTmdb = func(m radarr.Movie) bool { return m.TmdbID == id }
+	}
+	if id := strings.TrimSpace(event.ImdbID); id != "" {
+		byImdb = func(m radarr.Movie) bool { return strings.EqualFold(m.ImdbID, id) }
+	}
+	if event.Path != "" {
+		byPath = func(m radarr.Movie) bool { return pathWithin(event.Path, m.Path) }
+	}
+	if title := normalizeTitle(event.Title); title != "" {
+		byTitle = func(m radarr.Movie) bool {
+			return normalizeTitle(m.Title) == title && (event.Year == 0 || m.Year == event.Year)
+		}
+	}
+
+	return resolve(movies, []matcher[radarr.Movie]{
+		{"tmdb", byTmdb},
+		{"imdb", byImdb},
+		{"path", byPath},
+		{"title", byTitle},
+	})
+}
+
+// resolveSeries retrouve la série Sonarr concernée par l'événement.
+// Pour un épisode ou une saison, les provider IDs ne sont pas ceux de la
+// série : on se rabat donc sur le chemin et le nom de la série.
+func resolveSeries(event MediaDeleted, series []sonarr.Series) (*sonarr.Series, string, error) {
+	var byTvdb, byTmdb, byImdb, byPath, byTitle func(sonarr.Series) bool
+
+	// L'année d'un épisode est sa date de diffusion, pas celle de la série.
+	title, year := event.SeriesName, 0
+	if event.ItemType == "series" {
+		title, year = event.Title, event.Year
+
+		if id := atoi(event.TvdbID); id > 0 {
+			byTvdb = func(s sonarr.Series) bool { return s.TvdbID == id }
+		}
+		if id := atoi(event.TmdbID); id > 0 {
+			byTmdb = func(s sonarr.Series) bool { return s.TmdbID == id }
+		}
+		if id := strings.TrimSpace(event.ImdbID); id != "" {
+			byImdb = func(s sonarr.Series) bool { return strings.EqualFold(s.ImdbID, id) }
+		}
+	}
+	if event.Path != "" {
+		byPath = func(s sonarr.Series) bool { return pathWithin(event.Path, s.Path) }
+	}
+	if title := normalizeTitle(title); title != "" {
+		byTitle = func(s sonarr.Series) bool {
+			return normalizeTitle(s.Title) == title && (year == 0 || s.Year == year)
+		}
+	}
+
+	return resolve(series, []matcher[sonarr.Series]{
+		{"tvdb", byTvdb},
+		{"tmdb", byTmdb},
+		{"imdb", byImdb},
+		{"path", byPath},
+		{"title", byTitle},
+	})
+}
+
+// resolveAlbum retrouve l'album Lidarr concerné par l'événement, par
+// release group MusicBrainz puis titre + artiste. Pour une piste, le
+// provider ID et l'année sont ceux de la piste : seul le nom de l'album
+// est utilisé. Un album n'a pas de dossier propre dans Lidarr, le chemin
+// ne sert qu'à restreindre la recherche à l'artiste.
+func resolveAlbum(event MediaDeleted, albums []lidarr.Album) (*lidarr.Album, string, error) {
+	var byMbid, byTitle func(lidarr.Album) bool
+
+	title, year := event.Album, 0
+	if event.ItemType == "musicalbum" {
+		title, year = event.Title, event.Year
+
+		if id := strings.TrimSpace(event.MusicBrainzReleaseGroup); id != "" {
+			byMbid = func(a lidarr.Album) bool { return strings.EqualFold(a.ForeignAlbumID, id) }
+		}
+	}
+	if title := normalizeTitle(title); title != "" {
+		artist := normalizeTitle(event.Artist)
+		byTitle = func(a lidarr.Album) bool {
+			return normalizeTitle(a.Title) == title &&
+				(artist == "" || normalizeTitle(a.Artist.ArtistName) == artist) &&
+				(event.Path == "" || pathWithin(event.Path, a.Artist.Path)) &&
+				(year == 0 || a.ReleaseDate.Year() == year)
+		}
+	}
+
+	return resolve(albums, []matcher[lidarr.Album]{
+		{"musicbrainz", byMbid},
+		{"title", byTitle},
+	})
+}
+
+// resolveBook retrouve le livre Readarr concerné par l'événement, par
+// titre + auteur. Les serveurs média n'exposent pas d'ID Goodreads, et un
+// livre n'a pas de dossier propre : le chemin restreint la recherche à
+// l'auteur.
+func resolveBook(event MediaDeleted, books []readarr.Book) (*readarr.Book, string, error) {
+	var byTitle func(readarr.Book) bool
+
+	if title := normalizeTitle(event.Title); title != "" {
+		author := normalizeTitle(event.Artist)
+		byTitle = func(b readarr.Book) bool {
+			return normalizeTitle(b.Title) == title &&
+				(author == "" || normalizeTitle(b.Author.AuthorName) == author) &&
+				(event.Path == "" || pathWithin(event.Path, b.Author.Path))
+		}
+	}
+
+	return resolve(books, []matcher[readarr.Book]{
+		{"title", byTitle},
+	})
+}
+
+// ─── Helpers ──────────────────────────────────────────────────────────
+
+// pathWithin indique si p est root ou se trouve sous root,
+// en comparant segment par segment.
+func pathWithin(p, root string) bool {
+	if p == "" || root == "" {
+		return false
+	}
+	p, root = path.Clean(p), path.Clean(root)
+	return p == root || strings.HasPrefix(p, strings.TrimSuffix(root, "/")+"/")
+}
+
+// normalizeTitle ne garde que les lettres et chiffres en minuscules,
+// pour comparer "Spider-Man: No Way Home" et "Spider Man No Way Home".
+func normalizeTitle(title string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(title) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func atoi(s string) int {
+	n, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil {
+		return 0
+	}
+	return n
+}
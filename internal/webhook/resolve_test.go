@@ -0,0 +1,168 @@
+package webhook
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cleeryy/clarr/internal/lidarr"
+	"github.com/cleeryy/clarr/internal/radarr"
+	"github.com/cleeryy/clarr/internal/readarr"
+	"github.com/cleeryy/clarr/internal/sonarr"
+)
+
+func TestResolveMovie(t *testing.T) {
+	movies := []radarr.Movie{
+		{ID: 1, Title: "Dune", Year: 1984, TmdbID: 841, ImdbID: "tt0087182", Path: "/movies/Dune (1984)"},
+		{ID: 2, Title: "Dune", Year: 2021, TmdbID: 438631, ImdbID: "tt1160419", Path: "/movies/Dune (2021)"},
+		{ID: 3, Title: "Spider-Man: No Way Home", Year: 2021, TmdbID: 634649, Path: "/movies/Spider-Man No Way Home (2021)"},
+	}
+
+	tests := []struct {
+		name      string
+		event     MediaDeleted
+		wantID    int
+		matchedBy string
+		wantErr   bool
+	}{
+		{"tmdb", MediaDeleted{Title: "Dune", TmdbID: "438631"}, 2, "tmdb", false},
+		{"imdb", MediaDeleted{Title: "Dune", ImdbID: "tt0087182"}, 1, "imdb", false},
+		{"path", MediaDeleted{Path: "/movies/Dune (2021)/Dune.mkv"}, 2, "path", false},
+		{"path prefix is not a segment", MediaDeleted{Path: "/movies/Dune (2021) Extended/Dune.mkv"}, 0, "", true},
+		{"title and year", MediaDeleted{Title: "Dune", Year: 1984}, 1, "title", false},
+		{"title normalized", MediaDeleted{Title: "Spider Man No Way Home"}, 3, "title", false},
+		{"title ambiguous", MediaDeleted{Title: "Dune"}, 0, "title", true},
+		{"unknown", MediaDeleted{Title: "Alien", TmdbID: "348"}, 0, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, by, err := resolveMovie(tt.event, movies)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got movie %d", m.ID)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if m.ID != tt.wantID || by != tt.matchedBy {
+				t.Errorf("got movie %d by %q, want %d by %q", m.ID, by, tt.wantID, tt.matchedBy)
+			}
+		})
+	}
+}
+
+func TestResolveSeries_EpisodeIgnoresProviderIDs(t *testing.T) {
+	series := []sonarr.Series{
+		{ID: 1, Title: "The Office (US)", TvdbID: 73244},
+		{ID: 2, Title: "Severance", TvdbID: 371980},
+	}
+
+	// Le TVDb d'un épisode n'est pas celui de la série.
+	event := MediaDeleted{
+		ItemType:   "episode",
+		Title:      "Good News About Hell",
+		SeriesName: "Severance",
+		TvdbID:     "73244",
+	}
+
+	s, by, err := resolveSeries(event, series)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if s.ID != 2 || by != "title" {
+		t.Errorf("got series %d by %q, want 2 by \"title\"", s.ID, by)
+	}
+}
+
+func TestResolveAlbum(t *testing.T) {
+	album := func(id int, title, artist string, year int, mbid string) lidarr.Album {
+		a := lidarr.Album{ID: id, Title: title, ForeignAlbumID: mbid, ReleaseDate: time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC)}
+		a.Artist.ArtistName = artist
+		a.Artist.Path = "/music/" + artist
+		return a
+	}
+	albums := []lidarr.Album{
+		album(1, "Greatest Hits", "Queen", 1981, "b6b1a6b4-0000-0000-0000-000000000001"),
+		album(2, "Greatest Hits", "ABBA", 1975, "b6b1a6b4-0000-0000-0000-000000000002"),
+		album(3, "Kid A", "Radiohead", 2000, "b6b1a6b4-0000-0000-0000-000000000003"),
+	}
+
+	tests := []struct {
+		name      string
+		event     MediaDeleted
+		wantID    int
+		matchedBy string
+		wantErr   bool
+	}{
+		{"musicbrainz", MediaDeleted{ItemType: "musicalbum", Title: "Greatest Hits", MusicBrainzReleaseGroup: "B6B1A6B4-0000-0000-0000-000000000002"}, 2, "musicbrainz", false},
+		{"title and artist", MediaDeleted{ItemType: "musicalbum", Title: "Greatest Hits", Artist: "Queen"}, 1, "title", false},
+		{"title and artist path", MediaDeleted{ItemType: "musicalbum", Title: "Greatest Hits", Path: "/music/ABBA/Greatest Hits"}, 2, "title", false},
+		{"title ambiguous", MediaDeleted{ItemType: "musicalbum", Title: "Greatest Hits"}, 0, "title", true},
+		{"track uses album name", MediaDeleted{ItemType: "audio", Title: "Idioteque", Album: "Kid A", Year: 2021}, 3, "title", false},
+		{"track ignores provider ID", MediaDeleted{ItemType: "audio", Title: "Bohemian Rhapsody", Album: "Greatest Hits", Artist: "Queen", MusicBrainzReleaseGroup: "b6b1a6b4-0000-0000-0000-000000000002"}, 1, "title", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, by, err := resolveAlbum(tt.event, albums)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got album %d", a.ID)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if a.ID != tt.wantID || by != tt.matchedBy {
+				t.Errorf("got album %d by %q, want %d by %q", a.ID, by, tt.wantID, tt.matchedBy)
+			}
+		})
+	}
+}
+
+func TestResolveBook(t *testing.T) {
+	book := func(id int, title, author string) readarr.Book {
+		b := readarr.Book{ID: id, Title: title}
+		b.Author.AuthorName = author
+		b.Author.Path = "/books/" + author
+		return b
+	}
+	books := []readarr.Book{
+		book(1, "Dune", "Frank Herbert"),
+		book(2, "Dune", "Brian Herbert"),
+		book(3, "Project Hail Mary", "Andy Weir"),
+	}
+
+	tests := []struct {
+		name    string
+		event   MediaDeleted
+		wantID  int
+		wantErr bool
+	}{
+		{"title", MediaDeleted{ItemType: "audiobook", Title: "Project Hail Mary"}, 3, false},
+		{"title and author", MediaDeleted{ItemType: "book", Title: "Dune", Artist: "Frank Herbert"}, 1, false},
+		{"title and author path", MediaDeleted{ItemType: "book", Title: "Dune", Path: "/books/Brian Herbert/Dune.epub"}, 2, false},
+		{"title ambiguous", MediaDeleted{ItemType: "book", Title: "Dune"}, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, _, err := resolveBook(tt.event, books)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got book %d", b.ID)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if b.ID != tt.wantID {
+				t.Errorf("got book %d, want %d", b.ID, tt.wantID)
+			}
+		})
+	}
+}